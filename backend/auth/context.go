@@ -0,0 +1,27 @@
+package auth
+
+import (
+	"context"
+)
+
+// contextKey keeps auth context values from colliding with other packages
+type contextKey struct{}
+
+// WithClaims returns a copy of ctx carrying the caller's claims
+func WithClaims(ctx context.Context, claims *Claims) context.Context {
+	return context.WithValue(ctx, contextKey{}, claims)
+}
+
+// ClaimsFromContext returns the claims of the authenticated caller, if any
+func ClaimsFromContext(ctx context.Context) (*Claims, bool) {
+	claims, ok := ctx.Value(contextKey{}).(*Claims)
+	return claims, ok && claims != nil
+}
+
+// SubjectFromContext returns the authenticated caller's subject, or "" for anonymous requests
+func SubjectFromContext(ctx context.Context) string {
+	if claims, ok := ClaimsFromContext(ctx); ok {
+		return claims.Subject
+	}
+	return ""
+}
@@ -0,0 +1,261 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Signing algorithms accepted in the JWT "alg" header
+const (
+	AlgorithmHS256 = "HS256"
+	AlgorithmRS256 = "RS256"
+)
+
+// Token errors
+var (
+	// ErrMissingToken is returned when a request carries no bearer token
+	ErrMissingToken = errors.New("missing bearer token")
+	// ErrInvalidToken is returned for malformed tokens and bad signatures
+	ErrInvalidToken = errors.New("invalid token")
+	// ErrExpiredToken is returned for tokens past their exp claim
+	ErrExpiredToken = errors.New("token has expired")
+	// ErrUnsupportedAlgorithm is returned for tokens signed with an algorithm the verifier has no key for
+	ErrUnsupportedAlgorithm = errors.New("unsupported token algorithm")
+)
+
+// Audience is the aud claim, which JWTs encode as either a string or an array
+type Audience []string
+
+// UnmarshalJSON accepts both encodings of the aud claim
+func (a *Audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = Audience{single}
+		return nil
+	}
+	var many []string
+	if err := json.Unmarshal(data, &many); err != nil {
+		return err
+	}
+	*a = many
+	return nil
+}
+
+// Contains reports whether the audience includes value
+func (a Audience) Contains(value string) bool {
+	for _, audience := range a {
+		if audience == value {
+			return true
+		}
+	}
+	return false
+}
+
+// Claims are the JWT claims the API understands. Subject identifies the caller.
+type Claims struct {
+	Subject   string   `json:"sub"`
+	Email     string   `json:"email,omitempty"`
+	Roles     []string `json:"roles,omitempty"`
+	Issuer    string   `json:"iss,omitempty"`
+	Audience  Audience `json:"aud,omitempty"`
+	IssuedAt  int64    `json:"iat,omitempty"`
+	NotBefore int64    `json:"nbf,omitempty"`
+	ExpiresAt int64    `json:"exp"`
+	ID        string   `json:"jti,omitempty"`
+}
+
+// HasRole reports whether the claims grant role
+func (c Claims) HasRole(role string) bool {
+	for _, granted := range c.Roles {
+		if granted == role {
+			return true
+		}
+	}
+	return false
+}
+
+// tokenHeader is the JOSE header of a JWT
+type tokenHeader struct {
+	Algorithm string `json:"alg"`
+	Type      string `json:"typ,omitempty"`
+}
+
+// VerifierConfig configures a Verifier. At least one of HMACSecret and
+// RSAPublicKey must be set; tokens are only accepted for algorithms that have a key.
+type VerifierConfig struct {
+	HMACSecret   []byte
+	RSAPublicKey *rsa.PublicKey
+	// Issuer and Audience, when set, must match the iss and aud claims
+	Issuer   string
+	Audience string
+	// Leeway allows for clock skew when checking exp and nbf
+	Leeway time.Duration
+}
+
+// Verifier validates HS256 and RS256 signed JWTs
+type Verifier struct {
+	config VerifierConfig
+	now    func() time.Time
+}
+
+// NewVerifier creates a Verifier
+func NewVerifier(config VerifierConfig) (*Verifier, error) {
+	if len(config.HMACSecret) == 0 && config.RSAPublicKey == nil {
+		return nil, errors.New("auth: a verifier needs an HMAC secret or an RSA public key")
+	}
+	return &Verifier{config: config, now: time.Now}, nil
+}
+
+// Verify checks a token's signature and time and issuer constraints and returns its claims
+func (v *Verifier) Verify(token string) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: expected three segments", ErrInvalidToken)
+	}
+
+	var header tokenHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("%w: bad header", ErrInvalidToken)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: bad signature encoding", ErrInvalidToken)
+	}
+
+	// The algorithm picks the key, so an HS256 token can never be checked
+	// against the RSA public key and "none" is never accepted
+	signed := []byte(parts[0] + "." + parts[1])
+	switch header.Algorithm {
+	case AlgorithmHS256:
+		if len(v.config.HMACSecret) == 0 {
+			return nil, fmt.Errorf("%w: %s", ErrUnsupportedAlgorithm, header.Algorithm)
+		}
+		if !hmac.Equal(signature, hmacSHA256(v.config.HMACSecret, signed)) {
+			return nil, fmt.Errorf("%w: signature mismatch", ErrInvalidToken)
+		}
+	case AlgorithmRS256:
+		if v.config.RSAPublicKey == nil {
+			return nil, fmt.Errorf("%w: %s", ErrUnsupportedAlgorithm, header.Algorithm)
+		}
+		digest := sha256.Sum256(signed)
+		if err := rsa.VerifyPKCS1v15(v.config.RSAPublicKey, crypto.SHA256, digest[:], signature); err != nil {
+			return nil, fmt.Errorf("%w: signature mismatch", ErrInvalidToken)
+		}
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedAlgorithm, header.Algorithm)
+	}
+
+	var claims Claims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("%w: bad claims", ErrInvalidToken)
+	}
+	if err := v.validateClaims(claims); err != nil {
+		return nil, err
+	}
+	return &claims, nil
+}
+
+// validateClaims checks the registered claims of a token with a valid signature
+func (v *Verifier) validateClaims(claims Claims) error {
+	now := v.now()
+	leeway := v.config.Leeway
+
+	if claims.Subject == "" {
+		return fmt.Errorf("%w: missing sub claim", ErrInvalidToken)
+	}
+	if claims.ExpiresAt == 0 {
+		return fmt.Errorf("%w: missing exp claim", ErrInvalidToken)
+	}
+	if now.After(time.Unix(claims.ExpiresAt, 0).Add(leeway)) {
+		return ErrExpiredToken
+	}
+	if claims.NotBefore != 0 && now.Add(leeway).Before(time.Unix(claims.NotBefore, 0)) {
+		return fmt.Errorf("%w: token not valid yet", ErrInvalidToken)
+	}
+	if v.config.Issuer != "" && claims.Issuer != v.config.Issuer {
+		return fmt.Errorf("%w: unexpected issuer", ErrInvalidToken)
+	}
+	if v.config.Audience != "" && !claims.Audience.Contains(v.config.Audience) {
+		return fmt.Errorf("%w: unexpected audience", ErrInvalidToken)
+	}
+	return nil
+}
+
+// Signer mints signed JWTs
+type Signer struct {
+	algorithm  string
+	hmacSecret []byte
+	rsaKey     *rsa.PrivateKey
+}
+
+// NewHS256Signer creates a Signer that signs with an HMAC secret
+func NewHS256Signer(secret []byte) *Signer {
+	return &Signer{algorithm: AlgorithmHS256, hmacSecret: secret}
+}
+
+// NewRS256Signer creates a Signer that signs with an RSA private key
+func NewRS256Signer(key *rsa.PrivateKey) *Signer {
+	return &Signer{algorithm: AlgorithmRS256, rsaKey: key}
+}
+
+// Sign encodes and signs claims
+func (s *Signer) Sign(claims Claims) (string, error) {
+	header, err := encodeSegment(tokenHeader{Algorithm: s.algorithm, Type: "JWT"})
+	if err != nil {
+		return "", err
+	}
+	payload, err := encodeSegment(claims)
+	if err != nil {
+		return "", err
+	}
+	signed := header + "." + payload
+
+	var signature []byte
+	switch s.algorithm {
+	case AlgorithmHS256:
+		signature = hmacSHA256(s.hmacSecret, []byte(signed))
+	case AlgorithmRS256:
+		digest := sha256.Sum256([]byte(signed))
+		signature, err = rsa.SignPKCS1v15(rand.Reader, s.rsaKey, crypto.SHA256, digest[:])
+		if err != nil {
+			return "", err
+		}
+	default:
+		return "", fmt.Errorf("%w: %q", ErrUnsupportedAlgorithm, s.algorithm)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// hmacSHA256 computes an HS256 signature
+func hmacSHA256(secret, data []byte) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(data)
+	return mac.Sum(nil)
+}
+
+// decodeSegment decodes a base64url JSON token segment
+func decodeSegment(segment string, target interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, target)
+}
+
+// encodeSegment encodes a value as a base64url JSON token segment
+func encodeSegment(value interface{}) (string, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
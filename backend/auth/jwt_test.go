@@ -0,0 +1,218 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testSecret = []byte("test-secret")
+
+// newTestVerifier creates a Verifier whose clock is fixed at now
+func newTestVerifier(t *testing.T, config VerifierConfig, now time.Time) *Verifier {
+	t.Helper()
+	verifier, err := NewVerifier(config)
+	if err != nil {
+		t.Fatalf("NewVerifier: %v", err)
+	}
+	verifier.now = func() time.Time { return now }
+	return verifier
+}
+
+// signHS256 signs claims with the test secret
+func signHS256(t *testing.T, claims Claims) string {
+	t.Helper()
+	token, err := NewHS256Signer(testSecret).Sign(claims)
+	if err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+	return token
+}
+
+// withHeader replaces the header of a token, keeping its claims and signature
+func withHeader(token, header string) string {
+	parts := strings.Split(token, ".")
+	parts[0] = base64.RawURLEncoding.EncodeToString([]byte(header))
+	return strings.Join(parts, ".")
+}
+
+func TestVerifyHS256(t *testing.T) {
+	token, err := MintTestToken(testSecret, "alice", []string{RoleMerchandiser}, time.Minute)
+	if err != nil {
+		t.Fatalf("MintTestToken: %v", err)
+	}
+
+	claims, err := newTestVerifier(t, VerifierConfig{HMACSecret: testSecret}, time.Now()).Verify(token)
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if claims.Subject != "alice" || !claims.HasRole(RoleMerchandiser) {
+		t.Errorf("claims = %+v, want subject alice with role merchandiser", claims)
+	}
+
+	other := newTestVerifier(t, VerifierConfig{HMACSecret: []byte("other-secret")}, time.Now())
+	if _, err := other.Verify(token); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Verify with another secret = %v, want ErrInvalidToken", err)
+	}
+}
+
+func TestVerifyRS256(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	token, err := NewRS256Signer(key).Sign(Claims{Subject: "alice", ExpiresAt: time.Now().Add(time.Minute).Unix()})
+	if err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+
+	verifier := newTestVerifier(t, VerifierConfig{RSAPublicKey: &key.PublicKey}, time.Now())
+	if claims, err := verifier.Verify(token); err != nil || claims.Subject != "alice" {
+		t.Fatalf("Verify = %+v, %v; want subject alice", claims, err)
+	}
+
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	other := newTestVerifier(t, VerifierConfig{RSAPublicKey: &otherKey.PublicKey}, time.Now())
+	if _, err := other.Verify(token); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Verify with another key = %v, want ErrInvalidToken", err)
+	}
+
+	// An HS256 verifier has no key for RS256 tokens
+	hmacOnly := newTestVerifier(t, VerifierConfig{HMACSecret: testSecret}, time.Now())
+	if _, err := hmacOnly.Verify(token); !errors.Is(err, ErrUnsupportedAlgorithm) {
+		t.Errorf("Verify RS256 with an HMAC verifier = %v, want ErrUnsupportedAlgorithm", err)
+	}
+}
+
+// TestVerifyRejectsAlgorithmConfusion checks that a token HMAC-signed with the
+// RSA public key, or not signed at all, is never accepted
+func TestVerifyRejectsAlgorithmConfusion(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		t.Fatalf("MarshalPKIXPublicKey: %v", err)
+	}
+	publicPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+
+	claims := Claims{Subject: "mallory", Roles: []string{RoleAdmin}, ExpiresAt: time.Now().Add(time.Minute).Unix()}
+	forged, err := NewHS256Signer(publicPEM).Sign(claims)
+	if err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+	verifier := newTestVerifier(t, VerifierConfig{RSAPublicKey: &key.PublicKey}, time.Now())
+	if _, err := verifier.Verify(forged); !errors.Is(err, ErrUnsupportedAlgorithm) {
+		t.Errorf("Verify HS256 signed with the public key = %v, want ErrUnsupportedAlgorithm", err)
+	}
+
+	unsigned := withHeader(signHS256(t, claims), `{"alg":"none","typ":"JWT"}`)
+	unsigned = unsigned[:strings.LastIndex(unsigned, ".")+1]
+	for _, config := range []VerifierConfig{{HMACSecret: testSecret}, {RSAPublicKey: &key.PublicKey}} {
+		if _, err := newTestVerifier(t, config, time.Now()).Verify(unsigned); !errors.Is(err, ErrUnsupportedAlgorithm) {
+			t.Errorf("Verify alg none = %v, want ErrUnsupportedAlgorithm", err)
+		}
+	}
+}
+
+func TestVerifyRejectsTamperedTokens(t *testing.T) {
+	verifier := newTestVerifier(t, VerifierConfig{HMACSecret: testSecret}, time.Now())
+	token := signHS256(t, Claims{Subject: "alice", ExpiresAt: time.Now().Add(time.Minute).Unix()})
+	parts := strings.Split(token, ".")
+
+	admin, err := encodeSegment(Claims{Subject: "alice", Roles: []string{RoleAdmin}, ExpiresAt: time.Now().Add(time.Minute).Unix()})
+	if err != nil {
+		t.Fatalf("encodeSegment: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"changed claims", parts[0] + "." + admin + "." + parts[2]},
+		{"two segments", parts[0] + "." + parts[1]},
+		{"bad signature encoding", parts[0] + "." + parts[1] + ".!!"},
+		{"bad header", "e30x." + parts[1] + "." + parts[2]},
+		{"empty", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := verifier.Verify(tt.token); !errors.Is(err, ErrInvalidToken) {
+				t.Errorf("Verify = %v, want ErrInvalidToken", err)
+			}
+		})
+	}
+}
+
+func TestVerifyClaims(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	leeway := 30 * time.Second
+	config := VerifierConfig{HMACSecret: testSecret, Issuer: "shop", Audience: "api", Leeway: leeway}
+	valid := func() Claims {
+		return Claims{
+			Subject:   "alice",
+			Issuer:    "shop",
+			Audience:  Audience{"api"},
+			ExpiresAt: now.Add(time.Minute).Unix(),
+		}
+	}
+
+	tests := []struct {
+		name   string
+		change func(*Claims)
+		want   error
+	}{
+		{"valid", func(*Claims) {}, nil},
+		{"expired within leeway", func(c *Claims) { c.ExpiresAt = now.Add(-leeway / 2).Unix() }, nil},
+		{"expired past leeway", func(c *Claims) { c.ExpiresAt = now.Add(-2 * leeway).Unix() }, ErrExpiredToken},
+		{"not before within leeway", func(c *Claims) { c.NotBefore = now.Add(leeway / 2).Unix() }, nil},
+		{"not before past leeway", func(c *Claims) { c.NotBefore = now.Add(2 * leeway).Unix() }, ErrInvalidToken},
+		{"missing exp", func(c *Claims) { c.ExpiresAt = 0 }, ErrInvalidToken},
+		{"missing sub", func(c *Claims) { c.Subject = "" }, ErrInvalidToken},
+		{"other issuer", func(c *Claims) { c.Issuer = "elsewhere" }, ErrInvalidToken},
+		{"missing issuer", func(c *Claims) { c.Issuer = "" }, ErrInvalidToken},
+		{"other audience", func(c *Claims) { c.Audience = Audience{"billing"} }, ErrInvalidToken},
+		{"audience among several", func(c *Claims) { c.Audience = Audience{"billing", "api"} }, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := valid()
+			tt.change(&claims)
+			_, err := newTestVerifier(t, config, now).Verify(signHS256(t, claims))
+			if tt.want == nil && err != nil {
+				t.Errorf("Verify = %v, want success", err)
+			}
+			if tt.want != nil && !errors.Is(err, tt.want) {
+				t.Errorf("Verify = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestAudienceAcceptsStringAndArray(t *testing.T) {
+	for _, payload := range []string{`{"aud":"api"}`, `{"aud":["billing","api"]}`} {
+		var claims Claims
+		if err := decodeSegment(base64.RawURLEncoding.EncodeToString([]byte(payload)), &claims); err != nil {
+			t.Fatalf("decode %s: %v", payload, err)
+		}
+		if !claims.Audience.Contains("api") {
+			t.Errorf("audience of %s = %v, want it to contain api", payload, claims.Audience)
+		}
+	}
+}
+
+func TestNewVerifierNeedsAKey(t *testing.T) {
+	if _, err := NewVerifier(VerifierConfig{}); err == nil {
+		t.Error("NewVerifier without keys succeeded")
+	}
+}
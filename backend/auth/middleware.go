@@ -0,0 +1,79 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"ecommerce-backend/logger"
+)
+
+// Authenticate validates the bearer token in the Authorization header, if there
+// is one, and puts its claims into the request context. Requests without a
+// token pass through anonymously; requests with a bad token get 401.
+func Authenticate(verifier *Verifier) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token, err := bearerToken(r)
+			if errors.Is(err, ErrMissingToken) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if err == nil {
+				var claims *Claims
+				claims, err = verifier.Verify(token)
+				if err == nil {
+					next.ServeHTTP(w, r.WithContext(WithClaims(r.Context(), claims)))
+					return
+				}
+			}
+
+			logger.WarnContext(r.Context(), "Rejected bearer token", map[string]interface{}{
+				"component": "auth",
+				"method":    r.Method,
+				"path":      r.URL.Path,
+				"error":     err.Error(),
+			})
+			unauthorized(w, "invalid_token", err)
+		})
+	}
+}
+
+// RequireAuth rejects requests that Authenticate didn't attach claims to
+func RequireAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := ClaimsFromContext(r.Context()); !ok {
+			logger.WarnContext(r.Context(), "Unauthenticated request to protected route", map[string]interface{}{
+				"component": "auth",
+				"method":    r.Method,
+				"path":      r.URL.Path,
+			})
+			unauthorized(w, "", ErrMissingToken)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// bearerToken extracts the token from an "Authorization: Bearer <token>" header
+func bearerToken(r *http.Request) (string, error) {
+	header := strings.TrimSpace(r.Header.Get("Authorization"))
+	if header == "" {
+		return "", ErrMissingToken
+	}
+	scheme, token, found := strings.Cut(header, " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") || strings.TrimSpace(token) == "" {
+		return "", errors.New("Authorization header must be \"Bearer <token>\"")
+	}
+	return strings.TrimSpace(token), nil
+}
+
+// unauthorized writes a 401 with a WWW-Authenticate challenge
+func unauthorized(w http.ResponseWriter, code string, err error) {
+	challenge := `Bearer realm="api"`
+	if code != "" {
+		challenge += `, error="` + code + `"`
+	}
+	w.Header().Set("WWW-Authenticate", challenge)
+	http.Error(w, "Unauthorized: "+err.Error(), http.StatusUnauthorized)
+}
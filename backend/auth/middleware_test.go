@@ -0,0 +1,145 @@
+package auth
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"ecommerce-backend/logger"
+)
+
+func TestMain(m *testing.M) {
+	if err := logger.Init(logger.LogConfig{Level: "error", Output: "stdout"}); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	logger.Logger.SetOutput(io.Discard)
+	os.Exit(m.Run())
+}
+
+// subjectHandler writes the caller's subject, or "anonymous"
+var subjectHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	subject := SubjectFromContext(r.Context())
+	if subject == "" {
+		subject = "anonymous"
+	}
+	io.WriteString(w, subject)
+})
+
+// serve runs handler on a GET request with the given Authorization header
+func serve(handler http.Handler, authorization string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodGet, "/api/admin/products", nil)
+	if authorization != "" {
+		r.Header.Set("Authorization", authorization)
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
+
+// mint returns an Authorization header for subject with roles
+func mint(t *testing.T, subject string, roles ...string) string {
+	t.Helper()
+	token, err := MintTestToken(testSecret, subject, roles, time.Minute)
+	if err != nil {
+		t.Fatalf("MintTestToken: %v", err)
+	}
+	return "Bearer " + token
+}
+
+func TestAuthenticate(t *testing.T) {
+	verifier := newTestVerifier(t, VerifierConfig{HMACSecret: testSecret}, time.Now())
+	handler := Authenticate(verifier)(subjectHandler)
+
+	expired, err := MintTestToken(testSecret, "alice", nil, -time.Hour)
+	if err != nil {
+		t.Fatalf("MintTestToken: %v", err)
+	}
+	forged, err := MintTestToken([]byte("other-secret"), "alice", nil, time.Minute)
+	if err != nil {
+		t.Fatalf("MintTestToken: %v", err)
+	}
+
+	tests := []struct {
+		name          string
+		authorization string
+		wantStatus    int
+		wantBody      string
+	}{
+		{"no token", "", http.StatusOK, "anonymous"},
+		{"valid token", mint(t, "alice"), http.StatusOK, "alice"},
+		{"lower case scheme", strings.Replace(mint(t, "alice"), "Bearer", "bearer", 1), http.StatusOK, "alice"},
+		{"expired token", "Bearer " + expired, http.StatusUnauthorized, ""},
+		{"wrong secret", "Bearer " + forged, http.StatusUnauthorized, ""},
+		{"garbage token", "Bearer not-a-jwt", http.StatusUnauthorized, ""},
+		{"basic scheme", "Basic YWxpY2U6c2VjcmV0", http.StatusUnauthorized, ""},
+		{"empty bearer", "Bearer ", http.StatusUnauthorized, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(handler, tt.authorization)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if tt.wantStatus == http.StatusUnauthorized {
+				if challenge := w.Header().Get("WWW-Authenticate"); challenge != `Bearer realm="api", error="invalid_token"` {
+					t.Errorf("WWW-Authenticate = %q", challenge)
+				}
+				return
+			}
+			if w.Body.String() != tt.wantBody {
+				t.Errorf("body = %q, want %q", w.Body.String(), tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestRequireAuth(t *testing.T) {
+	verifier := newTestVerifier(t, VerifierConfig{HMACSecret: testSecret}, time.Now())
+	handler := Authenticate(verifier)(RequireAuth(subjectHandler))
+
+	w := serve(handler, "")
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("anonymous status = %d, want 401", w.Code)
+	}
+	if challenge := w.Header().Get("WWW-Authenticate"); challenge != `Bearer realm="api"` {
+		t.Errorf("anonymous WWW-Authenticate = %q", challenge)
+	}
+
+	if w := serve(handler, mint(t, "alice")); w.Code != http.StatusOK || w.Body.String() != "alice" {
+		t.Errorf("signed in = %d %q, want 200 alice", w.Code, w.Body.String())
+	}
+}
+
+func TestRequirePermission(t *testing.T) {
+	verifier := newTestVerifier(t, VerifierConfig{HMACSecret: testSecret}, time.Now())
+	handler := Authenticate(verifier)(RequirePermission(PermissionManageCatalog)(subjectHandler))
+
+	if w := serve(handler, ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("anonymous status = %d, want 401", w.Code)
+	}
+
+	w := serve(handler, mint(t, "bob", RoleShopper))
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("shopper status = %d, want 403", w.Code)
+	}
+	var forbidden Forbidden
+	if err := json.NewDecoder(w.Body).Decode(&forbidden); err != nil {
+		t.Fatalf("decode 403 body: %v", err)
+	}
+	if forbidden.Permission != PermissionManageCatalog || len(forbidden.Roles) != 1 || forbidden.Roles[0] != RoleShopper {
+		t.Errorf("403 body = %+v", forbidden)
+	}
+
+	for _, role := range []string{RoleMerchandiser, RoleAdmin} {
+		if w := serve(handler, mint(t, "alice", role)); w.Code != http.StatusOK {
+			t.Errorf("%s status = %d, want 200", role, w.Code)
+		}
+	}
+}
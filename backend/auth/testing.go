@@ -0,0 +1,18 @@
+package auth
+
+import (
+	"time"
+)
+
+// MintTestToken returns an HS256 token for subject with the given roles that
+// expires after ttl. It is meant for tests and local development against a
+// server configured with the same secret.
+func MintTestToken(secret []byte, subject string, roles []string, ttl time.Duration) (string, error) {
+	now := time.Now()
+	return NewHS256Signer(secret).Sign(Claims{
+		Subject:   subject,
+		Roles:     roles,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(ttl).Unix(),
+	})
+}
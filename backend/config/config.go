@@ -3,18 +3,30 @@ package config
 import (
 	"os"
 	"strconv"
+	"strings"
+	"time"
 )
 
 // Config holds the application configuration
 type Config struct {
-	Server ServerConfig
-	CORS   CORSConfig
+	Server  ServerConfig
+	CORS    CORSConfig
+	Storage StorageConfig
+	Payment PaymentConfig
+	Auth    AuthConfig
 }
 
 // ServerConfig holds server-related configuration
 type ServerConfig struct {
-	Port int
-	Host string
+	Port           int
+	Host           string
+	Environment    string        // "development" allows development-only fallbacks
+	IdempotencyTTL time.Duration // How long responses are kept for Idempotency-Key retries
+}
+
+// IsDevelopment reports whether the server runs with ENV=development
+func (sc ServerConfig) IsDevelopment() bool {
+	return strings.EqualFold(sc.Environment, "development")
 }
 
 // CORSConfig holds CORS-related configuration
@@ -24,12 +36,50 @@ type CORSConfig struct {
 	AllowedHeaders []string
 }
 
+// StorageConfig holds product storage configuration
+type StorageConfig struct {
+	Driver       string // "memory", "file" or "sqlite"
+	Path         string // Data file location for the file driver
+	DatabasePath string // Database location for the sqlite driver
+}
+
+// PaymentConfig holds payment gateway configuration
+type PaymentConfig struct {
+	Gateway       string        // "fake" is the only gateway so far
+	WebhookSecret string        // Shared secret used to sign and verify webhooks
+	WebhookURL    string        // Where the fake gateway delivers its webhooks
+	WebhookDelay  time.Duration // How long the fake gateway holds back delayed webhooks
+	Timeout       time.Duration // Deadline for each gateway call
+}
+
+// AuthConfig holds JWT authentication configuration
+type AuthConfig struct {
+	JWTSecret         string        // HMAC secret for HS256 tokens
+	JWTPublicKeyPath  string        // PEM RSA public key for RS256 tokens
+	JWTPrivateKeyPath string        // PEM RSA private key; login issues RS256 tokens when set
+	Issuer            string        // Required iss claim, if set
+	Audience          string        // Required aud claim, if set
+	Leeway            time.Duration // Allowed clock skew for exp and nbf
+	AccessTokenTTL    time.Duration // Lifetime of access tokens issued at login
+	RefreshTokenTTL   time.Duration // Lifetime of refresh tokens
+	MaxFailedLogins   int           // Wrong passwords in a row before an account locks
+	LockoutDuration   time.Duration // How long a locked account stays locked
+	AdminEmail        string        // Admin account created at startup, if set
+	AdminPassword     string        // Password of the bootstrap admin account
+}
+
+// DefaultWebhookSecret is the development webhook secret used when none is configured
+const DefaultWebhookSecret = "dev-webhook-secret"
+
 // LoadConfig loads configuration from environment variables with default values
 func LoadConfig() *Config {
+	port := getEnvAsInt("PORT", 8080)
 	return &Config{
 		Server: ServerConfig{
-			Port: getEnvAsInt("PORT", 8080),
-			Host: getEnv("HOST", ""),
+			Port:           port,
+			Host:           getEnv("HOST", ""),
+			Environment:    getEnv("ENV", ""),
+			IdempotencyTTL: getEnvAsDuration("IDEMPOTENCY_TTL", 24*time.Hour),
 		},
 		CORS: CORSConfig{
 			AllowedOrigins: []string{
@@ -40,6 +90,7 @@ func LoadConfig() *Config {
 				"GET", 
 				"POST", 
 				"PUT", 
+				"PATCH", 
 				"DELETE", 
 				"OPTIONS",
 			},
@@ -47,6 +98,32 @@ func LoadConfig() *Config {
 				"*",
 			},
 		},
+		Storage: StorageConfig{
+			Driver:       getEnv("PRODUCT_STORE", "memory"),
+			Path:         getEnv("PRODUCT_STORE_PATH", "data/products.json"),
+			DatabasePath: getEnv("PRODUCT_DB_PATH", "data/products.db"),
+		},
+		Payment: PaymentConfig{
+			Gateway:       getEnv("PAYMENT_GATEWAY", "fake"),
+			WebhookSecret: getEnv("PAYMENT_WEBHOOK_SECRET", DefaultWebhookSecret),
+			WebhookURL:    getEnv("PAYMENT_WEBHOOK_URL", "http://localhost:"+strconv.Itoa(port)+"/api/payments/webhook"),
+			WebhookDelay:  getEnvAsDuration("PAYMENT_WEBHOOK_DELAY", 5*time.Second),
+			Timeout:       getEnvAsDuration("PAYMENT_TIMEOUT", 5*time.Second),
+		},
+		Auth: AuthConfig{
+			JWTSecret:         getEnv("JWT_HMAC_SECRET", ""),
+			JWTPublicKeyPath:  getEnv("JWT_RSA_PUBLIC_KEY_PATH", ""),
+			JWTPrivateKeyPath: getEnv("JWT_RSA_PRIVATE_KEY_PATH", ""),
+			Issuer:            getEnv("JWT_ISSUER", ""),
+			Audience:          getEnv("JWT_AUDIENCE", ""),
+			Leeway:            getEnvAsDuration("JWT_LEEWAY", 30*time.Second),
+			AccessTokenTTL:    getEnvAsDuration("ACCESS_TOKEN_TTL", 15*time.Minute),
+			RefreshTokenTTL:   getEnvAsDuration("REFRESH_TOKEN_TTL", 30*24*time.Hour),
+			MaxFailedLogins:   getEnvAsInt("MAX_FAILED_LOGINS", 5),
+			LockoutDuration:   getEnvAsDuration("LOCKOUT_DURATION", 15*time.Minute),
+			AdminEmail:        getEnv("ADMIN_EMAIL", ""),
+			AdminPassword:     getEnv("ADMIN_PASSWORD", ""),
+		},
 	}
 }
 
@@ -74,4 +151,14 @@ func getEnvAsInt(key string, defaultValue int) int {
 		}
 	}
 	return defaultValue
+}
+
+// getEnvAsDuration gets an environment variable as a duration such as "5s" with a default value
+func getEnvAsDuration(key string, defaultValue time.Duration) time.Duration {
+	if value, exists := os.LookupEnv(key); exists {
+		if duration, err := time.ParseDuration(value); err == nil {
+			return duration
+		}
+	}
+	return defaultValue
 }
\ No newline at end of file
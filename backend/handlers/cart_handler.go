@@ -0,0 +1,253 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"ecommerce-backend/auth"
+	"ecommerce-backend/logger"
+	"ecommerce-backend/models"
+	"ecommerce-backend/services"
+	"github.com/gorilla/mux"
+)
+
+// CartHandler handles HTTP requests for shopping carts
+type CartHandler struct {
+	cartService *services.CartService
+}
+
+// NewCartHandler creates a new cart handler
+func NewCartHandler(cartService *services.CartService) *CartHandler {
+	return &CartHandler{
+		cartService: cartService,
+	}
+}
+
+// CreateCart handles POST /api/carts requests
+func (ch *CartHandler) CreateCart(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	w.Header().Set("Content-Type", "application/json")
+
+	logger.InfoContext(r.Context(), "Handling create cart request", map[string]interface{}{
+		"handler": "CreateCart",
+		"method":  r.Method,
+		"path":    r.URL.Path,
+	})
+
+	cart, err := ch.cartService.CreateCart(r.Context())
+	if err != nil {
+		writeCartError(w, r, "CreateCart", "", err, start)
+		return
+	}
+
+	w.Header().Set("Location", fmt.Sprintf("/api/carts/%s/items", cart.ID))
+	w.WriteHeader(http.StatusCreated)
+	ch.writeCart(w, r, "CreateCart", cart, start)
+}
+
+// GetCartItems handles GET /api/carts/{id}/items requests
+func (ch *CartHandler) GetCartItems(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	w.Header().Set("Content-Type", "application/json")
+
+	id := mux.Vars(r)["id"]
+	logger.InfoContext(r.Context(), "Handling get cart request", map[string]interface{}{
+		"handler": "GetCartItems",
+		"cart_id": id,
+		"method":  r.Method,
+		"path":    r.URL.Path,
+	})
+
+	cart, err := ch.cartService.GetCart(r.Context(), id, auth.SubjectFromContext(r.Context()))
+	if err != nil {
+		writeCartError(w, r, "GetCartItems", id, err, start)
+		return
+	}
+	ch.writeCart(w, r, "GetCartItems", cart, start)
+}
+
+// PutCartItem handles PUT /api/carts/{id}/items requests.
+// It sets the quantity of one line; a quantity of 0 removes it.
+func (ch *CartHandler) PutCartItem(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	w.Header().Set("Content-Type", "application/json")
+
+	id := mux.Vars(r)["id"]
+	var item models.CartItem
+	if err := decodeJSONBody(w, r, &item); err != nil {
+		duration := float64(time.Since(start).Nanoseconds()) / 1e6
+		logger.WarnContext(r.Context(), "Invalid cart item body", map[string]interface{}{
+			"handler":     "PutCartItem",
+			"cart_id":     id,
+			"error":       err.Error(),
+			"duration_ms": duration,
+		})
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	logger.InfoContext(r.Context(), "Handling put cart item request", map[string]interface{}{
+		"handler":    "PutCartItem",
+		"cart_id":    id,
+		"product_id": item.ProductID,
+		"quantity":   item.Quantity,
+		"method":     r.Method,
+		"path":       r.URL.Path,
+	})
+
+	cart, err := ch.cartService.SetItem(r.Context(), id, auth.SubjectFromContext(r.Context()), item)
+	if err != nil {
+		writeCartError(w, r, "PutCartItem", id, err, start)
+		return
+	}
+	ch.writeCart(w, r, "PutCartItem", cart, start)
+}
+
+// DeleteCartItems handles DELETE /api/carts/{id}/items requests. With the
+// productId, size and color parameters it removes that line, otherwise it
+// empties the cart.
+func (ch *CartHandler) DeleteCartItems(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	w.Header().Set("Content-Type", "application/json")
+
+	id := mux.Vars(r)["id"]
+	query := r.URL.Query()
+	productIDStr := query.Get("productId")
+
+	logger.InfoContext(r.Context(), "Handling delete cart items request", map[string]interface{}{
+		"handler":    "DeleteCartItems",
+		"cart_id":    id,
+		"product_id": productIDStr,
+		"method":     r.Method,
+		"path":       r.URL.Path,
+	})
+
+	var cart models.PricedCart
+	var err error
+	if productIDStr == "" {
+		cart, err = ch.cartService.ClearCart(r.Context(), id, auth.SubjectFromContext(r.Context()))
+	} else {
+		productID, convErr := strconv.Atoi(productIDStr)
+		if convErr != nil {
+			duration := float64(time.Since(start).Nanoseconds()) / 1e6
+			logger.WarnContext(r.Context(), "Invalid cart item product ID", map[string]interface{}{
+				"handler":     "DeleteCartItems",
+				"cart_id":     id,
+				"invalid_id":  productIDStr,
+				"duration_ms": duration,
+			})
+			http.Error(w, "Invalid product ID", http.StatusBadRequest)
+			return
+		}
+		cart, err = ch.cartService.RemoveItem(r.Context(), id, auth.SubjectFromContext(r.Context()), models.CartItem{
+			ProductID: productID,
+			Size:      query.Get("size"),
+			Color:     query.Get("color"),
+		})
+	}
+	if err != nil {
+		writeCartError(w, r, "DeleteCartItems", id, err, start)
+		return
+	}
+	ch.writeCart(w, r, "DeleteCartItems", cart, start)
+}
+
+// MergeCart handles POST /api/carts/merge requests. It folds the guest cart
+// named in the body into the signed-in user's cart.
+func (ch *CartHandler) MergeCart(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	w.Header().Set("Content-Type", "application/json")
+
+	var request models.CartMergeRequest
+	if err := decodeJSONBody(w, r, &request); err != nil {
+		writeCartError(w, r, "MergeCart", "", &models.ValidationError{Problems: []string{err.Error()}}, start)
+		return
+	}
+
+	userID := auth.SubjectFromContext(r.Context())
+	logger.InfoContext(r.Context(), "Handling merge cart request", map[string]interface{}{
+		"handler":       "MergeCart",
+		"user_id":       userID,
+		"guest_cart_id": request.GuestCartID,
+		"method":        r.Method,
+		"path":          r.URL.Path,
+	})
+
+	result, err := ch.cartService.MergeCart(r.Context(), userID, request.GuestCartID)
+	if err != nil {
+		writeCartError(w, r, "MergeCart", request.GuestCartID, err, start)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		logger.LogErrorContext(r.Context(), "handlers", "MergeCart", err, map[string]interface{}{
+			"cart_id": result.Cart.ID,
+		})
+		return
+	}
+
+	duration := float64(time.Since(start).Nanoseconds()) / 1e6
+	logger.InfoContext(r.Context(), "MergeCart request completed successfully", map[string]interface{}{
+		"handler":     "MergeCart",
+		"cart_id":     result.Cart.ID,
+		"adjustments": len(result.Adjustments),
+		"duration_ms": duration,
+	})
+}
+
+// writeCart encodes a priced cart and logs the completed request
+func (ch *CartHandler) writeCart(w http.ResponseWriter, r *http.Request, handler string, cart models.PricedCart, start time.Time) {
+	if err := json.NewEncoder(w).Encode(cart); err != nil {
+		logger.LogErrorContext(r.Context(), "handlers", handler, err, map[string]interface{}{
+			"cart_id": cart.ID,
+		})
+		return
+	}
+
+	duration := float64(time.Since(start).Nanoseconds()) / 1e6
+	logger.InfoContext(r.Context(), "Cart request completed successfully", map[string]interface{}{
+		"handler":     handler,
+		"cart_id":     cart.ID,
+		"lines":       len(cart.Items),
+		"subtotal":    cart.Subtotal,
+		"duration_ms": duration,
+	})
+}
+
+// writeCartError maps cart service errors to HTTP responses
+func writeCartError(w http.ResponseWriter, r *http.Request, handler, id string, err error, start time.Time) {
+	duration := float64(time.Since(start).Nanoseconds()) / 1e6
+
+	var validationErr *models.ValidationError
+	status := http.StatusInternalServerError
+	message := "Failed to update cart"
+	switch {
+	case errors.As(err, &validationErr):
+		status, message = http.StatusBadRequest, err.Error()
+	case errors.Is(err, services.ErrCartNotFound):
+		status, message = http.StatusNotFound, "Cart not found"
+	case errors.Is(err, services.ErrCartItemNotFound):
+		status, message = http.StatusNotFound, "Cart item not found"
+	case errors.Is(err, services.ErrInsufficientStock):
+		status, message = http.StatusConflict, err.Error()
+	}
+
+	if status == http.StatusInternalServerError {
+		logger.LogErrorContext(r.Context(), "handlers", handler, err, map[string]interface{}{
+			"cart_id":     id,
+			"duration_ms": duration,
+		})
+	} else {
+		logger.WarnContext(r.Context(), "Cart request rejected", map[string]interface{}{
+			"handler":     handler,
+			"cart_id":     id,
+			"error":       err.Error(),
+			"duration_ms": duration,
+		})
+	}
+	http.Error(w, message, status)
+}
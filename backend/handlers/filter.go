@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"ecommerce-backend/models"
+)
+
+// parseProductFilter builds a ProductFilter from the query string. Supported
+// parameters: gender, category (repeatable or comma-separated), min, max, q,
+// size, color and inStock.
+func parseProductFilter(r *http.Request) (models.ProductFilter, error) {
+	query := r.URL.Query()
+	filter := models.ProductFilter{
+		Gender: query.Get("gender"),
+		Query:  strings.TrimSpace(query.Get("q")),
+		Size:   query.Get("size"),
+		Color:  query.Get("color"),
+	}
+
+	for _, value := range query["category"] {
+		for _, category := range strings.Split(value, ",") {
+			if category = strings.TrimSpace(category); category != "" {
+				filter.Categories = append(filter.Categories, category)
+			}
+		}
+	}
+
+	if minPriceStr := query.Get("min"); minPriceStr != "" {
+		minPrice, err := strconv.ParseFloat(minPriceStr, 64)
+		if err != nil {
+			return filter, errors.New("Invalid min price format")
+		}
+		filter.MinPrice = &minPrice
+	}
+
+	if maxPriceStr := query.Get("max"); maxPriceStr != "" {
+		maxPrice, err := strconv.ParseFloat(maxPriceStr, 64)
+		if err != nil {
+			return filter, errors.New("Invalid max price format")
+		}
+		filter.MaxPrice = &maxPrice
+	}
+
+	if filter.MinPrice != nil && filter.MaxPrice != nil && *filter.MinPrice > *filter.MaxPrice {
+		return filter, errors.New("Min price cannot be greater than max price")
+	}
+
+	if inStockStr := query.Get("inStock"); inStockStr != "" {
+		inStock, err := strconv.ParseBool(inStockStr)
+		if err != nil {
+			return filter, errors.New("Invalid inStock value, expected true or false")
+		}
+		filter.InStock = &inStock
+	}
+
+	return filter, nil
+}
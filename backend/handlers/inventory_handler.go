@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"ecommerce-backend/logger"
+	"ecommerce-backend/services"
+)
+
+// InventoryHandler handles HTTP requests for variant stock
+type InventoryHandler struct {
+	inventoryService *services.InventoryService
+}
+
+// NewInventoryHandler creates a new inventory handler
+func NewInventoryHandler(inventoryService *services.InventoryService) *InventoryHandler {
+	return &InventoryHandler{
+		inventoryService: inventoryService,
+	}
+}
+
+// stockAdjustment is the body of a stock adjustment request
+type stockAdjustment struct {
+	Size  string `json:"size"`
+	Color string `json:"color"`
+	Delta int    `json:"delta"`
+}
+
+// AdjustStock handles POST /api/admin/products/{id}/inventory and
+// POST /api/products/{id}/inventory requests. It changes the stock of one
+// variant by delta and returns the updated variant.
+func (ih *InventoryHandler) AdjustStock(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	w.Header().Set("Content-Type", "application/json")
+
+	id, ok := parseProductID(w, r, "AdjustStock", start)
+	if !ok {
+		return
+	}
+
+	var adjustment stockAdjustment
+	if err := decodeJSONBody(w, r, &adjustment); err != nil {
+		duration := float64(time.Since(start).Nanoseconds()) / 1e6
+		logger.WarnContext(r.Context(), "Invalid stock adjustment body", map[string]interface{}{
+			"handler":     "AdjustStock",
+			"product_id":  id,
+			"error":       err.Error(),
+			"duration_ms": duration,
+		})
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	logger.InfoContext(r.Context(), "Handling stock adjustment request", map[string]interface{}{
+		"handler":    "AdjustStock",
+		"product_id": id,
+		"size":       adjustment.Size,
+		"color":      adjustment.Color,
+		"delta":      adjustment.Delta,
+		"method":     r.Method,
+		"path":       r.URL.Path,
+	})
+
+	variant, err := ih.inventoryService.Adjust(r.Context(), id, adjustment.Size, adjustment.Color, adjustment.Delta)
+	if err != nil {
+		writeInventoryError(w, r, "AdjustStock", id, err, start)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(variant); err != nil {
+		logger.LogErrorContext(r.Context(), "handlers", "AdjustStock", err, map[string]interface{}{
+			"product_id": id,
+		})
+		return
+	}
+
+	duration := float64(time.Since(start).Nanoseconds()) / 1e6
+	logger.InfoContext(r.Context(), "Stock adjustment request completed successfully", map[string]interface{}{
+		"handler":     "AdjustStock",
+		"product_id":  id,
+		"stock":       variant.Stock,
+		"duration_ms": duration,
+	})
+}
+
+// writeInventoryError maps inventory service errors to HTTP responses
+func writeInventoryError(w http.ResponseWriter, r *http.Request, handler string, id int, err error, start time.Time) {
+	duration := float64(time.Since(start).Nanoseconds()) / 1e6
+
+	status := http.StatusInternalServerError
+	message := "Failed to update stock"
+	switch {
+	case errors.Is(err, services.ErrProductNotFound):
+		status, message = http.StatusNotFound, "Product not found"
+	case errors.Is(err, services.ErrVariantNotFound):
+		status, message = http.StatusNotFound, err.Error()
+	case errors.Is(err, services.ErrInsufficientStock):
+		status, message = http.StatusConflict, err.Error()
+	case errors.Is(err, services.ErrInvalidQuantity):
+		status, message = http.StatusBadRequest, err.Error()
+	}
+
+	if status == http.StatusInternalServerError {
+		logger.LogErrorContext(r.Context(), "handlers", handler, err, map[string]interface{}{
+			"product_id":  id,
+			"duration_ms": duration,
+		})
+	} else {
+		logger.WarnContext(r.Context(), "Stock update rejected", map[string]interface{}{
+			"handler":     handler,
+			"product_id":  id,
+			"error":       err.Error(),
+			"duration_ms": duration,
+		})
+	}
+	http.Error(w, message, status)
+}
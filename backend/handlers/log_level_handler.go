@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"ecommerce-backend/auth"
+	"ecommerce-backend/logger"
+	"ecommerce-backend/models"
+	"github.com/sirupsen/logrus"
+)
+
+// LogLevelHandler handles HTTP requests for the runtime log levels
+type LogLevelHandler struct{}
+
+// NewLogLevelHandler creates a new log level handler
+func NewLogLevelHandler() *LogLevelHandler {
+	return &LogLevelHandler{}
+}
+
+// logLevels is the body of log level requests and responses. Components maps a
+// component or service name, such as "ProductService", to its own level.
+type logLevels struct {
+	Level      string            `json:"level"`
+	Components map[string]string `json:"components"`
+}
+
+// GetLogLevel handles GET /api/admin/log-level requests
+func (lh *LogLevelHandler) GetLogLevel(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	w.Header().Set("Content-Type", "application/json")
+
+	logger.InfoContext(r.Context(), "Handling get log level request", map[string]interface{}{
+		"handler": "GetLogLevel",
+		"method":  r.Method,
+		"path":    r.URL.Path,
+	})
+
+	lh.writeLevels(w, r, "GetLogLevel", start)
+}
+
+// PutLogLevel handles PUT /api/admin/log-level requests. It replaces the global
+// level and the component overrides; components left out fall back to the
+// global level.
+func (lh *LogLevelHandler) PutLogLevel(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	w.Header().Set("Content-Type", "application/json")
+
+	var request logLevels
+	if err := decodeJSONBody(w, r, &request); err != nil {
+		writeLogLevelError(w, r, &models.ValidationError{Problems: []string{err.Error()}}, start)
+		return
+	}
+
+	global, components, err := parseLogLevels(request)
+	if err != nil {
+		writeLogLevelError(w, r, err, start)
+		return
+	}
+
+	previous := currentLogLevels()
+	logger.SetLevels(global, components)
+
+	logger.LogAuditContext(r.Context(), "log_level_change", "allowed", map[string]interface{}{
+		"subject":             auth.SubjectFromContext(r.Context()),
+		"previous_log_level":  previous.Level,
+		"previous_components": previous.Components,
+		"log_level":           global.String(),
+		"components":          request.Components,
+	})
+
+	lh.writeLevels(w, r, "PutLogLevel", start)
+}
+
+// writeLevels encodes the current log levels and logs the completed request
+func (lh *LogLevelHandler) writeLevels(w http.ResponseWriter, r *http.Request, handler string, start time.Time) {
+	levels := currentLogLevels()
+	if err := json.NewEncoder(w).Encode(levels); err != nil {
+		logger.LogErrorContext(r.Context(), "handlers", handler, err, nil)
+		return
+	}
+
+	duration := float64(time.Since(start).Nanoseconds()) / 1e6
+	logger.InfoContext(r.Context(), fmt.Sprintf("%s request completed successfully", handler), map[string]interface{}{
+		"handler":     handler,
+		"log_level":   levels.Level,
+		"duration_ms": duration,
+	})
+}
+
+// currentLogLevels reads the log levels from the logger
+func currentLogLevels() logLevels {
+	levels := logLevels{
+		Level:      logger.GetLevel().String(),
+		Components: map[string]string{},
+	}
+	for component, level := range logger.ComponentLevels() {
+		levels.Components[component] = level.String()
+	}
+	return levels
+}
+
+// parseLogLevels validates a log level request
+func parseLogLevels(request logLevels) (logrus.Level, map[string]logrus.Level, error) {
+	var problems []string
+	allowed := strings.Join(logger.LevelNames(), ", ")
+
+	global, err := logrus.ParseLevel(request.Level)
+	if err != nil {
+		problems = append(problems, fmt.Sprintf("level must be one of %s", allowed))
+	}
+
+	names := make([]string, 0, len(request.Components))
+	for component := range request.Components {
+		names = append(names, component)
+	}
+	sort.Strings(names)
+
+	components := make(map[string]logrus.Level, len(request.Components))
+	for _, component := range names {
+		if strings.TrimSpace(component) == "" {
+			problems = append(problems, "component names must not be empty")
+			continue
+		}
+		level, err := logrus.ParseLevel(request.Components[component])
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("level of component %q must be one of %s", component, allowed))
+			continue
+		}
+		components[component] = level
+	}
+
+	if len(problems) > 0 {
+		return 0, nil, &models.ValidationError{Problems: problems}
+	}
+	return global, components, nil
+}
+
+// writeLogLevelError rejects an invalid log level request
+func writeLogLevelError(w http.ResponseWriter, r *http.Request, err error, start time.Time) {
+	duration := float64(time.Since(start).Nanoseconds()) / 1e6
+	logger.WarnContext(r.Context(), "Log level request rejected", map[string]interface{}{
+		"handler":     "PutLogLevel",
+		"error":       err.Error(),
+		"duration_ms": duration,
+	})
+	http.Error(w, err.Error(), http.StatusBadRequest)
+}
@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"ecommerce-backend/logger"
+	"github.com/sirupsen/logrus"
+)
+
+func TestMain(m *testing.M) {
+	if err := logger.Init(logger.LogConfig{Level: "error", Output: "stdout"}); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	logger.Logger.SetOutput(io.Discard)
+	os.Exit(m.Run())
+}
+
+// serveLogLevel sends a log level request and decodes a successful response
+func serveLogLevel(t *testing.T, method, body string) (int, logLevels, string) {
+	t.Helper()
+	handler := NewLogLevelHandler()
+	request := httptest.NewRequest(method, "/api/admin/log-level", strings.NewReader(body))
+	recorder := httptest.NewRecorder()
+	if method == http.MethodPut {
+		handler.PutLogLevel(recorder, request)
+	} else {
+		handler.GetLogLevel(recorder, request)
+	}
+
+	var levels logLevels
+	if recorder.Code == http.StatusOK {
+		if err := json.Unmarshal(recorder.Body.Bytes(), &levels); err != nil {
+			t.Fatalf("decode response %q: %v", recorder.Body.String(), err)
+		}
+	}
+	return recorder.Code, levels, recorder.Body.String()
+}
+
+func TestGetLogLevel(t *testing.T) {
+	logger.SetLevels(logrus.WarnLevel, map[string]logrus.Level{"ProductService": logrus.DebugLevel})
+	t.Cleanup(func() { logger.SetLevels(logrus.ErrorLevel, nil) })
+
+	status, levels, _ := serveLogLevel(t, http.MethodGet, "")
+	want := logLevels{Level: "warning", Components: map[string]string{"ProductService": "debug"}}
+	if status != http.StatusOK || !reflect.DeepEqual(levels, want) {
+		t.Errorf("GET = %d %+v, want 200 %+v", status, levels, want)
+	}
+
+	// No overrides is an empty object, not null
+	logger.SetLevels(logrus.InfoLevel, nil)
+	if _, _, body := serveLogLevel(t, http.MethodGet, ""); !strings.Contains(body, `"components":{}`) {
+		t.Errorf("GET without overrides = %s, want empty components", body)
+	}
+}
+
+func TestPutLogLevel(t *testing.T) {
+	t.Cleanup(func() { logger.SetLevels(logrus.ErrorLevel, nil) })
+
+	tests := []struct {
+		name     string
+		body     string
+		status   int
+		want     logLevels
+		problems []string
+	}{
+		{
+			name:   "global level and overrides",
+			body:   `{"level":"warn","components":{"ProductService":"debug","auth":"ERROR"}}`,
+			status: http.StatusOK,
+			want:   logLevels{Level: "warning", Components: map[string]string{"ProductService": "debug", "auth": "error"}},
+		},
+		{
+			name:   "overrides left out are cleared",
+			body:   `{"level":"info"}`,
+			status: http.StatusOK,
+			want:   logLevels{Level: "info", Components: map[string]string{}},
+		},
+		{
+			name:     "unknown global level",
+			body:     `{"level":"loud"}`,
+			status:   http.StatusBadRequest,
+			problems: []string{"level must be one of panic, fatal, error, warning, info, debug, trace"},
+		},
+		{
+			name:     "missing global level",
+			body:     `{"components":{"auth":"debug"}}`,
+			status:   http.StatusBadRequest,
+			problems: []string{"level must be one of"},
+		},
+		{
+			name:     "every bad component is reported",
+			body:     `{"level":"info","components":{"auth":"verbose"," ":"debug","CartService":"quiet"}}`,
+			status:   http.StatusBadRequest,
+			problems: []string{"component names must not be empty", `level of component "CartService"`, `level of component "auth"`},
+		},
+		{
+			name:     "unknown field",
+			body:     `{"level":"info","verbose":true}`,
+			status:   http.StatusBadRequest,
+			problems: []string{"invalid JSON body"},
+		},
+		{
+			name:     "malformed JSON",
+			body:     `{"level":`,
+			status:   http.StatusBadRequest,
+			problems: []string{"invalid JSON body"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger.SetLevels(logrus.ErrorLevel, map[string]logrus.Level{"OrderService": logrus.InfoLevel})
+
+			status, levels, body := serveLogLevel(t, http.MethodPut, tt.body)
+			if status != tt.status {
+				t.Fatalf("PUT %s = %d %s, want %d", tt.body, status, body, tt.status)
+			}
+			for _, problem := range tt.problems {
+				if !strings.Contains(body, problem) {
+					t.Errorf("response %q does not mention %q", body, problem)
+				}
+			}
+
+			if tt.status == http.StatusOK {
+				if !reflect.DeepEqual(levels, tt.want) {
+					t.Errorf("response = %+v, want %+v", levels, tt.want)
+				}
+				if current := currentLogLevels(); !reflect.DeepEqual(current, tt.want) {
+					t.Errorf("levels after PUT = %+v, want %+v", current, tt.want)
+				}
+				return
+			}
+
+			// A rejected request changes nothing
+			want := logLevels{Level: "error", Components: map[string]string{"OrderService": "info"}}
+			if current := currentLogLevels(); !reflect.DeepEqual(current, want) {
+				t.Errorf("levels after a rejected PUT = %+v, want %+v", current, want)
+			}
+		})
+	}
+}
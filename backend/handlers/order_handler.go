@@ -0,0 +1,257 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"ecommerce-backend/auth"
+	"ecommerce-backend/logger"
+	"ecommerce-backend/models"
+	"ecommerce-backend/services"
+	"github.com/gorilla/mux"
+)
+
+// OrderTokenHeader carries the access token of a guest order
+const OrderTokenHeader = "X-Order-Token"
+
+// OrderHandler handles HTTP requests for orders
+type OrderHandler struct {
+	orderService *services.OrderService
+}
+
+// NewOrderHandler creates a new order handler
+func NewOrderHandler(orderService *services.OrderService) *OrderHandler {
+	return &OrderHandler{
+		orderService: orderService,
+	}
+}
+
+// PlaceOrder handles POST /api/orders requests
+func (oh *OrderHandler) PlaceOrder(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	w.Header().Set("Content-Type", "application/json")
+
+	var request models.OrderRequest
+	if err := decodeJSONBody(w, r, &request); err != nil {
+		duration := float64(time.Since(start).Nanoseconds()) / 1e6
+		logger.WarnContext(r.Context(), "Invalid place order body", map[string]interface{}{
+			"handler":     "PlaceOrder",
+			"error":       err.Error(),
+			"duration_ms": duration,
+		})
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	request.UserID = auth.SubjectFromContext(r.Context())
+
+	logger.InfoContext(r.Context(), "Handling place order request", map[string]interface{}{
+		"handler": "PlaceOrder",
+		"user_id": request.UserID,
+		"cart_id": request.CartID,
+		"lines":   len(request.Items),
+		"method":  r.Method,
+		"path":    r.URL.Path,
+	})
+
+	order, err := oh.orderService.PlaceOrder(r.Context(), request)
+	if err != nil {
+		writeOrderError(w, r, "PlaceOrder", "", err, start)
+		return
+	}
+
+	w.Header().Set("Location", fmt.Sprintf("/api/orders/%s", order.ID))
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(order); err != nil {
+		logger.LogErrorContext(r.Context(), "handlers", "PlaceOrder", err, map[string]interface{}{
+			"order_id": order.ID,
+		})
+		return
+	}
+
+	duration := float64(time.Since(start).Nanoseconds()) / 1e6
+	logger.InfoContext(r.Context(), "Place order request completed successfully", map[string]interface{}{
+		"handler":     "PlaceOrder",
+		"order_id":    order.ID,
+		"total":       order.Total,
+		"duration_ms": duration,
+	})
+}
+
+// GetOrder handles GET /api/orders/{id} requests
+func (oh *OrderHandler) GetOrder(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	w.Header().Set("Content-Type", "application/json")
+
+	id := mux.Vars(r)["id"]
+	logger.InfoContext(r.Context(), "Handling get order request", map[string]interface{}{
+		"handler":  "GetOrder",
+		"order_id": id,
+		"method":   r.Method,
+		"path":     r.URL.Path,
+	})
+
+	order, err := oh.orderService.GetOrder(r.Context(), id, orderAccess(r))
+	if err != nil {
+		writeOrderError(w, r, "GetOrder", id, err, start)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(order); err != nil {
+		logger.LogErrorContext(r.Context(), "handlers", "GetOrder", err, map[string]interface{}{
+			"order_id": id,
+		})
+		http.Error(w, "Failed to encode order", http.StatusInternalServerError)
+		return
+	}
+
+	duration := float64(time.Since(start).Nanoseconds()) / 1e6
+	logger.InfoContext(r.Context(), "Order request completed successfully", map[string]interface{}{
+		"handler":     "GetOrder",
+		"order_id":    id,
+		"duration_ms": duration,
+	})
+}
+
+// TransitionOrder handles POST /api/admin/orders/{id}/transitions and
+// POST /api/orders/{id}/transitions requests
+func (oh *OrderHandler) TransitionOrder(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	w.Header().Set("Content-Type", "application/json")
+
+	id := mux.Vars(r)["id"]
+	var transition models.OrderTransition
+	if err := decodeJSONBody(w, r, &transition); err != nil {
+		duration := float64(time.Since(start).Nanoseconds()) / 1e6
+		logger.WarnContext(r.Context(), "Invalid order transition body", map[string]interface{}{
+			"handler":     "TransitionOrder",
+			"order_id":    id,
+			"error":       err.Error(),
+			"duration_ms": duration,
+		})
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	// The event is always recorded under the authenticated caller
+	transition.Actor = auth.SubjectFromContext(r.Context())
+
+	logger.InfoContext(r.Context(), "Handling order transition request", map[string]interface{}{
+		"handler":  "TransitionOrder",
+		"order_id": id,
+		"status":   transition.Status,
+		"actor":    transition.Actor,
+		"method":   r.Method,
+		"path":     r.URL.Path,
+	})
+
+	order, err := oh.orderService.TransitionOrder(r.Context(), id, transition)
+	if err != nil {
+		writeOrderError(w, r, "TransitionOrder", id, err, start)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(order); err != nil {
+		logger.LogErrorContext(r.Context(), "handlers", "TransitionOrder", err, map[string]interface{}{
+			"order_id": id,
+		})
+		return
+	}
+
+	duration := float64(time.Since(start).Nanoseconds()) / 1e6
+	logger.InfoContext(r.Context(), "Order transition request completed successfully", map[string]interface{}{
+		"handler":     "TransitionOrder",
+		"order_id":    id,
+		"status":      order.Status,
+		"duration_ms": duration,
+	})
+}
+
+// GetOrderEvents handles GET /api/orders/{id}/events requests
+func (oh *OrderHandler) GetOrderEvents(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	w.Header().Set("Content-Type", "application/json")
+
+	id := mux.Vars(r)["id"]
+	logger.InfoContext(r.Context(), "Handling order events request", map[string]interface{}{
+		"handler":  "GetOrderEvents",
+		"order_id": id,
+		"method":   r.Method,
+		"path":     r.URL.Path,
+	})
+
+	events, err := oh.orderService.OrderEvents(r.Context(), id, orderAccess(r))
+	if err != nil {
+		writeOrderError(w, r, "GetOrderEvents", id, err, start)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(events); err != nil {
+		logger.LogErrorContext(r.Context(), "handlers", "GetOrderEvents", err, map[string]interface{}{
+			"order_id": id,
+		})
+		http.Error(w, "Failed to encode order events", http.StatusInternalServerError)
+		return
+	}
+
+	duration := float64(time.Since(start).Nanoseconds()) / 1e6
+	logger.InfoContext(r.Context(), "Order events request completed successfully", map[string]interface{}{
+		"handler":     "GetOrderEvents",
+		"order_id":    id,
+		"count":       len(events),
+		"duration_ms": duration,
+	})
+}
+
+// orderAccess describes the caller of an order read request
+func orderAccess(r *http.Request) models.OrderAccess {
+	access := models.OrderAccess{
+		UserID:      auth.SubjectFromContext(r.Context()),
+		AccessToken: r.Header.Get(OrderTokenHeader),
+	}
+	if claims, ok := auth.ClaimsFromContext(r.Context()); ok {
+		access.ManageAll = claims.Allows(auth.PermissionManageOrders)
+	}
+	return access
+}
+
+// writeOrderError maps order service errors to HTTP responses
+func writeOrderError(w http.ResponseWriter, r *http.Request, handler, id string, err error, start time.Time) {
+	duration := float64(time.Since(start).Nanoseconds()) / 1e6
+
+	var validationErr *models.ValidationError
+	status := http.StatusInternalServerError
+	message := "Failed to process order"
+	switch {
+	case errors.As(err, &validationErr):
+		status, message = http.StatusBadRequest, err.Error()
+	case errors.Is(err, services.ErrInsufficientStock):
+		status, message = http.StatusConflict, err.Error()
+	case errors.Is(err, services.ErrCartNotFound):
+		status, message = http.StatusNotFound, "Cart not found"
+	case errors.Is(err, services.ErrOrderNotFound):
+		status, message = http.StatusNotFound, "Order not found"
+	case errors.Is(err, services.ErrInvalidTransition), errors.Is(err, services.ErrPaymentState):
+		status, message = http.StatusConflict, err.Error()
+	case errors.Is(err, services.ErrPaymentDeclined):
+		status, message = http.StatusPaymentRequired, err.Error()
+	case errors.Is(err, services.ErrPaymentTimeout):
+		status, message = http.StatusGatewayTimeout, "Payment gateway timed out"
+	}
+
+	if status == http.StatusInternalServerError {
+		logger.LogErrorContext(r.Context(), "handlers", handler, err, map[string]interface{}{
+			"order_id":    id,
+			"duration_ms": duration,
+		})
+	} else {
+		logger.WarnContext(r.Context(), "Order request rejected", map[string]interface{}{
+			"handler":     handler,
+			"order_id":    id,
+			"error":       err.Error(),
+			"duration_ms": duration,
+		})
+	}
+	http.Error(w, message, status)
+}
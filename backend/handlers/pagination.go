@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"ecommerce-backend/logger"
+	"ecommerce-backend/models"
+	"ecommerce-backend/services"
+)
+
+// parsePageRequest reads the limit, offset, cursor and sort query parameters
+func parsePageRequest(r *http.Request) (models.PageRequest, error) {
+	query := r.URL.Query()
+	page := models.PageRequest{
+		Cursor: query.Get("cursor"),
+		Sort:   query.Get("sort"),
+	}
+
+	if limitStr := query.Get("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit < 1 {
+			return page, fmt.Errorf("Invalid limit %q", limitStr)
+		}
+		page.Limit = limit
+	}
+
+	if offsetStr := query.Get("offset"); offsetStr != "" {
+		offset, err := strconv.Atoi(offsetStr)
+		if err != nil || offset < 0 {
+			return page, fmt.Errorf("Invalid offset %q", offsetStr)
+		}
+		page.Offset = offset
+	}
+
+	return page, nil
+}
+
+// writePageError responds to a pagination failure; invalid page requests are client errors
+func writePageError(w http.ResponseWriter, r *http.Request, handler string, err error, start time.Time) {
+	duration := float64(time.Since(start).Nanoseconds()) / 1e6
+	if errors.Is(err, services.ErrInvalidPageRequest) {
+		logger.WarnContext(r.Context(), "Invalid page request", map[string]interface{}{
+			"handler":     handler,
+			"error":       err.Error(),
+			"duration_ms": duration,
+		})
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	logger.LogErrorContext(r.Context(), "handlers", handler, err, map[string]interface{}{
+		"duration_ms": duration,
+	})
+	http.Error(w, "Failed to paginate products", http.StatusInternalServerError)
+}
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"ecommerce-backend/logger"
+	"ecommerce-backend/models"
+	"ecommerce-backend/payments"
+	"ecommerce-backend/services"
+)
+
+// maxWebhookBodyBytes caps the size of payment webhook payloads
+const maxWebhookBodyBytes = 64 << 10
+
+// PaymentHandler receives payment gateway webhooks
+type PaymentHandler struct {
+	orderService  *services.OrderService
+	webhookSecret string
+}
+
+// NewPaymentHandler creates a new payment handler that accepts webhooks signed with webhookSecret
+func NewPaymentHandler(orderService *services.OrderService, webhookSecret string) *PaymentHandler {
+	return &PaymentHandler{
+		orderService:  orderService,
+		webhookSecret: webhookSecret,
+	}
+}
+
+// ReceiveWebhook handles POST /api/payments/webhook requests
+func (ph *PaymentHandler) ReceiveWebhook(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	w.Header().Set("Content-Type", "application/json")
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookBodyBytes))
+	if err != nil {
+		ph.reject(w, r, "Invalid webhook body", err, http.StatusBadRequest, start)
+		return
+	}
+
+	// The signature covers the raw body, so check it before decoding anything
+	if err := payments.VerifySignature(ph.webhookSecret, r.Header.Get(payments.SignatureHeader), body, time.Now()); err != nil {
+		ph.reject(w, r, "Invalid webhook signature", err, http.StatusUnauthorized, start)
+		return
+	}
+
+	var event payments.Event
+	if err := json.Unmarshal(body, &event); err != nil {
+		ph.reject(w, r, "Invalid webhook body", err, http.StatusBadRequest, start)
+		return
+	}
+
+	logger.InfoContext(r.Context(), "Handling payment webhook", map[string]interface{}{
+		"handler":    "ReceiveWebhook",
+		"event_id":   event.ID,
+		"event_type": event.Type,
+		"payment_id": event.Payment.ID,
+		"order_id":   event.Payment.OrderID,
+	})
+
+	if err := ph.orderService.HandlePaymentEvent(r.Context(), event); err != nil {
+		var validationErr *models.ValidationError
+		switch {
+		case errors.As(err, &validationErr):
+			ph.reject(w, r, err.Error(), err, http.StatusBadRequest, start)
+		case errors.Is(err, services.ErrOrderNotFound):
+			ph.reject(w, r, "Order not found", err, http.StatusNotFound, start)
+		default:
+			logger.LogErrorContext(r.Context(), "handlers", "ReceiveWebhook", err, map[string]interface{}{
+				"event_id": event.ID,
+			})
+			http.Error(w, "Failed to process webhook", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{"received": true}); err != nil {
+		logger.LogErrorContext(r.Context(), "handlers", "ReceiveWebhook", err, map[string]interface{}{
+			"event_id": event.ID,
+		})
+		return
+	}
+
+	duration := float64(time.Since(start).Nanoseconds()) / 1e6
+	logger.InfoContext(r.Context(), "Payment webhook processed successfully", map[string]interface{}{
+		"handler":     "ReceiveWebhook",
+		"event_id":    event.ID,
+		"duration_ms": duration,
+	})
+}
+
+// reject logs and answers a webhook that can't be processed
+func (ph *PaymentHandler) reject(w http.ResponseWriter, r *http.Request, message string, err error, status int, start time.Time) {
+	duration := float64(time.Since(start).Nanoseconds()) / 1e6
+	logger.WarnContext(r.Context(), "Payment webhook rejected", map[string]interface{}{
+		"handler":     "ReceiveWebhook",
+		"error":       err.Error(),
+		"status":      status,
+		"duration_ms": duration,
+	})
+	http.Error(w, message, status)
+}
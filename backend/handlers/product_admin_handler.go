@@ -0,0 +1,261 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"ecommerce-backend/logger"
+	"ecommerce-backend/models"
+	"ecommerce-backend/services"
+	"github.com/gorilla/mux"
+)
+
+// maxProductBodyBytes caps the size of product JSON payloads
+const maxProductBodyBytes = 1 << 20
+
+// CreateProduct handles POST /api/admin/products and POST /api/products requests
+func (ph *ProductHandler) CreateProduct(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	w.Header().Set("Content-Type", "application/json")
+
+	var product models.Product
+	if err := decodeJSONBody(w, r, &product); err != nil {
+		duration := float64(time.Since(start).Nanoseconds()) / 1e6
+		logger.WarnContext(r.Context(), "Invalid create product body", map[string]interface{}{
+			"handler":     "CreateProduct",
+			"error":       err.Error(),
+			"duration_ms": duration,
+		})
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	logger.InfoContext(r.Context(), "Handling create product request", map[string]interface{}{
+		"handler":      "CreateProduct",
+		"product_name": product.Name,
+		"method":       r.Method,
+		"path":         r.URL.Path,
+	})
+
+	created, err := ph.productService.CreateProduct(r.Context(), product)
+	if err != nil {
+		ph.writeMutationError(w, r, "CreateProduct", 0, err, start)
+		return
+	}
+
+	w.Header().Set("Location", fmt.Sprintf("/api/products/%d", created.ID))
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(created); err != nil {
+		logger.LogErrorContext(r.Context(), "handlers", "CreateProduct", err, map[string]interface{}{
+			"product_id": created.ID,
+		})
+		return
+	}
+
+	duration := float64(time.Since(start).Nanoseconds()) / 1e6
+	logger.InfoContext(r.Context(), "Create product request completed successfully", map[string]interface{}{
+		"handler":     "CreateProduct",
+		"product_id":  created.ID,
+		"duration_ms": duration,
+	})
+}
+
+// UpdateProduct handles PUT /api/admin/products/{id} and PUT /api/products/{id} requests
+func (ph *ProductHandler) UpdateProduct(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	w.Header().Set("Content-Type", "application/json")
+
+	id, ok := parseProductID(w, r, "UpdateProduct", start)
+	if !ok {
+		return
+	}
+
+	var product models.Product
+	if err := decodeJSONBody(w, r, &product); err != nil {
+		duration := float64(time.Since(start).Nanoseconds()) / 1e6
+		logger.WarnContext(r.Context(), "Invalid update product body", map[string]interface{}{
+			"handler":     "UpdateProduct",
+			"product_id":  id,
+			"error":       err.Error(),
+			"duration_ms": duration,
+		})
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	logger.InfoContext(r.Context(), "Handling update product request", map[string]interface{}{
+		"handler":    "UpdateProduct",
+		"product_id": id,
+		"method":     r.Method,
+		"path":       r.URL.Path,
+	})
+
+	updated, err := ph.productService.UpdateProduct(r.Context(), id, product)
+	if err != nil {
+		ph.writeMutationError(w, r, "UpdateProduct", id, err, start)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(updated); err != nil {
+		logger.LogErrorContext(r.Context(), "handlers", "UpdateProduct", err, map[string]interface{}{
+			"product_id": id,
+		})
+		http.Error(w, "Failed to encode product", http.StatusInternalServerError)
+		return
+	}
+
+	duration := float64(time.Since(start).Nanoseconds()) / 1e6
+	logger.InfoContext(r.Context(), "Update product request completed successfully", map[string]interface{}{
+		"handler":     "UpdateProduct",
+		"product_id":  id,
+		"duration_ms": duration,
+	})
+}
+
+// PatchProduct handles PATCH /api/admin/products/{id} and PATCH /api/products/{id} requests
+func (ph *ProductHandler) PatchProduct(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	w.Header().Set("Content-Type", "application/json")
+
+	id, ok := parseProductID(w, r, "PatchProduct", start)
+	if !ok {
+		return
+	}
+
+	var patch models.ProductPatch
+	if err := decodeJSONBody(w, r, &patch); err != nil {
+		duration := float64(time.Since(start).Nanoseconds()) / 1e6
+		logger.WarnContext(r.Context(), "Invalid patch product body", map[string]interface{}{
+			"handler":     "PatchProduct",
+			"product_id":  id,
+			"error":       err.Error(),
+			"duration_ms": duration,
+		})
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	logger.InfoContext(r.Context(), "Handling patch product request", map[string]interface{}{
+		"handler":    "PatchProduct",
+		"product_id": id,
+		"method":     r.Method,
+		"path":       r.URL.Path,
+	})
+
+	updated, err := ph.productService.PatchProduct(r.Context(), id, patch)
+	if err != nil {
+		ph.writeMutationError(w, r, "PatchProduct", id, err, start)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(updated); err != nil {
+		logger.LogErrorContext(r.Context(), "handlers", "PatchProduct", err, map[string]interface{}{
+			"product_id": id,
+		})
+		http.Error(w, "Failed to encode product", http.StatusInternalServerError)
+		return
+	}
+
+	duration := float64(time.Since(start).Nanoseconds()) / 1e6
+	logger.InfoContext(r.Context(), "Patch product request completed successfully", map[string]interface{}{
+		"handler":     "PatchProduct",
+		"product_id":  id,
+		"duration_ms": duration,
+	})
+}
+
+// DeleteProduct handles DELETE /api/admin/products/{id} and DELETE /api/products/{id} requests
+func (ph *ProductHandler) DeleteProduct(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+
+	id, ok := parseProductID(w, r, "DeleteProduct", start)
+	if !ok {
+		return
+	}
+
+	logger.InfoContext(r.Context(), "Handling delete product request", map[string]interface{}{
+		"handler":    "DeleteProduct",
+		"product_id": id,
+		"method":     r.Method,
+		"path":       r.URL.Path,
+	})
+
+	if err := ph.productService.DeleteProduct(r.Context(), id); err != nil {
+		ph.writeMutationError(w, r, "DeleteProduct", id, err, start)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+
+	duration := float64(time.Since(start).Nanoseconds()) / 1e6
+	logger.InfoContext(r.Context(), "Delete product request completed successfully", map[string]interface{}{
+		"handler":     "DeleteProduct",
+		"product_id":  id,
+		"duration_ms": duration,
+	})
+}
+
+// writeMutationError maps service errors from write operations to HTTP responses
+func (ph *ProductHandler) writeMutationError(w http.ResponseWriter, r *http.Request, handler string, id int, err error, start time.Time) {
+	duration := float64(time.Since(start).Nanoseconds()) / 1e6
+
+	var validationErr *models.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		logger.WarnContext(r.Context(), "Product validation failed in handler", map[string]interface{}{
+			"handler":     handler,
+			"product_id":  id,
+			"problems":    validationErr.Problems,
+			"duration_ms": duration,
+		})
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, services.ErrProductNotFound):
+		logger.WarnContext(r.Context(), "Product not found in handler", map[string]interface{}{
+			"handler":     handler,
+			"product_id":  id,
+			"duration_ms": duration,
+		})
+		http.Error(w, "Product not found", http.StatusNotFound)
+	default:
+		logger.LogErrorContext(r.Context(), "handlers", handler, err, map[string]interface{}{
+			"product_id":  id,
+			"duration_ms": duration,
+		})
+		http.Error(w, "Failed to save product", http.StatusInternalServerError)
+	}
+}
+
+// parseProductID extracts the {id} route variable, writing a 400 response if it is invalid
+func parseProductID(w http.ResponseWriter, r *http.Request, handler string, start time.Time) (int, bool) {
+	idStr := mux.Vars(r)["id"]
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		duration := float64(time.Since(start).Nanoseconds()) / 1e6
+		logger.LogErrorContext(r.Context(), "handlers", handler, err, map[string]interface{}{
+			"invalid_id":  idStr,
+			"duration_ms": duration,
+		})
+		http.Error(w, "Invalid product ID", http.StatusBadRequest)
+		return 0, false
+	}
+	return id, true
+}
+
+// decodeJSONBody decodes a size-limited JSON request body, rejecting unknown fields
+func decodeJSONBody(w http.ResponseWriter, r *http.Request, target interface{}) error {
+	r.Body = http.MaxBytesReader(w, r.Body, maxProductBodyBytes)
+
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(target); err != nil {
+		return fmt.Errorf("invalid JSON body: %v", err)
+	}
+	if decoder.More() {
+		return errors.New("invalid JSON body: unexpected data after JSON object")
+	}
+	return nil
+}
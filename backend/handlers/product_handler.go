@@ -2,11 +2,13 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 	"time"
 
 	"ecommerce-backend/logger"
+	"ecommerce-backend/models"
 	"ecommerce-backend/services"
 	"github.com/gorilla/mux"
 )
@@ -29,40 +31,26 @@ func (ph *ProductHandler) GetProducts(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
 	// Parse query parameters
-	gender := r.URL.Query().Get("gender")
-	category := r.URL.Query().Get("category")
-
-	logger.Info("Handling get products request", map[string]interface{}{
-		"handler":  "GetProducts",
-		"gender":   gender,
-		"category": category,
-		"method":   r.Method,
-		"path":     r.URL.Path,
-	})
-
-	// Get filtered products from service
-	products := ph.productService.GetAllProducts(gender, category)
-
-	// Encode and send response
-	if err := json.NewEncoder(w).Encode(products); err != nil {
+	filter, err := parseProductFilter(r)
+	if err != nil {
 		duration := float64(time.Since(start).Nanoseconds()) / 1e6
-		logger.LogError("handlers", "GetProducts", err, map[string]interface{}{
-			"gender":      gender,
-			"category":    category,
+		logger.WarnContext(r.Context(), "Invalid product filter", map[string]interface{}{
+			"handler":     "GetProducts",
+			"error":       err.Error(),
 			"duration_ms": duration,
 		})
-		http.Error(w, "Failed to encode products", http.StatusInternalServerError)
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	duration := float64(time.Since(start).Nanoseconds()) / 1e6
-	logger.Info("Products request completed successfully", map[string]interface{}{
-		"handler":      "GetProducts",
-		"products_count": len(products),
-		"gender":       gender,
-		"category":     category,
-		"duration_ms":  duration,
+	logger.InfoContext(r.Context(), "Handling get products request", map[string]interface{}{
+		"handler": "GetProducts",
+		"filter":  r.URL.RawQuery,
+		"method":  r.Method,
+		"path":    r.URL.Path,
 	})
+
+	ph.writeProductListing(w, r, "GetProducts", filter, start)
 }
 
 // GetProduct handles GET /api/products/{id} requests
@@ -76,7 +64,7 @@ func (ph *ProductHandler) GetProduct(w http.ResponseWriter, r *http.Request) {
 	id, err := strconv.Atoi(idStr)
 	if err != nil {
 		duration := float64(time.Since(start).Nanoseconds()) / 1e6
-		logger.LogError("handlers", "GetProduct", err, map[string]interface{}{
+		logger.LogErrorContext(r.Context(), "handlers", "GetProduct", err, map[string]interface{}{
 			"invalid_id":  idStr,
 			"duration_ms": duration,
 		})
@@ -84,7 +72,7 @@ func (ph *ProductHandler) GetProduct(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	logger.Info("Handling get product request", map[string]interface{}{
+	logger.InfoContext(r.Context(), "Handling get product request", map[string]interface{}{
 		"handler":    "GetProduct",
 		"product_id": id,
 		"method":     r.Method,
@@ -92,10 +80,10 @@ func (ph *ProductHandler) GetProduct(w http.ResponseWriter, r *http.Request) {
 	})
 
 	// Get product from service
-	product, found := ph.productService.GetProductByID(id)
-	if !found {
+	product, err := ph.productService.GetProductByID(r.Context(), id)
+	if errors.Is(err, services.ErrProductNotFound) {
 		duration := float64(time.Since(start).Nanoseconds()) / 1e6
-		logger.Warn("Product not found in handler", map[string]interface{}{
+		logger.WarnContext(r.Context(), "Product not found in handler", map[string]interface{}{
 			"handler":     "GetProduct",
 			"product_id":  id,
 			"duration_ms": duration,
@@ -103,11 +91,20 @@ func (ph *ProductHandler) GetProduct(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Product not found", http.StatusNotFound)
 		return
 	}
+	if err != nil {
+		duration := float64(time.Since(start).Nanoseconds()) / 1e6
+		logger.LogErrorContext(r.Context(), "handlers", "GetProduct", err, map[string]interface{}{
+			"product_id":  id,
+			"duration_ms": duration,
+		})
+		http.Error(w, "Failed to retrieve product", http.StatusInternalServerError)
+		return
+	}
 
 	// Encode and send response
 	if err := json.NewEncoder(w).Encode(product); err != nil {
 		duration := float64(time.Since(start).Nanoseconds()) / 1e6
-		logger.LogError("handlers", "GetProduct", err, map[string]interface{}{
+		logger.LogErrorContext(r.Context(), "handlers", "GetProduct", err, map[string]interface{}{
 			"product_id":  id,
 			"duration_ms": duration,
 		})
@@ -116,7 +113,7 @@ func (ph *ProductHandler) GetProduct(w http.ResponseWriter, r *http.Request) {
 	}
 
 	duration := float64(time.Since(start).Nanoseconds()) / 1e6
-	logger.Info("Product request completed successfully", map[string]interface{}{
+	logger.InfoContext(r.Context(), "Product request completed successfully", map[string]interface{}{
 		"handler":      "GetProduct",
 		"product_id":   id,
 		"product_name": product.Name,
@@ -129,19 +126,27 @@ func (ph *ProductHandler) GetCategories(w http.ResponseWriter, r *http.Request)
 	start := time.Now()
 	w.Header().Set("Content-Type", "application/json")
 
-	logger.Info("Handling get categories request", map[string]interface{}{
+	logger.InfoContext(r.Context(), "Handling get categories request", map[string]interface{}{
 		"handler": "GetCategories",
 		"method":  r.Method,
 		"path":    r.URL.Path,
 	})
 
 	// Get categories from service
-	categories := ph.productService.GetCategories()
+	categories, err := ph.productService.GetCategories(r.Context())
+	if err != nil {
+		duration := float64(time.Since(start).Nanoseconds()) / 1e6
+		logger.LogErrorContext(r.Context(), "handlers", "GetCategories", err, map[string]interface{}{
+			"duration_ms": duration,
+		})
+		http.Error(w, "Failed to retrieve categories", http.StatusInternalServerError)
+		return
+	}
 
 	// Encode and send response
 	if err := json.NewEncoder(w).Encode(categories); err != nil {
 		duration := float64(time.Since(start).Nanoseconds()) / 1e6
-		logger.LogError("handlers", "GetCategories", err, map[string]interface{}{
+		logger.LogErrorContext(r.Context(), "handlers", "GetCategories", err, map[string]interface{}{
 			"duration_ms": duration,
 		})
 		http.Error(w, "Failed to encode categories", http.StatusInternalServerError)
@@ -149,7 +154,7 @@ func (ph *ProductHandler) GetCategories(w http.ResponseWriter, r *http.Request)
 	}
 
 	duration := float64(time.Since(start).Nanoseconds()) / 1e6
-	logger.Info("Categories request completed successfully", map[string]interface{}{
+	logger.InfoContext(r.Context(), "Categories request completed successfully", map[string]interface{}{
 		"handler":          "GetCategories",
 		"categories_count": len(categories),
 		"duration_ms":      duration,
@@ -161,7 +166,12 @@ func (ph *ProductHandler) GetGenders(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
 	// Get genders from service
-	genders := ph.productService.GetGenders()
+	genders, err := ph.productService.GetGenders(r.Context())
+	if err != nil {
+		logger.LogErrorContext(r.Context(), "handlers", "GetGenders", err, nil)
+		http.Error(w, "Failed to retrieve genders", http.StatusInternalServerError)
+		return
+	}
 
 	// Encode and send response
 	if err := json.NewEncoder(w).Encode(genders); err != nil {
@@ -170,7 +180,8 @@ func (ph *ProductHandler) GetGenders(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-// SearchProducts handles GET /api/products/search requests
+// SearchProducts handles GET /api/products/search requests.
+// It is an alias for GET /api/products that requires the q parameter.
 func (ph *ProductHandler) SearchProducts(w http.ResponseWriter, r *http.Request) {
 	start := time.Now()
 	w.Header().Set("Content-Type", "application/json")
@@ -179,7 +190,7 @@ func (ph *ProductHandler) SearchProducts(w http.ResponseWriter, r *http.Request)
 	query := r.URL.Query().Get("q")
 	if query == "" {
 		duration := float64(time.Since(start).Nanoseconds()) / 1e6
-		logger.Warn("Search request missing query parameter", map[string]interface{}{
+		logger.WarnContext(r.Context(), "Search request missing query parameter", map[string]interface{}{
 			"handler":     "SearchProducts",
 			"duration_ms": duration,
 		})
@@ -187,37 +198,30 @@ func (ph *ProductHandler) SearchProducts(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	logger.Info("Handling search products request", map[string]interface{}{
-		"handler":      "SearchProducts",
-		"search_query": query,
-		"method":       r.Method,
-		"path":         r.URL.Path,
-	})
-
-	// Search products using service
-	products := ph.productService.SearchProducts(query)
-
-	// Encode and send response
-	if err := json.NewEncoder(w).Encode(products); err != nil {
+	filter, err := parseProductFilter(r)
+	if err != nil {
 		duration := float64(time.Since(start).Nanoseconds()) / 1e6
-		logger.LogError("handlers", "SearchProducts", err, map[string]interface{}{
-			"search_query": query,
-			"duration_ms":  duration,
+		logger.WarnContext(r.Context(), "Invalid product filter", map[string]interface{}{
+			"handler":     "SearchProducts",
+			"error":       err.Error(),
+			"duration_ms": duration,
 		})
-		http.Error(w, "Failed to encode search results", http.StatusInternalServerError)
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	duration := float64(time.Since(start).Nanoseconds()) / 1e6
-	logger.Info("Search request completed successfully", map[string]interface{}{
-		"handler":       "SearchProducts",
-		"search_query":  query,
-		"results_count": len(products),
-		"duration_ms":   duration,
+	logger.InfoContext(r.Context(), "Handling search products request", map[string]interface{}{
+		"handler":      "SearchProducts",
+		"search_query": query,
+		"method":       r.Method,
+		"path":         r.URL.Path,
 	})
+
+	ph.writeProductListing(w, r, "SearchProducts", filter, start)
 }
 
-// GetProductsByPriceRange handles GET /api/products/price-range requests
+// GetProductsByPriceRange handles GET /api/products/price-range requests.
+// It is an alias for GET /api/products that requires the min and max parameters.
 func (ph *ProductHandler) GetProductsByPriceRange(w http.ResponseWriter, r *http.Request) {
 	start := time.Now()
 	w.Header().Set("Content-Type", "application/json")
@@ -228,7 +232,7 @@ func (ph *ProductHandler) GetProductsByPriceRange(w http.ResponseWriter, r *http
 
 	if minPriceStr == "" || maxPriceStr == "" {
 		duration := float64(time.Since(start).Nanoseconds()) / 1e6
-		logger.Warn("Price range request missing parameters", map[string]interface{}{
+		logger.WarnContext(r.Context(), "Price range request missing parameters", map[string]interface{}{
 			"handler":     "GetProductsByPriceRange",
 			"min_price":   minPriceStr,
 			"max_price":   maxPriceStr,
@@ -238,57 +242,67 @@ func (ph *ProductHandler) GetProductsByPriceRange(w http.ResponseWriter, r *http
 		return
 	}
 
-	minPrice, err := strconv.ParseFloat(minPriceStr, 64)
+	filter, err := parseProductFilter(r)
 	if err != nil {
 		duration := float64(time.Since(start).Nanoseconds()) / 1e6
-		logger.LogError("handlers", "GetProductsByPriceRange", err, map[string]interface{}{
-			"invalid_min_price": minPriceStr,
-			"duration_ms":       duration,
+		logger.WarnContext(r.Context(), "Invalid product filter", map[string]interface{}{
+			"handler":     "GetProductsByPriceRange",
+			"error":       err.Error(),
+			"duration_ms": duration,
 		})
-		http.Error(w, "Invalid min price format", http.StatusBadRequest)
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	maxPrice, err := strconv.ParseFloat(maxPriceStr, 64)
+	logger.InfoContext(r.Context(), "Handling price range request", map[string]interface{}{
+		"handler":   "GetProductsByPriceRange",
+		"min_price": *filter.MinPrice,
+		"max_price": *filter.MaxPrice,
+		"method":    r.Method,
+		"path":      r.URL.Path,
+	})
+
+	ph.writeProductListing(w, r, "GetProductsByPriceRange", filter, start)
+}
+
+// writeProductListing runs a filtered listing through the service and writes one paginated page
+func (ph *ProductHandler) writeProductListing(w http.ResponseWriter, r *http.Request, handler string, filter models.ProductFilter, start time.Time) {
+	pageRequest, err := parsePageRequest(r)
 	if err != nil {
 		duration := float64(time.Since(start).Nanoseconds()) / 1e6
-		logger.LogError("handlers", "GetProductsByPriceRange", err, map[string]interface{}{
-			"invalid_max_price": maxPriceStr,
-			"duration_ms":       duration,
+		logger.WarnContext(r.Context(), "Invalid pagination parameters", map[string]interface{}{
+			"handler":     handler,
+			"error":       err.Error(),
+			"duration_ms": duration,
 		})
-		http.Error(w, "Invalid max price format", http.StatusBadRequest)
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	if minPrice > maxPrice {
+	// Get filtered products from service
+	listing, err := ph.productService.ListProducts(r.Context(), filter)
+	if err != nil {
 		duration := float64(time.Since(start).Nanoseconds()) / 1e6
-		logger.Warn("Invalid price range", map[string]interface{}{
-			"handler":     "GetProductsByPriceRange",
-			"min_price":   minPrice,
-			"max_price":   maxPrice,
+		logger.LogErrorContext(r.Context(), "handlers", handler, err, map[string]interface{}{
+			"filter":      r.URL.RawQuery,
 			"duration_ms": duration,
 		})
-		http.Error(w, "Min price cannot be greater than max price", http.StatusBadRequest)
+		http.Error(w, "Failed to retrieve products", http.StatusInternalServerError)
 		return
 	}
 
-	logger.Info("Handling price range request", map[string]interface{}{
-		"handler":   "GetProductsByPriceRange",
-		"min_price": minPrice,
-		"max_price": maxPrice,
-		"method":    r.Method,
-		"path":      r.URL.Path,
-	})
-
-	// Get products by price range from service
-	products := ph.productService.GetProductsByPriceRange(minPrice, maxPrice)
+	// Sort and slice the requested page
+	page, err := services.PaginateProducts(listing, pageRequest, filter)
+	if err != nil {
+		writePageError(w, r, handler, err, start)
+		return
+	}
 
 	// Encode and send response
-	if err := json.NewEncoder(w).Encode(products); err != nil {
+	if err := json.NewEncoder(w).Encode(page); err != nil {
 		duration := float64(time.Since(start).Nanoseconds()) / 1e6
-		logger.LogError("handlers", "GetProductsByPriceRange", err, map[string]interface{}{
-			"min_price":   minPrice,
-			"max_price":   maxPrice,
+		logger.LogErrorContext(r.Context(), "handlers", handler, err, map[string]interface{}{
+			"filter":      r.URL.RawQuery,
 			"duration_ms": duration,
 		})
 		http.Error(w, "Failed to encode products", http.StatusInternalServerError)
@@ -296,11 +310,11 @@ func (ph *ProductHandler) GetProductsByPriceRange(w http.ResponseWriter, r *http
 	}
 
 	duration := float64(time.Since(start).Nanoseconds()) / 1e6
-	logger.Info("Price range request completed successfully", map[string]interface{}{
-		"handler":       "GetProductsByPriceRange",
-		"min_price":     minPrice,
-		"max_price":     maxPrice,
-		"results_count": len(products),
-		"duration_ms":   duration,
+	logger.InfoContext(r.Context(), "Products request completed successfully", map[string]interface{}{
+		"handler":        handler,
+		"products_count": len(page.Items),
+		"total_count":    page.Total,
+		"filter":         r.URL.RawQuery,
+		"duration_ms":    duration,
 	})
-}
\ No newline at end of file
+}
@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"ecommerce-backend/logger"
+	"ecommerce-backend/search"
+	"ecommerce-backend/services"
+)
+
+// suggestResponse is the body returned by the autocomplete endpoint
+type suggestResponse struct {
+	Prefix      string              `json:"prefix"`
+	Suggestions []search.Completion `json:"suggestions"`
+}
+
+// SuggestProducts handles GET /api/products/suggest requests.
+// It returns type-ahead completions for the prefix parameter.
+func (ph *ProductHandler) SuggestProducts(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	w.Header().Set("Content-Type", "application/json")
+
+	prefix := strings.TrimSpace(r.URL.Query().Get("prefix"))
+	if prefix == "" {
+		duration := float64(time.Since(start).Nanoseconds()) / 1e6
+		logger.WarnContext(r.Context(), "Suggest request missing prefix parameter", map[string]interface{}{
+			"handler":     "SuggestProducts",
+			"duration_ms": duration,
+		})
+		http.Error(w, "Prefix is required", http.StatusBadRequest)
+		return
+	}
+
+	limit, err := parseSuggestLimit(r)
+	if err != nil {
+		duration := float64(time.Since(start).Nanoseconds()) / 1e6
+		logger.WarnContext(r.Context(), "Invalid suggest limit", map[string]interface{}{
+			"handler":     "SuggestProducts",
+			"error":       err.Error(),
+			"duration_ms": duration,
+		})
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	logger.DebugContext(r.Context(), "Handling suggest products request", map[string]interface{}{
+		"handler": "SuggestProducts",
+		"prefix":  prefix,
+		"limit":   limit,
+		"method":  r.Method,
+		"path":    r.URL.Path,
+	})
+
+	response := suggestResponse{
+		Prefix:      prefix,
+		Suggestions: ph.productService.SuggestProducts(r.Context(), prefix, limit),
+	}
+
+	// Encode and send response
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		duration := float64(time.Since(start).Nanoseconds()) / 1e6
+		logger.LogErrorContext(r.Context(), "handlers", "SuggestProducts", err, map[string]interface{}{
+			"prefix":      prefix,
+			"duration_ms": duration,
+		})
+		http.Error(w, "Failed to encode suggestions", http.StatusInternalServerError)
+		return
+	}
+
+	duration := float64(time.Since(start).Nanoseconds()) / 1e6
+	logger.DebugContext(r.Context(), "Suggest request completed successfully", map[string]interface{}{
+		"handler":           "SuggestProducts",
+		"prefix":            prefix,
+		"suggestions_count": len(response.Suggestions),
+		"duration_ms":       duration,
+	})
+}
+
+// parseSuggestLimit reads the limit query parameter of the autocomplete endpoint
+func parseSuggestLimit(r *http.Request) (int, error) {
+	limitStr := r.URL.Query().Get("limit")
+	if limitStr == "" {
+		return services.DefaultSuggestLimit, nil
+	}
+	limit, err := strconv.Atoi(limitStr)
+	if err != nil || limit < 1 || limit > services.MaxSuggestLimit {
+		return 0, fmt.Errorf("Invalid limit %q: must be between 1 and %d", limitStr, services.MaxSuggestLimit)
+	}
+	return limit, nil
+}
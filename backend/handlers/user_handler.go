@@ -0,0 +1,213 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"ecommerce-backend/auth"
+	"ecommerce-backend/logger"
+	"ecommerce-backend/models"
+	"ecommerce-backend/services"
+)
+
+// UserHandler handles HTTP requests for accounts and sessions
+type UserHandler struct {
+	userService *services.UserService
+}
+
+// NewUserHandler creates a new user handler
+func NewUserHandler(userService *services.UserService) *UserHandler {
+	return &UserHandler{
+		userService: userService,
+	}
+}
+
+// Register handles POST /api/users requests
+func (uh *UserHandler) Register(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	w.Header().Set("Content-Type", "application/json")
+
+	var registration models.Registration
+	if err := decodeJSONBody(w, r, &registration); err != nil {
+		writeUserError(w, r, "Register", &models.ValidationError{Problems: []string{err.Error()}}, start)
+		return
+	}
+
+	logger.InfoContext(r.Context(), "Handling register request", map[string]interface{}{
+		"handler": "Register",
+		"email":   models.NormalizeEmail(registration.Email),
+		"method":  r.Method,
+		"path":    r.URL.Path,
+	})
+
+	user, err := uh.userService.Register(r.Context(), registration)
+	if err != nil {
+		writeUserError(w, r, "Register", err, start)
+		return
+	}
+
+	w.Header().Set("Location", "/api/users/me")
+	writeUserResponse(w, r, "Register", http.StatusCreated, user, start)
+}
+
+// VerifyEmail handles POST /api/users/verify requests
+func (uh *UserHandler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	w.Header().Set("Content-Type", "application/json")
+
+	var request models.VerificationRequest
+	if err := decodeJSONBody(w, r, &request); err != nil {
+		writeUserError(w, r, "VerifyEmail", &models.ValidationError{Problems: []string{err.Error()}}, start)
+		return
+	}
+
+	logger.InfoContext(r.Context(), "Handling verify email request", map[string]interface{}{
+		"handler": "VerifyEmail",
+		"method":  r.Method,
+		"path":    r.URL.Path,
+	})
+
+	user, err := uh.userService.VerifyEmail(r.Context(), request.Token)
+	if err != nil {
+		writeUserError(w, r, "VerifyEmail", err, start)
+		return
+	}
+	writeUserResponse(w, r, "VerifyEmail", http.StatusOK, user, start)
+}
+
+// GetCurrentUser handles GET /api/users/me requests
+func (uh *UserHandler) GetCurrentUser(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	w.Header().Set("Content-Type", "application/json")
+
+	subject := auth.SubjectFromContext(r.Context())
+	logger.InfoContext(r.Context(), "Handling current user request", map[string]interface{}{
+		"handler": "GetCurrentUser",
+		"user_id": subject,
+		"method":  r.Method,
+		"path":    r.URL.Path,
+	})
+
+	user, err := uh.userService.GetUser(r.Context(), subject)
+	if err != nil {
+		writeUserError(w, r, "GetCurrentUser", err, start)
+		return
+	}
+	writeUserResponse(w, r, "GetCurrentUser", http.StatusOK, user, start)
+}
+
+// Login handles POST /api/auth/login requests
+func (uh *UserHandler) Login(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	w.Header().Set("Content-Type", "application/json")
+
+	var request models.LoginRequest
+	if err := decodeJSONBody(w, r, &request); err != nil {
+		writeUserError(w, r, "Login", &models.ValidationError{Problems: []string{err.Error()}}, start)
+		return
+	}
+
+	logger.InfoContext(r.Context(), "Handling login request", map[string]interface{}{
+		"handler": "Login",
+		"email":   models.NormalizeEmail(request.Email),
+		"method":  r.Method,
+		"path":    r.URL.Path,
+	})
+
+	tokens, err := uh.userService.Login(r.Context(), request)
+	if err != nil {
+		writeUserError(w, r, "Login", err, start)
+		return
+	}
+	writeUserResponse(w, r, "Login", http.StatusOK, tokens, start)
+}
+
+// Refresh handles POST /api/auth/refresh requests
+func (uh *UserHandler) Refresh(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	w.Header().Set("Content-Type", "application/json")
+
+	var request models.RefreshRequest
+	if err := decodeJSONBody(w, r, &request); err != nil {
+		writeUserError(w, r, "Refresh", &models.ValidationError{Problems: []string{err.Error()}}, start)
+		return
+	}
+
+	logger.InfoContext(r.Context(), "Handling refresh request", map[string]interface{}{
+		"handler": "Refresh",
+		"method":  r.Method,
+		"path":    r.URL.Path,
+	})
+
+	tokens, err := uh.userService.Refresh(r.Context(), request.RefreshToken)
+	if err != nil {
+		writeUserError(w, r, "Refresh", err, start)
+		return
+	}
+	writeUserResponse(w, r, "Refresh", http.StatusOK, tokens, start)
+}
+
+// writeUserResponse encodes a successful account or session response
+func writeUserResponse(w http.ResponseWriter, r *http.Request, handler string, status int, body interface{}, start time.Time) {
+	// Sessions carry credentials that must not be cached
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		logger.LogErrorContext(r.Context(), "handlers", handler, err, nil)
+		return
+	}
+
+	duration := float64(time.Since(start).Nanoseconds()) / 1e6
+	logger.InfoContext(r.Context(), fmt.Sprintf("%s request completed successfully", handler), map[string]interface{}{
+		"handler":     handler,
+		"status_code": status,
+		"duration_ms": duration,
+	})
+}
+
+// writeUserError maps user service errors to HTTP responses
+func writeUserError(w http.ResponseWriter, r *http.Request, handler string, err error, start time.Time) {
+	duration := float64(time.Since(start).Nanoseconds()) / 1e6
+
+	var validationErr *models.ValidationError
+	var lockedErr *services.AccountLockedError
+	status := http.StatusInternalServerError
+	message := "Failed to process request"
+	switch {
+	case errors.As(err, &validationErr):
+		status, message = http.StatusBadRequest, err.Error()
+	case errors.Is(err, services.ErrEmailTaken):
+		status, message = http.StatusConflict, "Email is already registered"
+	case errors.Is(err, services.ErrInvalidCredentials):
+		status, message = http.StatusUnauthorized, "Invalid email or password"
+	case errors.As(err, &lockedErr):
+		retryAfter := int(math.Ceil(time.Until(lockedErr.Until).Seconds()))
+		w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+		status, message = http.StatusLocked, "Account is temporarily locked after too many failed logins"
+	case errors.Is(err, services.ErrInvalidRefreshToken):
+		status, message = http.StatusUnauthorized, "Invalid refresh token"
+	case errors.Is(err, services.ErrInvalidVerificationToken):
+		status, message = http.StatusBadRequest, "Invalid verification token"
+	case errors.Is(err, services.ErrUserNotFound):
+		status, message = http.StatusNotFound, "User not found"
+	}
+
+	if status == http.StatusInternalServerError {
+		logger.LogErrorContext(r.Context(), "handlers", handler, err, map[string]interface{}{
+			"duration_ms": duration,
+		})
+	} else {
+		logger.WarnContext(r.Context(), "User request rejected", map[string]interface{}{
+			"handler":     handler,
+			"error":       err.Error(),
+			"status_code": status,
+			"duration_ms": duration,
+		})
+	}
+	http.Error(w, message, status)
+}
@@ -0,0 +1,131 @@
+package logger
+
+import (
+	"context"
+
+	"github.com/sirupsen/logrus"
+)
+
+// RequestIDField is the log field that carries the request ID
+const RequestIDField = "request_id"
+
+// requestIDKey is the context key for the request ID
+type requestIDKey struct{}
+
+// WithRequestID returns a copy of ctx carrying the request ID
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFromContext returns the request ID stored in ctx, or "" if there is none
+func RequestIDFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// FromContext returns a log entry tagged with the request ID from ctx, if any.
+// Lines logged through it can be tied back to the HTTP request that caused them.
+func FromContext(ctx context.Context) *logrus.Entry {
+	entry := logrus.NewEntry(Logger)
+	if id := RequestIDFromContext(ctx); id != "" {
+		entry = entry.WithField(RequestIDField, id)
+	}
+	return entry
+}
+
+// withFields adds the optional fields to the entry for ctx
+func withFields(ctx context.Context, fields []logrus.Fields) *logrus.Entry {
+	entry := FromContext(ctx)
+	if len(fields) > 0 {
+		entry = entry.WithFields(fields[0])
+	}
+	return entry
+}
+
+// InfoContext logs an info message tagged with the request ID from ctx
+func InfoContext(ctx context.Context, message string, fields ...logrus.Fields) {
+	withFields(ctx, fields).Info(message)
+}
+
+// WarnContext logs a warning message tagged with the request ID from ctx
+func WarnContext(ctx context.Context, message string, fields ...logrus.Fields) {
+	withFields(ctx, fields).Warn(message)
+}
+
+// DebugContext logs a debug message tagged with the request ID from ctx
+func DebugContext(ctx context.Context, message string, fields ...logrus.Fields) {
+	withFields(ctx, fields).Debug(message)
+}
+
+// LogHTTPRequestContext logs HTTP request details tagged with the request ID from ctx
+func LogHTTPRequestContext(ctx context.Context, method, path, userAgent, clientIP string, statusCode int, duration float64) {
+	FromContext(ctx).WithFields(logrus.Fields{
+		"method":      method,
+		"path":        path,
+		"status_code": statusCode,
+		"duration_ms": duration,
+		"user_agent":  userAgent,
+		"client_ip":   clientIP,
+		"type":        "http_request",
+	}).Info("HTTP Request")
+}
+
+// LogServiceCallContext logs a service method call tagged with the request ID from ctx
+func LogServiceCallContext(ctx context.Context, service, method string, params map[string]interface{}) {
+	FromContext(ctx).WithFields(logrus.Fields{
+		"service": service,
+		"method":  method,
+		"params":  params,
+		"type":    "service_call",
+	}).Debug("Service method called")
+}
+
+// LogServiceResultContext logs a service method result tagged with the request ID from ctx
+func LogServiceResultContext(ctx context.Context, service, method string, resultCount int, duration float64) {
+	FromContext(ctx).WithFields(logrus.Fields{
+		"service":      service,
+		"method":       method,
+		"result_count": resultCount,
+		"duration_ms":  duration,
+		"type":         "service_result",
+	}).Debug("Service method completed")
+}
+
+// LogErrorContext logs an application error tagged with the request ID from ctx
+func LogErrorContext(ctx context.Context, component, operation string, err error, extra map[string]interface{}) {
+	fields := logrus.Fields{
+		"component": component,
+		"operation": operation,
+		"type":      "application_error",
+	}
+	if err != nil {
+		fields["error"] = err.Error()
+	}
+	for key, value := range extra {
+		fields[key] = value
+	}
+
+	FromContext(ctx).WithFields(fields).Error("Application error occurred")
+}
+
+// LogAuditContext logs a security relevant decision tagged with the request ID from ctx
+func LogAuditContext(ctx context.Context, action, outcome string, extra map[string]interface{}) {
+	fields := logrus.Fields{
+		"action":  action,
+		"outcome": outcome,
+		"type":    "audit",
+	}
+	for key, value := range extra {
+		fields[key] = value
+	}
+
+	entry := FromContext(ctx).WithFields(fields)
+	if outcome == "denied" {
+		entry.Warn("Audit event")
+		return
+	}
+	entry.Info("Audit event")
+}
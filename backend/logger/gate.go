@@ -0,0 +1,44 @@
+package logger
+
+import "github.com/sirupsen/logrus"
+
+// droppedField marks an entry the gate rejected. It never reaches the output
+// since gateFormatter skips marked entries.
+const droppedField = "\x00dropped"
+
+// gateHook decides whether an entry is written before any other hook sees it.
+// logrus fires hooks before formatting, so the hooks that do work per entry,
+// such as redaction, are wrapped here and only run for entries that pass the
+// level of their component and the sampler, if any.
+type gateHook struct {
+	hooks   logrus.LevelHooks
+	sampler *sampler
+}
+
+// Levels implements logrus.Hook
+func (h *gateHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire implements logrus.Hook; rejected entries are marked and skip the wrapped hooks
+func (h *gateHook) Fire(entry *logrus.Entry) error {
+	if !levelEnabled(entry) || (h.sampler != nil && !h.sampler.allow(entry)) {
+		entry.Data[droppedField] = true
+		return nil
+	}
+	return h.hooks.Fire(entry.Level, entry)
+}
+
+// gateFormatter skips entries marked by gateHook and hands the rest to the
+// real formatter
+type gateFormatter struct {
+	next logrus.Formatter
+}
+
+// Format implements logrus.Formatter; dropped entries produce no output
+func (f *gateFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	if dropped, _ := entry.Data[droppedField].(bool); dropped {
+		return nil, nil
+	}
+	return f.next.Format(entry)
+}
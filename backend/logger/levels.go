@@ -0,0 +1,111 @@
+package logger
+
+import (
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// levelState holds the global log level and per-component overrides. The
+// logrus level is kept at the most verbose of them so entries reach gateHook,
+// which then applies the level of the entry's component before other hooks run.
+var levelState = struct {
+	mu         sync.RWMutex
+	global     logrus.Level
+	components map[string]logrus.Level
+}{
+	global:     logrus.InfoLevel,
+	components: map[string]logrus.Level{},
+}
+
+// GetLevel returns the global log level
+func GetLevel() logrus.Level {
+	levelState.mu.RLock()
+	defer levelState.mu.RUnlock()
+
+	return levelState.global
+}
+
+// ComponentLevels returns the per-component level overrides
+func ComponentLevels() map[string]logrus.Level {
+	levelState.mu.RLock()
+	defer levelState.mu.RUnlock()
+
+	levels := make(map[string]logrus.Level, len(levelState.components))
+	for component, level := range levelState.components {
+		levels[component] = level
+	}
+	return levels
+}
+
+// SetLevels replaces the global level and all component overrides at once.
+// A component is the "component" or "service" field of a log line, such as
+// "ProductService" or "auth".
+func SetLevels(global logrus.Level, components map[string]logrus.Level) {
+	levelState.mu.Lock()
+	defer levelState.mu.Unlock()
+
+	levelState.global = global
+	levelState.components = make(map[string]logrus.Level, len(components))
+	for component, level := range components {
+		levelState.components[component] = level
+	}
+	applyLevels()
+}
+
+// SetLevel changes the global log level and keeps the component overrides
+func SetLevel(level logrus.Level) {
+	levelState.mu.Lock()
+	defer levelState.mu.Unlock()
+
+	levelState.global = level
+	applyLevels()
+}
+
+// applyLevels sets the logrus level to the most verbose configured level;
+// callers must hold levelState.mu
+func applyLevels() {
+	if Logger == nil {
+		return
+	}
+	verbose := levelState.global
+	for _, level := range levelState.components {
+		if level > verbose {
+			verbose = level
+		}
+	}
+	Logger.SetLevel(verbose)
+}
+
+// levelEnabled reports whether an entry passes the level of its component
+func levelEnabled(entry *logrus.Entry) bool {
+	levelState.mu.RLock()
+	defer levelState.mu.RUnlock()
+
+	level := levelState.global
+	if component := componentOf(entry); component != "" {
+		if override, ok := levelState.components[component]; ok {
+			level = override
+		}
+	}
+	return entry.Level <= level
+}
+
+// componentOf returns the component a log line belongs to
+func componentOf(entry *logrus.Entry) string {
+	for _, key := range []string{"component", "service"} {
+		if component, ok := entry.Data[key].(string); ok && component != "" {
+			return component
+		}
+	}
+	return ""
+}
+
+// LevelNames lists the levels accepted by ParseLevel, most severe first
+func LevelNames() []string {
+	names := make([]string, 0, len(logrus.AllLevels))
+	for _, level := range logrus.AllLevels {
+		names = append(names, level.String())
+	}
+	return names
+}
@@ -0,0 +1,169 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+// captureLogs initializes the logger with JSON output written to the returned
+// buffer and restores the default levels when the test ends
+func captureLogs(t *testing.T, config LogConfig) *bytes.Buffer {
+	t.Helper()
+	config.Format = "json"
+	if err := Init(config); err != nil {
+		t.Fatalf("Init: %v", err)
+	}
+	var buf bytes.Buffer
+	Logger.SetOutput(&buf)
+	t.Cleanup(func() {
+		stopSampling()
+		SetLevels(logrus.InfoLevel, nil)
+	})
+	return &buf
+}
+
+// loggedLines decodes the JSON lines written to buf
+func loggedLines(t *testing.T, buf *bytes.Buffer) []map[string]interface{} {
+	t.Helper()
+	var lines []map[string]interface{}
+	for _, text := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if text == "" {
+			continue
+		}
+		var line map[string]interface{}
+		if err := json.Unmarshal([]byte(text), &line); err != nil {
+			t.Fatalf("decode log line %q: %v", text, err)
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// loggedMessages returns the message of each line written to buf
+func loggedMessages(t *testing.T, buf *bytes.Buffer) []string {
+	t.Helper()
+	var messages []string
+	for _, line := range loggedLines(t, buf) {
+		messages = append(messages, line["msg"].(string))
+	}
+	return messages
+}
+
+func TestComponentLevelOverrides(t *testing.T) {
+	buf := captureLogs(t, LogConfig{Level: "info"})
+	SetLevels(logrus.InfoLevel, map[string]logrus.Level{
+		"ProductService": logrus.DebugLevel,
+		"auth":           logrus.ErrorLevel,
+	})
+
+	tests := []struct {
+		name    string
+		log     func()
+		written bool
+	}{
+		{"debug from a verbose component", func() { Debug("product debug", map[string]interface{}{"component": "ProductService"}) }, true},
+		{"debug from a verbose service", func() { LogServiceCall("ProductService", "GetProducts", nil) }, true},
+		{"debug from another component", func() { Debug("cart debug", map[string]interface{}{"component": "CartService"}) }, false},
+		{"debug without a component", func() { Debug("plain debug") }, false},
+		{"info from a quiet component", func() { Info("auth info", map[string]interface{}{"component": "auth"}) }, false},
+		{"warning from a quiet component", func() { Warn("auth warning", map[string]interface{}{"component": "auth"}) }, false},
+		{"error from a quiet component", func() { Error("auth error", nil, map[string]interface{}{"component": "auth"}) }, true},
+		{"info without a component", func() { Info("plain info") }, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf.Reset()
+			tt.log()
+			if written := buf.Len() > 0; written != tt.written {
+				t.Errorf("written = %v, want %v (output %q)", written, tt.written, buf.String())
+			}
+		})
+	}
+
+	// Changing the global level keeps the overrides
+	SetLevel(logrus.WarnLevel)
+	if want := map[string]logrus.Level{"ProductService": logrus.DebugLevel, "auth": logrus.ErrorLevel}; !reflect.DeepEqual(ComponentLevels(), want) {
+		t.Errorf("ComponentLevels after SetLevel = %v, want %v", ComponentLevels(), want)
+	}
+	buf.Reset()
+	Info("plain info")
+	Debug("product debug", map[string]interface{}{"component": "ProductService"})
+	if got, want := loggedMessages(t, buf), []string{"product debug"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("after SetLevel(warn) logged %q, want %q", got, want)
+	}
+
+	// The logrus level follows the most verbose setting so overrides can lower it
+	if Logger.GetLevel() != logrus.DebugLevel {
+		t.Errorf("logrus level = %v, want debug", Logger.GetLevel())
+	}
+	SetLevels(logrus.WarnLevel, nil)
+	if Logger.GetLevel() != logrus.WarnLevel {
+		t.Errorf("logrus level without overrides = %v, want warn", Logger.GetLevel())
+	}
+}
+
+// countingHook counts the entries it sees
+type countingHook struct {
+	fired []string
+}
+
+func (h *countingHook) Levels() []logrus.Level { return logrus.AllLevels }
+
+func (h *countingHook) Fire(entry *logrus.Entry) error {
+	h.fired = append(h.fired, entry.Message)
+	return nil
+}
+
+// TestGateRunsBeforeHooks checks that entries filtered by component level never
+// reach the hooks, so redaction work is not spent on lines that are not written
+func TestGateRunsBeforeHooks(t *testing.T) {
+	buf := captureLogs(t, LogConfig{Level: "info"})
+	SetLevels(logrus.InfoLevel, map[string]logrus.Level{"ProductService": logrus.DebugLevel})
+
+	hook := &countingHook{}
+	hooks := logrus.LevelHooks{}
+	hooks.Add(hook)
+	Logger.ReplaceHooks(logrus.LevelHooks{})
+	Logger.AddHook(&gateHook{hooks: hooks})
+
+	Debug("cart debug", map[string]interface{}{"component": "CartService"})
+	Debug("product debug", map[string]interface{}{"component": "ProductService"})
+	Info("plain info")
+
+	want := []string{"product debug", "plain info"}
+	if !reflect.DeepEqual(hook.fired, want) {
+		t.Errorf("hooks fired for %q, want %q", hook.fired, want)
+	}
+	if got := loggedMessages(t, buf); !reflect.DeepEqual(got, want) {
+		t.Errorf("logged %q, want %q", got, want)
+	}
+	if strings.Contains(buf.String(), "dropped") {
+		t.Errorf("the drop marker leaked into the output: %s", buf.String())
+	}
+}
+
+// TestInitGatesEveryHook checks that Init registers nothing but the gate, which
+// holds the redaction hook
+func TestInitGatesEveryHook(t *testing.T) {
+	buf := captureLogs(t, LogConfig{Level: "info"})
+
+	for _, level := range logrus.AllLevels {
+		hooks := Logger.Hooks[level]
+		if len(hooks) != 1 {
+			t.Fatalf("%d hooks at level %v, want only the gate", len(hooks), level)
+		}
+		if _, ok := hooks[0].(*gateHook); !ok {
+			t.Fatalf("hook at level %v is %T, want *gateHook", level, hooks[0])
+		}
+	}
+
+	Info("login", map[string]interface{}{"password": "hunter2"})
+	if lines := loggedLines(t, buf); len(lines) != 1 || lines[0]["password"] != RedactedValue {
+		t.Errorf("logged %v, want the password redacted", lines)
+	}
+}
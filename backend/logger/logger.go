@@ -1,9 +1,14 @@
 package logger
 
 import (
+	"context"
+	"fmt"
 	"os"
+	"os/signal"
 	"runtime"
+	"strconv"
 	"strings"
+	"syscall"
 
 	"github.com/sirupsen/logrus"
 )
@@ -11,6 +16,13 @@ import (
 var (
 	// Logger is the global logger instance
 	Logger *logrus.Logger
+
+	// logFile is the open log file when Output is a file path
+	logFile *RotatingFile
+	// hangup receives SIGHUP while logFile is open
+	hangup chan os.Signal
+	// logSampler drops high-volume entries when sampling rules are configured
+	logSampler *sampler
 )
 
 // LogConfig holds logging configuration
@@ -18,18 +30,43 @@ type LogConfig struct {
 	Level  string `json:"level"`
 	Format string `json:"format"` // "json" or "text"
 	Output string `json:"output"` // "stdout", "stderr", or file path
+
+	// Rotation settings for file output
+	MaxSizeMB   int  `json:"maxSizeMb"`   // rotate past this size; 0 disables
+	RotateDaily bool `json:"rotateDaily"` // rotate on the first write of each day
+	MaxBackups  int  `json:"maxBackups"`  // rotated files kept; 0 keeps all
+	Compress    bool `json:"compress"`    // gzip rotated files
+
+	// Sampling limits high-volume log lines; see ParseSamplingRules for the format
+	Sampling string `json:"sampling"`
+
+	// Sensitive values are masked unless DisableRedaction is set; RedactKeys
+	// adds field names to DefaultRedactedKeys
+	DisableRedaction bool     `json:"disableRedaction"`
+	RedactKeys       []string `json:"redactKeys"`
 }
 
-// Init initializes the global logger with configuration
-func Init(config LogConfig) {
+// Init initializes the global logger with configuration. File output is
+// reopened on SIGHUP. If the log file can't be opened or the sampling rules
+// are invalid, the logger writes everything to stdout and the error is returned.
+func Init(config LogConfig) error {
+	stopSampling()
+	closeLogFile()
 	Logger = logrus.New()
+	Logger.SetOutput(os.Stdout)
+
+	rules, err := ParseSamplingRules(config.Sampling)
+	if err != nil {
+		return err
+	}
 
 	// Set log level
 	level, err := logrus.ParseLevel(config.Level)
 	if err != nil {
 		level = logrus.InfoLevel
 	}
-	Logger.SetLevel(level)
+	// Component overrides set at runtime start over on every Init
+	SetLevels(level, nil)
 
 	// Set formatter
 	if config.Format == "json" {
@@ -57,15 +94,79 @@ func Init(config LogConfig) {
 		})
 	}
 
+	// Hooks run behind the gate so entries below their component's level or
+	// dropped by sampling skip them
+	hooks := logrus.LevelHooks{}
+	if !config.DisableRedaction {
+		hooks.Add(NewRedactionHook(DefaultRedactionConfig(config.RedactKeys...)))
+	}
+
+	if len(rules) > 0 {
+		logSampler = newSampler(rules)
+		go logSampler.report(SamplingReportInterval)
+	}
+	Logger.AddHook(&gateHook{hooks: hooks, sampler: logSampler})
+	Logger.SetFormatter(&gateFormatter{next: Logger.Formatter})
+
 	// Set output
 	switch config.Output {
 	case "stderr":
 		Logger.SetOutput(os.Stderr)
-	case "stdout":
+	case "stdout", "":
 		Logger.SetOutput(os.Stdout)
 	default:
-		// For file output or default to stdout
 		Logger.SetOutput(os.Stdout)
+		file, err := OpenRotatingFile(config.Output, RotateOptions{
+			MaxSize:    int64(config.MaxSizeMB) * 1024 * 1024,
+			Daily:      config.RotateDaily,
+			MaxBackups: config.MaxBackups,
+			Compress:   config.Compress,
+		})
+		if err != nil {
+			return err
+		}
+		Logger.SetOutput(file)
+		logFile = file
+		reopenOnHangup(file)
+	}
+	return nil
+}
+
+// reopenOnHangup reopens the log file whenever the process receives SIGHUP
+func reopenOnHangup(file *RotatingFile) {
+	hangup = make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	go func(signals chan os.Signal) {
+		for range signals {
+			if err := file.Reopen(); err != nil {
+				fmt.Fprintf(os.Stderr, "reopen log file %s: %v\n", file.Path(), err)
+				continue
+			}
+			Info("Log file reopened", map[string]interface{}{
+				"path": file.Path(),
+			})
+		}
+	}(hangup)
+}
+
+// stopSampling reports the entries dropped by an earlier Init and stops its sampler
+func stopSampling() {
+	if logSampler != nil {
+		logSampler.close()
+		logSampler = nil
+	}
+}
+
+// closeLogFile stops the SIGHUP handler and closes the log file from an earlier Init
+func closeLogFile() {
+	if hangup != nil {
+		signal.Stop(hangup)
+		close(hangup)
+		hangup = nil
+	}
+	if logFile != nil {
+		logFile.Close()
+		logFile = nil
 	}
 }
 
@@ -74,8 +175,16 @@ func GetDefaultConfig() LogConfig {
 	env := strings.ToLower(os.Getenv("ENV"))
 	
 	config := LogConfig{
-		Level:  getEnvOrDefault("LOG_LEVEL", "info"),
-		Output: "stdout",
+		Level:       getEnvOrDefault("LOG_LEVEL", "info"),
+		Output:      getEnvOrDefault("LOG_OUTPUT", "stdout"),
+		MaxSizeMB:   getEnvAsInt("LOG_MAX_SIZE_MB", 100),
+		RotateDaily: getEnvAsBool("LOG_ROTATE_DAILY", true),
+		MaxBackups:  getEnvAsInt("LOG_MAX_BACKUPS", 7),
+		Compress:    getEnvAsBool("LOG_COMPRESS", false),
+		Sampling:    os.Getenv("LOG_SAMPLING"),
+
+		DisableRedaction: !getEnvAsBool("LOG_REDACT", true),
+		RedactKeys:       getEnvAsList("LOG_REDACT_KEYS"),
 	}
 
 	// Use JSON format in production, text format in development
@@ -96,24 +205,43 @@ func getEnvOrDefault(key, defaultValue string) string {
 	return defaultValue
 }
 
+// getEnvAsInt gets an integer environment variable or returns default value
+func getEnvAsInt(key string, defaultValue int) int {
+	if value, err := strconv.Atoi(os.Getenv(key)); err == nil {
+		return value
+	}
+	return defaultValue
+}
+
+// getEnvAsList gets a comma-separated environment variable as a list
+func getEnvAsList(key string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// getEnvAsBool gets a boolean environment variable or returns default value
+func getEnvAsBool(key string, defaultValue bool) bool {
+	if value, err := strconv.ParseBool(os.Getenv(key)); err == nil {
+		return value
+	}
+	return defaultValue
+}
+
 // Structured logging helper functions
 
 // Info logs an info message with optional fields
 func Info(message string, fields ...logrus.Fields) {
-	entry := Logger.Info
-	if len(fields) > 0 {
-		entry = Logger.WithFields(fields[0]).Info
-	}
-	entry(message)
+	InfoContext(context.Background(), message, fields...)
 }
 
 // Warn logs a warning message with optional fields
 func Warn(message string, fields ...logrus.Fields) {
-	entry := Logger.Warn
-	if len(fields) > 0 {
-		entry = Logger.WithFields(fields[0]).Warn
-	}
-	entry(message)
+	WarnContext(context.Background(), message, fields...)
 }
 
 // Error logs an error message with optional fields
@@ -130,47 +258,24 @@ func Error(message string, err error, fields ...logrus.Fields) {
 
 // Debug logs a debug message with optional fields
 func Debug(message string, fields ...logrus.Fields) {
-	entry := Logger.Debug
-	if len(fields) > 0 {
-		entry = Logger.WithFields(fields[0]).Debug
-	}
-	entry(message)
+	DebugContext(context.Background(), message, fields...)
 }
 
 // HTTP Request Logging Helpers
 
 // LogHTTPRequest logs HTTP request details
 func LogHTTPRequest(method, path, userAgent, clientIP string, statusCode int, duration float64) {
-	Logger.WithFields(logrus.Fields{
-		"method":      method,
-		"path":        path,
-		"status_code": statusCode,
-		"duration_ms": duration,
-		"user_agent":  userAgent,
-		"client_ip":   clientIP,
-		"type":        "http_request",
-	}).Info("HTTP Request")
+	LogHTTPRequestContext(context.Background(), method, path, userAgent, clientIP, statusCode, duration)
 }
 
 // LogServiceCall logs service layer method calls
 func LogServiceCall(service, method string, params map[string]interface{}) {
-	Logger.WithFields(logrus.Fields{
-		"service": service,
-		"method":  method,
-		"params":  params,
-		"type":    "service_call",
-	}).Debug("Service method called")
+	LogServiceCallContext(context.Background(), service, method, params)
 }
 
 // LogServiceResult logs service layer method results
 func LogServiceResult(service, method string, resultCount int, duration float64) {
-	Logger.WithFields(logrus.Fields{
-		"service":      service,
-		"method":       method,
-		"result_count": resultCount,
-		"duration_ms":  duration,
-		"type":         "service_result",
-	}).Debug("Service method completed")
+	LogServiceResultContext(context.Background(), service, method, resultCount, duration)
 }
 
 // LogDatabaseQuery logs database query information
@@ -184,20 +289,8 @@ func LogDatabaseQuery(query string, duration float64, rowsAffected int64) {
 }
 
 // LogError logs application errors with context
-func LogError(component, operation string, err error, context map[string]interface{}) {
-	fields := logrus.Fields{
-		"component": component,
-		"operation": operation,
-		"error":     err.Error(),
-		"type":      "application_error",
-	}
-	
-	// Add context fields
-	for key, value := range context {
-		fields[key] = value
-	}
-	
-	Logger.WithFields(fields).Error("Application error occurred")
+func LogError(component, operation string, err error, extra map[string]interface{}) {
+	LogErrorContext(context.Background(), component, operation, err, extra)
 }
 
 // LogStartup logs application startup information
@@ -207,4 +300,10 @@ func LogStartup(component string, config map[string]interface{}) {
 		"config":    config,
 		"type":      "startup",
 	}).Info("Component started")
+}
+
+// LogAudit logs a security relevant decision, such as an access check. Denials
+// are logged as warnings so they stand out.
+func LogAudit(action, outcome string, extra map[string]interface{}) {
+	LogAuditContext(context.Background(), action, outcome, extra)
 }
\ No newline at end of file
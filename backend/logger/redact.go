@@ -0,0 +1,222 @@
+package logger
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+// RedactedValue replaces masked values in log lines
+const RedactedValue = "[REDACTED]"
+
+// DefaultRedactedKeys are the field names whose values are always masked. Keys
+// match case-insensitively, ignoring "_" and "-", when they contain one of these.
+var DefaultRedactedKeys = []string{
+	"password", "passwd", "secret", "token", "authorization", "cookie",
+	"apikey", "cardnumber", "cvv", "cvc",
+}
+
+// RedactionPattern masks values that look sensitive wherever they appear
+type RedactionPattern struct {
+	Name    string
+	Pattern *regexp.Regexp
+	// Replace returns the masked form of a match; nil replaces it with RedactedValue
+	Replace func(match string) string
+}
+
+// DefaultRedactionPatterns mask JWTs, email addresses, card numbers and
+// key=value pairs with a sensitive key, such as "token=..." in a query string
+func DefaultRedactionPatterns() []RedactionPattern {
+	return []RedactionPattern{
+		{
+			Name:    "jwt",
+			Pattern: regexp.MustCompile(`\beyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`),
+		},
+		{
+			Name:    "email",
+			Pattern: regexp.MustCompile(`[A-Za-z0-9._%+-]+@([A-Za-z0-9-]+\.)+[A-Za-z]{2,}`),
+			Replace: maskEmail,
+		},
+		{
+			Name:    "pan",
+			Pattern: regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`),
+			Replace: maskCardNumber,
+		},
+		{
+			Name:    "secret_parameter",
+			Pattern: regexp.MustCompile(`(?i)\b([a-z_-]*(?:password|secret|token|api_?key)[a-z_-]*)=([^&\s]+)`),
+			Replace: maskParameter,
+		},
+	}
+}
+
+// RedactionConfig selects what a RedactionHook masks
+type RedactionConfig struct {
+	// Keys are field names whose values are masked entirely
+	Keys []string
+	// Patterns mask matching parts of string values and of the message
+	Patterns []RedactionPattern
+}
+
+// DefaultRedactionConfig masks the default keys and patterns plus any extra keys
+func DefaultRedactionConfig(extraKeys ...string) RedactionConfig {
+	return RedactionConfig{
+		Keys:     append(append([]string(nil), DefaultRedactedKeys...), extraKeys...),
+		Patterns: DefaultRedactionPatterns(),
+	}
+}
+
+// RedactionHook is a logrus hook that masks sensitive fields and values before
+// entries are formatted. Nested maps and slices, such as service call params
+// and error context, are copied rather than changed in place.
+type RedactionHook struct {
+	keys     []string
+	patterns []RedactionPattern
+}
+
+// NewRedactionHook creates a redaction hook
+func NewRedactionHook(config RedactionConfig) *RedactionHook {
+	hook := &RedactionHook{patterns: config.Patterns}
+	for _, key := range config.Keys {
+		if normalized := normalizeKey(key); normalized != "" {
+			hook.keys = append(hook.keys, normalized)
+		}
+	}
+	return hook
+}
+
+// Levels implements logrus.Hook; every level is redacted
+func (h *RedactionHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire implements logrus.Hook
+func (h *RedactionHook) Fire(entry *logrus.Entry) error {
+	entry.Message = h.RedactString(entry.Message)
+
+	// entry.Data is the entry's own copy, so it can be changed in place
+	for key, value := range entry.Data {
+		entry.Data[key] = h.redactField(key, value)
+	}
+	return nil
+}
+
+// RedactString masks every sensitive pattern in s
+func (h *RedactionHook) RedactString(s string) string {
+	for _, pattern := range h.patterns {
+		if pattern.Replace == nil {
+			s = pattern.Pattern.ReplaceAllString(s, RedactedValue)
+			continue
+		}
+		s = pattern.Pattern.ReplaceAllStringFunc(s, pattern.Replace)
+	}
+	return s
+}
+
+// redactField masks a field by its key or, failing that, by its value
+func (h *RedactionHook) redactField(key string, value interface{}) interface{} {
+	if h.sensitiveKey(key) && value != nil && value != "" {
+		return RedactedValue
+	}
+	return h.redactValue(value)
+}
+
+// redactValue masks sensitive parts of a value, descending into maps and slices
+func (h *RedactionHook) redactValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case string:
+		return h.RedactString(v)
+	case error:
+		return h.RedactString(v.Error())
+	case map[string]interface{}:
+		redacted := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			redacted[key] = h.redactField(key, item)
+		}
+		return redacted
+	case logrus.Fields:
+		redacted := make(logrus.Fields, len(v))
+		for key, item := range v {
+			redacted[key] = h.redactField(key, item)
+		}
+		return redacted
+	case map[string]string:
+		redacted := make(map[string]string, len(v))
+		for key, item := range v {
+			redacted[key] = fmt.Sprint(h.redactField(key, item))
+		}
+		return redacted
+	case []string:
+		redacted := make([]string, len(v))
+		for i, item := range v {
+			redacted[i] = h.RedactString(item)
+		}
+		return redacted
+	case []interface{}:
+		redacted := make([]interface{}, len(v))
+		for i, item := range v {
+			redacted[i] = h.redactValue(item)
+		}
+		return redacted
+	default:
+		return value
+	}
+}
+
+// sensitiveKey reports whether a field name is one whose value is always masked
+func (h *RedactionHook) sensitiveKey(key string) bool {
+	normalized := normalizeKey(key)
+	for _, sensitive := range h.keys {
+		if strings.Contains(normalized, sensitive) {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeKey lowercases a field name and drops separators
+func normalizeKey(key string) string {
+	return strings.NewReplacer("_", "", "-", "", " ", "").Replace(strings.ToLower(key))
+}
+
+// maskEmail keeps the domain of an email address, which is usually enough to debug with
+func maskEmail(email string) string {
+	at := strings.LastIndex(email, "@")
+	return "***" + email[at:]
+}
+
+// maskCardNumber keeps the last four digits of a number that passes the Luhn
+// check; other long numbers are left alone
+func maskCardNumber(match string) string {
+	digits := strings.NewReplacer(" ", "", "-", "").Replace(match)
+	if !luhnValid(digits) {
+		return match
+	}
+	return strings.Repeat("*", len(digits)-4) + digits[len(digits)-4:]
+}
+
+// maskParameter masks the value of a key=value pair
+func maskParameter(match string) string {
+	key, _, _ := strings.Cut(match, "=")
+	return key + "=" + RedactedValue
+}
+
+// luhnValid reports whether a string of digits passes the Luhn checksum
+func luhnValid(digits string) bool {
+	sum := 0
+	double := false
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := int(digits[i] - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
@@ -0,0 +1,271 @@
+package logger
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// backupTimeFormat stamps rotated files; it sorts chronologically as a string
+const backupTimeFormat = "20060102-150405.000"
+
+// RotateOptions controls when a RotatingFile rolls over and what it keeps
+type RotateOptions struct {
+	// MaxSize rotates the file before it grows past this many bytes; 0 disables size rotation
+	MaxSize int64
+	// Daily rotates the file on the first write of a new day (local time)
+	Daily bool
+	// MaxBackups is how many rotated files are kept; 0 keeps them all
+	MaxBackups int
+	// Compress gzips rotated files
+	Compress bool
+}
+
+// RotatingFile is an io.Writer that appends to a log file and rotates it by
+// size and by day. Rotated files are renamed to <path>.<timestamp>, optionally
+// gzipped, and pruned down to MaxBackups in the background.
+type RotatingFile struct {
+	mu      sync.Mutex
+	path    string
+	options RotateOptions
+	file    *os.File
+	size    int64
+	day     string
+
+	// millMu serializes compression and pruning of rotated files
+	millMu sync.Mutex
+}
+
+// OpenRotatingFile opens (or creates) the log file at path for appending
+func OpenRotatingFile(path string, options RotateOptions) (*RotatingFile, error) {
+	rf := &RotatingFile{path: path, options: options}
+	if err := rf.open(); err != nil {
+		return nil, err
+	}
+	return rf, nil
+}
+
+// Write appends p to the file, rotating it first if needed
+func (rf *RotatingFile) Write(p []byte) (int, error) {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+
+	if rf.file == nil {
+		return 0, os.ErrClosed
+	}
+	if rf.shouldRotate(int64(len(p)), time.Now()) {
+		if err := rf.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := rf.file.Write(p)
+	rf.size += int64(n)
+	return n, err
+}
+
+// Reopen closes and reopens the file at its path, for use after an external
+// tool such as logrotate has moved it away
+func (rf *RotatingFile) Reopen() error {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+
+	if rf.file != nil {
+		rf.file.Close()
+		rf.file = nil
+	}
+	return rf.open()
+}
+
+// Rotate rolls the file over now
+func (rf *RotatingFile) Rotate() error {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+
+	if rf.file == nil {
+		return os.ErrClosed
+	}
+	return rf.rotate()
+}
+
+// Close closes the file; later writes fail
+func (rf *RotatingFile) Close() error {
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+
+	if rf.file == nil {
+		return nil
+	}
+	err := rf.file.Close()
+	rf.file = nil
+	return err
+}
+
+// Path returns the path of the active log file
+func (rf *RotatingFile) Path() string {
+	return rf.path
+}
+
+// open opens the active file and records its size and day; callers must hold rf.mu
+func (rf *RotatingFile) open() error {
+	if dir := filepath.Dir(rf.path); dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("create log directory: %w", err)
+		}
+	}
+	file, err := os.OpenFile(rf.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("open log file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("stat log file: %w", err)
+	}
+
+	rf.file = file
+	rf.size = info.Size()
+	// A file left over from an earlier day rolls over on the first write
+	rf.day = dayOf(info.ModTime())
+	if info.Size() == 0 {
+		rf.day = dayOf(time.Now())
+	}
+	return nil
+}
+
+// shouldRotate reports whether writing n more bytes at now needs a new file
+func (rf *RotatingFile) shouldRotate(n int64, now time.Time) bool {
+	if rf.size == 0 {
+		rf.day = dayOf(now)
+		return false
+	}
+	if rf.options.Daily && dayOf(now) != rf.day {
+		return true
+	}
+	return rf.options.MaxSize > 0 && rf.size+n > rf.options.MaxSize
+}
+
+// rotate renames the active file to a timestamped backup and opens a fresh one;
+// callers must hold rf.mu
+func (rf *RotatingFile) rotate() error {
+	if err := rf.file.Close(); err != nil {
+		return fmt.Errorf("close log file: %w", err)
+	}
+	rf.file = nil
+
+	backup := rf.path + "." + time.Now().Format(backupTimeFormat)
+	if err := os.Rename(rf.path, backup); err != nil && !os.IsNotExist(err) {
+		// Keep appending to the current file rather than losing lines
+		fmt.Fprintf(os.Stderr, "log rotation: rename %s: %v\n", rf.path, err)
+		return rf.open()
+	}
+	if err := rf.open(); err != nil {
+		return err
+	}
+
+	go rf.mill()
+	return nil
+}
+
+// mill compresses and prunes rotated files. It reports problems on stderr,
+// since the logger itself may be what is failing.
+func (rf *RotatingFile) mill() {
+	rf.millMu.Lock()
+	defer rf.millMu.Unlock()
+
+	backups, err := rf.backups()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "log rotation: list backups: %v\n", err)
+		return
+	}
+
+	if rf.options.MaxBackups > 0 && len(backups) > rf.options.MaxBackups {
+		for _, name := range backups[:len(backups)-rf.options.MaxBackups] {
+			if err := os.Remove(name); err != nil && !os.IsNotExist(err) {
+				fmt.Fprintf(os.Stderr, "log rotation: remove %s: %v\n", name, err)
+			}
+		}
+		backups = backups[len(backups)-rf.options.MaxBackups:]
+	}
+
+	if rf.options.Compress {
+		for _, name := range backups {
+			if strings.HasSuffix(name, ".gz") {
+				continue
+			}
+			if err := compressFile(name); err != nil {
+				fmt.Fprintf(os.Stderr, "log rotation: compress %s: %v\n", name, err)
+			}
+		}
+	}
+}
+
+// backups lists rotated files, oldest first
+func (rf *RotatingFile) backups() ([]string, error) {
+	entries, err := os.ReadDir(filepath.Dir(rf.path))
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := filepath.Base(rf.path) + "."
+	var backups []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		stamp := strings.TrimSuffix(strings.TrimPrefix(name, prefix), ".gz")
+		if _, err := time.Parse(backupTimeFormat, stamp); err != nil {
+			continue
+		}
+		backups = append(backups, filepath.Join(filepath.Dir(rf.path), name))
+	}
+	// The fixed-width timestamps sort chronologically
+	sort.Strings(backups)
+	return backups, nil
+}
+
+// compressFile gzips name to name.gz and removes the original
+func compressFile(name string) error {
+	src, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(name+".gz", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+
+	gz := gzip.NewWriter(dst)
+	if _, err := io.Copy(gz, src); err != nil {
+		gz.Close()
+		dst.Close()
+		os.Remove(name + ".gz")
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		dst.Close()
+		os.Remove(name + ".gz")
+		return err
+	}
+	if err := dst.Close(); err != nil {
+		os.Remove(name + ".gz")
+		return err
+	}
+
+	src.Close()
+	return os.Remove(name)
+}
+
+// dayOf returns the local calendar day of t
+func dayOf(t time.Time) string {
+	return t.Local().Format("2006-01-02")
+}
@@ -0,0 +1,217 @@
+package logger
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// SamplingReportInterval is how often the number of dropped entries is logged
+const SamplingReportInterval = time.Minute
+
+// SamplingRule limits one kind of high-volume log line. A line matches when its
+// "type" field equals Type and its message equals Message; an empty Type or
+// Message matches anything. Entries at error level and above are never dropped.
+type SamplingRule struct {
+	Type    string `json:"type,omitempty"`
+	Message string `json:"message,omitempty"`
+	// Every keeps the first and then every Nth matching line; 0 or 1 keeps all
+	Every int `json:"every,omitempty"`
+	// PerSecond keeps at most this many matching lines each second; 0 is unlimited
+	PerSecond int `json:"perSecond,omitempty"`
+}
+
+// Key names the rule in dropped entry reports
+func (r SamplingRule) Key() string {
+	var parts []string
+	if r.Type != "" {
+		parts = append(parts, "type="+r.Type)
+	}
+	if r.Message != "" {
+		parts = append(parts, "message="+r.Message)
+	}
+	return strings.Join(parts, ",")
+}
+
+// matches reports whether the rule applies to an entry
+func (r SamplingRule) matches(entry *logrus.Entry) bool {
+	if r.Type != "" {
+		if entryType, _ := entry.Data["type"].(string); entryType != r.Type {
+			return false
+		}
+	}
+	return r.Message == "" || entry.Message == r.Message
+}
+
+// ParseSamplingRules parses rules written as
+// "type=service_result,every=100;message=Products retrieved successfully,per_second=10".
+// Rules are separated by semicolons and their settings by commas.
+func ParseSamplingRules(spec string) ([]SamplingRule, error) {
+	var rules []SamplingRule
+	for _, text := range strings.Split(spec, ";") {
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+
+		var rule SamplingRule
+		for _, setting := range strings.Split(text, ",") {
+			key, value, found := strings.Cut(setting, "=")
+			key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+			if !found || value == "" {
+				return nil, fmt.Errorf("sampling rule %q: expected key=value, got %q", text, setting)
+			}
+
+			var err error
+			switch key {
+			case "type":
+				rule.Type = value
+			case "message":
+				rule.Message = value
+			case "every":
+				rule.Every, err = strconv.Atoi(value)
+			case "per_second":
+				rule.PerSecond, err = strconv.Atoi(value)
+			default:
+				return nil, fmt.Errorf("sampling rule %q: unknown setting %q", text, key)
+			}
+			if err != nil {
+				return nil, fmt.Errorf("sampling rule %q: %s must be a number", text, key)
+			}
+		}
+
+		switch {
+		case rule.Type == "" && rule.Message == "":
+			return nil, fmt.Errorf("sampling rule %q: needs a type or message", text)
+		case rule.Every < 0 || rule.PerSecond < 0:
+			return nil, fmt.Errorf("sampling rule %q: limits must not be negative", text)
+		case rule.Every <= 1 && rule.PerSecond == 0:
+			return nil, fmt.Errorf("sampling rule %q: needs every or per_second", text)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// sampleState counts the lines seen for one rule
+type sampleState struct {
+	seen     uint64
+	second   int64
+	inSecond int
+	dropped  uint64
+}
+
+// sampler applies sampling rules and counts what it drops
+type sampler struct {
+	mu     sync.Mutex
+	rules  []SamplingRule
+	states []sampleState
+	stop   chan struct{}
+	done   chan struct{}
+}
+
+// newSampler creates a sampler for the rules
+func newSampler(rules []SamplingRule) *sampler {
+	return &sampler{
+		rules:  rules,
+		states: make([]sampleState, len(rules)),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+}
+
+// allow reports whether an entry should be written; the first matching rule decides
+func (s *sampler) allow(entry *logrus.Entry) bool {
+	if entry.Level <= logrus.ErrorLevel {
+		return true
+	}
+
+	for i, rule := range s.rules {
+		if !rule.matches(entry) {
+			continue
+		}
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		state := &s.states[i]
+		keep := rule.Every <= 1 || state.seen%uint64(rule.Every) == 0
+		state.seen++
+		if keep && rule.PerSecond > 0 {
+			second := entry.Time.Unix()
+			if second != state.second {
+				state.second, state.inSecond = second, 0
+			}
+			if state.inSecond >= rule.PerSecond {
+				keep = false
+			} else {
+				state.inSecond++
+			}
+		}
+		if !keep {
+			state.dropped++
+		}
+		return keep
+	}
+	return true
+}
+
+// takeDropped returns the entries dropped per rule since the last call
+func (s *sampler) takeDropped() map[string]uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	dropped := map[string]uint64{}
+	for i := range s.states {
+		if s.states[i].dropped > 0 {
+			dropped[s.rules[i].Key()] += s.states[i].dropped
+			s.states[i].dropped = 0
+		}
+	}
+	return dropped
+}
+
+// report logs the dropped entry counts every interval until stop is closed
+func (s *sampler) report(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	defer close(s.done)
+
+	for {
+		select {
+		case <-ticker.C:
+			s.logDropped(interval)
+		case <-s.stop:
+			s.logDropped(interval)
+			return
+		}
+	}
+}
+
+// close reports what is left and stops the report loop
+func (s *sampler) close() {
+	close(s.stop)
+	<-s.done
+}
+
+// logDropped writes one summary line if anything was dropped
+func (s *sampler) logDropped(interval time.Duration) {
+	dropped := s.takeDropped()
+	if len(dropped) == 0 {
+		return
+	}
+
+	var total uint64
+	for _, count := range dropped {
+		total += count
+	}
+	Logger.WithFields(logrus.Fields{
+		"dropped":       dropped,
+		"dropped_total": total,
+		"interval":      interval.String(),
+		"type":          "log_sampling",
+	}).Warn("Log entries dropped by sampling")
+}
@@ -0,0 +1,215 @@
+package logger
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestParseSamplingRules(t *testing.T) {
+	tests := []struct {
+		name string
+		spec string
+		want []SamplingRule
+		err  string
+	}{
+		{name: "empty", spec: "", want: nil},
+		{
+			name: "several rules",
+			spec: " type=service_result, every=100 ;message=Products retrieved successfully,per_second=10;",
+			want: []SamplingRule{
+				{Type: "service_result", Every: 100},
+				{Message: "Products retrieved successfully", PerSecond: 10},
+			},
+		},
+		{
+			name: "every and per_second together",
+			spec: "type=http_request,message=HTTP request,every=2,per_second=5",
+			want: []SamplingRule{{Type: "http_request", Message: "HTTP request", Every: 2, PerSecond: 5}},
+		},
+		{name: "missing value", spec: "type=service_result,every", err: `expected key=value, got "every"`},
+		{name: "empty value", spec: "type=,every=2", err: `expected key=value, got "type="`},
+		{name: "unknown setting", spec: "type=service_result,rate=2", err: `unknown setting "rate"`},
+		{name: "every is not a number", spec: "type=service_result,every=ten", err: "every must be a number"},
+		{name: "per_second is not a number", spec: "type=service_result,per_second=1.5", err: "per_second must be a number"},
+		{name: "no type or message", spec: "every=2", err: "needs a type or message"},
+		{name: "negative limit", spec: "type=service_result,every=-2", err: "must not be negative"},
+		{name: "no limit", spec: "type=service_result,every=1", err: "needs every or per_second"},
+		{name: "bad rule after a good one", spec: "type=a,every=2;type=b", err: `sampling rule "type=b"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules, err := ParseSamplingRules(tt.spec)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("ParseSamplingRules(%q) error = %v, want one containing %q", tt.spec, err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseSamplingRules(%q): %v", tt.spec, err)
+			}
+			if !reflect.DeepEqual(rules, tt.want) {
+				t.Errorf("ParseSamplingRules(%q) = %+v, want %+v", tt.spec, rules, tt.want)
+			}
+		})
+	}
+}
+
+// sampleEntry builds an entry as logrus would hand it to the gate
+func sampleEntry(level logrus.Level, message, entryType string, at time.Time) *logrus.Entry {
+	entry := logrus.NewEntry(logrus.New()).WithField("type", entryType)
+	entry.Level = level
+	entry.Message = message
+	entry.Time = at
+	return entry
+}
+
+// kept returns which of count matching entries the sampler allows
+func kept(s *sampler, count int, at func(i int) time.Time) []int {
+	var allowed []int
+	for i := 0; i < count; i++ {
+		if s.allow(sampleEntry(logrus.InfoLevel, "Products retrieved successfully", "service_result", at(i))) {
+			allowed = append(allowed, i)
+		}
+	}
+	return allowed
+}
+
+func TestSamplerRates(t *testing.T) {
+	base := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	sameSecond := func(i int) time.Time { return base.Add(time.Duration(i) * time.Millisecond) }
+
+	tests := []struct {
+		name    string
+		rule    SamplingRule
+		count   int
+		at      func(i int) time.Time
+		want    []int
+		dropped uint64
+	}{
+		{
+			name:    "every keeps the first and then every nth",
+			rule:    SamplingRule{Type: "service_result", Every: 3},
+			count:   10,
+			at:      sameSecond,
+			want:    []int{0, 3, 6, 9},
+			dropped: 6,
+		},
+		{
+			name:    "per_second caps one second",
+			rule:    SamplingRule{Type: "service_result", PerSecond: 2},
+			count:   5,
+			at:      sameSecond,
+			want:    []int{0, 1},
+			dropped: 3,
+		},
+		{
+			name:  "per_second starts over each second",
+			rule:  SamplingRule{Type: "service_result", PerSecond: 2},
+			count: 9,
+			// Three entries in each of three seconds
+			at: func(i int) time.Time {
+				return base.Add(time.Duration(i/3)*time.Second + time.Duration(i%3)*time.Millisecond)
+			},
+			want:    []int{0, 1, 3, 4, 6, 7},
+			dropped: 3,
+		},
+		{
+			name:    "per_second counts only lines every keeps",
+			rule:    SamplingRule{Message: "Products retrieved successfully", Every: 2, PerSecond: 2},
+			count:   8,
+			at:      sameSecond,
+			want:    []int{0, 2},
+			dropped: 6,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newSampler([]SamplingRule{tt.rule})
+			if got := kept(s, tt.count, tt.at); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("kept %v, want %v", got, tt.want)
+			}
+			want := map[string]uint64{tt.rule.Key(): tt.dropped}
+			if got := s.takeDropped(); !reflect.DeepEqual(got, want) {
+				t.Errorf("dropped = %v, want %v", got, want)
+			}
+			if got := s.takeDropped(); len(got) != 0 {
+				t.Errorf("dropped after a report = %v, want the counts reset", got)
+			}
+		})
+	}
+}
+
+func TestSamplerMatching(t *testing.T) {
+	at := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	s := newSampler([]SamplingRule{
+		{Type: "service_result", PerSecond: 1},
+		{Message: "Products retrieved successfully", Every: 1000},
+	})
+
+	// The first matching rule decides, so the second never sees service results
+	for i := 0; i < 3; i++ {
+		s.allow(sampleEntry(logrus.InfoLevel, "Products retrieved successfully", "service_result", at))
+	}
+	if got, want := s.takeDropped(), map[string]uint64{"type=service_result": 2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("dropped = %v, want %v", got, want)
+	}
+
+	if !s.allow(sampleEntry(logrus.InfoLevel, "Cart updated", "service_result_other", at)) {
+		t.Error("an entry no rule matches was dropped")
+	}
+	if !s.allow(sampleEntry(logrus.ErrorLevel, "Products retrieved successfully", "service_result", at)) {
+		t.Error("an error entry was dropped")
+	}
+}
+
+// TestSamplingRunsBeforeHooks checks that sampled-out entries never reach the
+// hooks and are not written
+func TestSamplingRunsBeforeHooks(t *testing.T) {
+	buf := captureLogs(t, LogConfig{Level: "debug", Sampling: "type=sampled,every=2"})
+	if gate, ok := Logger.Hooks[logrus.InfoLevel][0].(*gateHook); !ok || gate.sampler == nil || gate.sampler != logSampler {
+		t.Fatalf("Init did not hand the sampler to the gate")
+	}
+
+	// Swap in a gate around a hook that records what it sees
+	hook := &countingHook{}
+	hooks := logrus.LevelHooks{}
+	hooks.Add(hook)
+	Logger.ReplaceHooks(logrus.LevelHooks{})
+	Logger.AddHook(&gateHook{hooks: hooks, sampler: logSampler})
+
+	for i := 0; i < 5; i++ {
+		Info("sampled line", map[string]interface{}{"type": "sampled", "n": i})
+	}
+	Info("other line")
+
+	if len(hook.fired) != 4 {
+		t.Errorf("hooks fired for %d entries, want 4 (3 sampled in, 1 other)", len(hook.fired))
+	}
+	var written []interface{}
+	for _, line := range loggedLines(t, buf) {
+		written = append(written, line["n"])
+	}
+	if want := []interface{}{0.0, 2.0, 4.0, nil}; !reflect.DeepEqual(written, want) {
+		t.Errorf("written lines n = %v, want %v", written, want)
+	}
+
+	// Stopping the sampler reports what it dropped
+	buf.Reset()
+	stopSampling()
+	lines := loggedLines(t, buf)
+	if len(lines) != 1 || lines[0]["type"] != "log_sampling" || lines[0]["dropped_total"] != 2.0 {
+		t.Errorf("sampling report = %v, want one log_sampling line with 2 dropped", lines)
+	}
+}
+
+func TestInitRejectsInvalidSampling(t *testing.T) {
+	captureLogs(t, LogConfig{Level: "info"})
+	if err := Init(LogConfig{Level: "info", Format: "json", Sampling: "every=2"}); err == nil {
+		t.Error("Init accepted a sampling rule without a type or message")
+	}
+}
@@ -7,6 +7,7 @@ import (
 
 	"ecommerce-backend/config"
 	"ecommerce-backend/logger"
+	"ecommerce-backend/middleware"
 	"ecommerce-backend/routes"
 	"github.com/rs/cors"
 )
@@ -14,7 +15,9 @@ import (
 func main() {
 	// Initialize structured logging
 	logConfig := logger.GetDefaultConfig()
-	logger.Init(logConfig)
+	if err := logger.Init(logConfig); err != nil {
+		log.Fatalf("Failed to initialize logging: %v", err)
+	}
 
 	logger.LogStartup("main", map[string]interface{}{
 		"log_level":  logConfig.Level,
@@ -28,18 +31,29 @@ func main() {
 	logger.LogStartup("config", map[string]interface{}{
 		"server_port":      cfg.Server.Port,
 		"server_host":      cfg.Server.Host,
+		"idempotency_ttl":  cfg.Server.IdempotencyTTL.String(),
 		"cors_origins":     cfg.CORS.AllowedOrigins,
 		"cors_methods":     cfg.CORS.AllowedMethods,
+		"product_store":    cfg.Storage.Driver,
+		"payment_gateway":  cfg.Payment.Gateway,
 	})
 
 	// Setup routes
-	router := routes.SetupRoutes()
+	router, err := routes.SetupRoutes(cfg)
+	if err != nil {
+		logger.LogError("main", "setup_routes", err, map[string]interface{}{
+			"product_store": cfg.Storage.Driver,
+		})
+		log.Fatal(err)
+	}
 
 	// Configure CORS
 	corsHandler := cors.New(cors.Options{
 		AllowedOrigins: cfg.CORS.AllowedOrigins,
 		AllowedMethods: cfg.CORS.AllowedMethods,
 		AllowedHeaders: cfg.CORS.AllowedHeaders,
+		// Let browser clients read the request ID to quote in bug reports
+		ExposedHeaders: []string{middleware.RequestIDHeader},
 	})
 
 	logger.Info("CORS configured", map[string]interface{}{
@@ -60,7 +74,27 @@ func main() {
 		"endpoints": map[string]string{
 			"products":           "GET /api/products",
 			"product_by_id":      "GET /api/products/{id}",
+			"create_product":     "POST /api/admin/products",
+			"update_product":     "PUT /api/admin/products/{id}",
+			"patch_product":      "PATCH /api/admin/products/{id}",
+			"delete_product":     "DELETE /api/admin/products/{id}",
+			"adjust_stock":       "POST /api/admin/products/{id}/inventory",
+			"create_cart":        "POST /api/carts",
+			"cart_items":         "GET|PUT|DELETE /api/carts/{id}/items",
+			"merge_cart":         "POST /api/carts/merge",
+			"place_order":        "POST /api/orders",
+			"order_by_id":        "GET /api/orders/{id}",
+			"order_transition":   "POST /api/admin/orders/{id}/transitions",
+			"log_level":          "GET|PUT /api/admin/log-level",
+			"order_events":       "GET /api/orders/{id}/events",
+			"payment_webhook":    "POST /api/payments/webhook",
+			"register":           "POST /api/users",
+			"verify_email":       "POST /api/users/verify",
+			"current_user":       "GET /api/users/me",
+			"login":              "POST /api/auth/login",
+			"refresh":            "POST /api/auth/refresh",
 			"search_products":    "GET /api/products/search?q={query}",
+			"suggest_products":   "GET /api/products/suggest?prefix={prefix}",
 			"price_range":        "GET /api/products/price-range?min={min}&max={max}",
 			"categories":         "GET /api/categories",
 			"genders":            "GET /api/genders",
@@ -73,7 +107,27 @@ func main() {
 	fmt.Printf("📋 Available endpoints:\n")
 	fmt.Printf("   GET  /api/products\n")
 	fmt.Printf("   GET  /api/products/{id}\n")
+	fmt.Printf("   POST /api/admin/products\n")
+	fmt.Printf("   PUT  /api/admin/products/{id}\n")
+	fmt.Printf("   PATCH /api/admin/products/{id}\n")
+	fmt.Printf("   DELETE /api/admin/products/{id}\n")
+	fmt.Printf("   POST /api/admin/products/{id}/inventory\n")
+	fmt.Printf("   POST /api/carts\n")
+	fmt.Printf("   GET|PUT|DELETE /api/carts/{id}/items\n")
+	fmt.Printf("   POST /api/carts/merge\n")
+	fmt.Printf("   POST /api/orders\n")
+	fmt.Printf("   GET  /api/orders/{id}\n")
+	fmt.Printf("   POST /api/admin/orders/{id}/transitions\n")
+	fmt.Printf("   GET|PUT /api/admin/log-level\n")
+	fmt.Printf("   GET  /api/orders/{id}/events\n")
+	fmt.Printf("   POST /api/payments/webhook\n")
+	fmt.Printf("   POST /api/users\n")
+	fmt.Printf("   POST /api/users/verify\n")
+	fmt.Printf("   GET  /api/users/me\n")
+	fmt.Printf("   POST /api/auth/login\n")
+	fmt.Printf("   POST /api/auth/refresh\n")
 	fmt.Printf("   GET  /api/products/search?q={query}\n")
+	fmt.Printf("   GET  /api/products/suggest?prefix={prefix}\n")
 	fmt.Printf("   GET  /api/products/price-range?min={min}&max={max}\n")
 	fmt.Printf("   GET  /api/categories\n")
 	fmt.Printf("   GET  /api/genders\n")
@@ -0,0 +1,287 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"ecommerce-backend/auth"
+	"ecommerce-backend/logger"
+)
+
+// IdempotencyKeyHeader is the request header clients set to make a request safe to retry
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// IdempotentReplayHeader is set on responses replayed from an earlier request
+const IdempotentReplayHeader = "Idempotent-Replayed"
+
+// maxIdempotencyKeyLength caps the length of an idempotency key
+const maxIdempotencyKeyLength = 255
+
+// maxFingerprintBodyBytes caps how much of a request body is hashed to detect key reuse
+const maxFingerprintBodyBytes = 1 << 20
+
+// idempotencySweepInterval is how often expired keys are dropped
+const idempotencySweepInterval = time.Minute
+
+// orderTokenHeader carries the access token of a guest order, as read by the order handlers
+const orderTokenHeader = "X-Order-Token"
+
+// idempotencyEntry is the state of one idempotency key
+type idempotencyEntry struct {
+	fingerprint string
+	done        bool
+	status      int
+	header      http.Header
+	body        []byte
+	expires     time.Time
+}
+
+// idempotencyStore holds the keys seen by IdempotencyMiddleware
+type idempotencyStore struct {
+	mu        sync.Mutex
+	entries   map[string]*idempotencyEntry
+	ttl       time.Duration
+	lastSweep time.Time
+}
+
+// recordingWriter passes a response through while keeping a copy of it
+type recordingWriter struct {
+	http.ResponseWriter
+	status int
+	header http.Header
+	body   bytes.Buffer
+}
+
+func (rw *recordingWriter) WriteHeader(code int) {
+	if rw.status == 0 {
+		rw.status = code
+		rw.header = rw.ResponseWriter.Header().Clone()
+	}
+	rw.ResponseWriter.WriteHeader(code)
+}
+
+func (rw *recordingWriter) Write(data []byte) (int, error) {
+	if rw.status == 0 {
+		rw.WriteHeader(http.StatusOK)
+	}
+	rw.body.Write(data)
+	return rw.ResponseWriter.Write(data)
+}
+
+// IdempotencyMiddleware makes POST, PUT, PATCH and DELETE requests that carry an
+// Idempotency-Key header safe to retry. The first response for a key is stored
+// and replayed byte-for-byte for retries until ttl has passed since it was sent.
+// A retry that arrives while the first request is still running gets 409, and
+// reusing a key for a different request gets 422. Server errors are not stored,
+// so the request runs again when it is retried. Keys are scoped to the caller:
+// a key sent by one signed-in user never replays another user's response, and
+// guests are told apart by the order token or cart ID they send.
+func IdempotencyMiddleware(ttl time.Duration) func(http.Handler) http.Handler {
+	store := &idempotencyStore{
+		entries:   make(map[string]*idempotencyEntry),
+		ttl:       ttl,
+		lastSweep: time.Now(),
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(IdempotencyKeyHeader)
+			if key == "" || !isMutatingMethod(r.Method) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if len(key) > maxIdempotencyKeyLength {
+				http.Error(w, "Idempotency-Key is too long", http.StatusBadRequest)
+				return
+			}
+
+			body, err := peekBody(r)
+			if err != nil {
+				http.Error(w, "Failed to read request body", http.StatusBadRequest)
+				return
+			}
+			caller := idempotencyCaller(r, body)
+			fingerprint := fingerprintRequest(r, caller, body)
+
+			scoped := caller + " " + r.Method + " " + r.URL.Path + " " + key
+			entry, existing := store.begin(scoped, fingerprint)
+			if existing {
+				store.respondExisting(w, r, key, entry, fingerprint)
+				return
+			}
+
+			recorder := &recordingWriter{ResponseWriter: w}
+			completed := false
+			defer func() {
+				// A panicking handler must not leave the key in flight forever
+				if !completed {
+					store.forget(scoped)
+				}
+			}()
+
+			next.ServeHTTP(recorder, r)
+
+			if recorder.status == 0 {
+				recorder.status = http.StatusOK
+				recorder.header = w.Header().Clone()
+			}
+			if recorder.status >= http.StatusInternalServerError {
+				store.forget(scoped)
+			} else {
+				store.complete(scoped, recorder)
+			}
+			completed = true
+		})
+	}
+}
+
+// begin looks up a key, reserving it for this request if it is new
+func (s *idempotencyStore) begin(key, fingerprint string) (idempotencyEntry, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(s.lastSweep) >= idempotencySweepInterval {
+		s.sweep(now)
+	}
+
+	if entry, exists := s.entries[key]; exists && (!entry.done || now.Before(entry.expires)) {
+		return *entry, true
+	}
+	s.entries[key] = &idempotencyEntry{fingerprint: fingerprint}
+	return idempotencyEntry{}, false
+}
+
+// complete stores the response for a key
+func (s *idempotencyStore) complete(key string, recorder *recordingWriter) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, exists := s.entries[key]
+	if !exists {
+		return
+	}
+	entry.done = true
+	entry.status = recorder.status
+	entry.header = recorder.header
+	entry.body = recorder.body.Bytes()
+	entry.expires = time.Now().Add(s.ttl)
+}
+
+// forget drops a key so the next request with it runs again
+func (s *idempotencyStore) forget(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.entries, key)
+}
+
+// sweep drops expired keys. Callers hold s.mu.
+func (s *idempotencyStore) sweep(now time.Time) {
+	for key, entry := range s.entries {
+		if entry.done && !now.Before(entry.expires) {
+			delete(s.entries, key)
+		}
+	}
+	s.lastSweep = now
+}
+
+// respondExisting answers a request whose key has been seen before
+func (s *idempotencyStore) respondExisting(w http.ResponseWriter, r *http.Request, key string, entry idempotencyEntry, fingerprint string) {
+	fields := map[string]interface{}{
+		"idempotency_key": key,
+		"method":          r.Method,
+		"path":            r.URL.Path,
+	}
+
+	switch {
+	case entry.fingerprint != fingerprint:
+		logger.WarnContext(r.Context(), "Idempotency key reused for a different request", fields)
+		http.Error(w, "Idempotency-Key was already used for a different request", http.StatusUnprocessableEntity)
+	case !entry.done:
+		logger.WarnContext(r.Context(), "Duplicate request while the original is in flight", fields)
+		http.Error(w, "A request with this Idempotency-Key is already in progress", http.StatusConflict)
+	default:
+		logger.InfoContext(r.Context(), "Replaying idempotent response", fields)
+		for name, values := range entry.header {
+			// The replay keeps this request's own ID
+			if name == http.CanonicalHeaderKey(RequestIDHeader) {
+				continue
+			}
+			w.Header()[name] = values
+		}
+		w.Header().Set(IdempotentReplayHeader, "true")
+		w.WriteHeader(entry.status)
+		w.Write(entry.body)
+	}
+}
+
+// idempotencyCaller identifies who sent a request: the token subject when the
+// caller is signed in, otherwise a hash of the Authorization header, if any.
+// Guests are identified by the secret they prove ownership with: the access
+// token of a guest order or the ID of the cart they order from. Cart routes
+// carry the cart ID in the path, which is part of the key anyway.
+func idempotencyCaller(r *http.Request, body []byte) string {
+	if subject := auth.SubjectFromContext(r.Context()); subject != "" {
+		return "sub:" + subject
+	}
+	if authorization := r.Header.Get("Authorization"); authorization != "" {
+		return "authz:" + hashString(authorization)
+	}
+	if token := r.Header.Get(orderTokenHeader); token != "" {
+		return "order:" + hashString(token)
+	}
+	var order struct {
+		CartID string `json:"cartId"`
+	}
+	if json.Unmarshal(body, &order) == nil && order.CartID != "" {
+		return "cart:" + hashString(order.CartID)
+	}
+	return "anonymous"
+}
+
+// hashString returns the hex SHA-256 of value, so secrets aren't kept as map keys
+func hashString(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
+
+// peekBody reads up to maxFingerprintBodyBytes of a request body, leaving the
+// whole body readable for the handler
+func peekBody(r *http.Request) ([]byte, error) {
+	if r.Body == nil {
+		return nil, nil
+	}
+	prefix, err := io.ReadAll(io.LimitReader(r.Body, maxFingerprintBodyBytes))
+	if err != nil {
+		return nil, err
+	}
+	r.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(prefix), r.Body), r.Body}
+	return prefix, nil
+}
+
+// fingerprintRequest hashes the caller, method, path, query and body of a request
+func fingerprintRequest(r *http.Request, caller string, body []byte) string {
+	hash := sha256.New()
+	io.WriteString(hash, caller+" "+r.Method+" "+r.URL.Path+"?"+r.URL.RawQuery+"\n")
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// isMutatingMethod reports whether requests with method change server state
+func isMutatingMethod(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,252 @@
+package middleware
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"ecommerce-backend/auth"
+	"ecommerce-backend/logger"
+)
+
+func TestMain(m *testing.M) {
+	if err := logger.Init(logger.LogConfig{Level: "error", Output: "stdout"}); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	logger.Logger.SetOutput(io.Discard)
+	os.Exit(m.Run())
+}
+
+// countingHandler answers 201 with the number of requests it has served
+type countingHandler struct {
+	mu    sync.Mutex
+	calls int
+}
+
+func (h *countingHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mu.Lock()
+	h.calls++
+	calls := h.calls
+	h.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	fmt.Fprintf(w, `{"call":%d}`, calls)
+}
+
+func (h *countingHandler) count() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.calls
+}
+
+// idempotentRequest builds a POST with an idempotency key
+func idempotentRequest(target, key, body string) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, target, strings.NewReader(body))
+	if key != "" {
+		r.Header.Set(IdempotencyKeyHeader, key)
+	}
+	return r
+}
+
+// send runs a request through handler
+func send(handler http.Handler, r *http.Request) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
+
+func TestIdempotencyReplaysFirstResponse(t *testing.T) {
+	next := &countingHandler{}
+	handler := IdempotencyMiddleware(time.Hour)(next)
+
+	first := send(handler, idempotentRequest("/api/orders", "key-1", `{"cartId":"c1"}`))
+	if first.Code != http.StatusCreated || first.Header().Get(IdempotentReplayHeader) != "" {
+		t.Fatalf("first response = %d replayed=%q, want 201 not replayed", first.Code, first.Header().Get(IdempotentReplayHeader))
+	}
+
+	retry := send(handler, idempotentRequest("/api/orders", "key-1", `{"cartId":"c1"}`))
+	if retry.Code != http.StatusCreated || retry.Body.String() != first.Body.String() {
+		t.Errorf("retry = %d %q, want %d %q", retry.Code, retry.Body.String(), first.Code, first.Body.String())
+	}
+	if retry.Header().Get(IdempotentReplayHeader) != "true" {
+		t.Errorf("retry %s = %q, want true", IdempotentReplayHeader, retry.Header().Get(IdempotentReplayHeader))
+	}
+	if retry.Header().Get("Content-Type") != "application/json" {
+		t.Errorf("retry Content-Type = %q, want the stored header", retry.Header().Get("Content-Type"))
+	}
+	if next.count() != 1 {
+		t.Errorf("handler ran %d times, want 1", next.count())
+	}
+
+	// Another key, and requests without a key or with a safe method, always run
+	send(handler, idempotentRequest("/api/orders", "key-2", `{"cartId":"c1"}`))
+	send(handler, idempotentRequest("/api/orders", "", `{"cartId":"c1"}`))
+	get := httptest.NewRequest(http.MethodGet, "/api/orders/1", nil)
+	get.Header.Set(IdempotencyKeyHeader, "key-1")
+	send(handler, get)
+	if next.count() != 4 {
+		t.Errorf("handler ran %d times, want 4", next.count())
+	}
+}
+
+func TestIdempotencyRejectsDuplicateInFlight(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	handler := IdempotencyMiddleware(time.Hour)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	done := make(chan int)
+	go func() {
+		done <- send(handler, idempotentRequest("/api/orders", "key-1", "{}")).Code
+	}()
+	<-started
+
+	if w := send(handler, idempotentRequest("/api/orders", "key-1", "{}")); w.Code != http.StatusConflict {
+		t.Errorf("duplicate in flight = %d, want 409", w.Code)
+	}
+	close(release)
+	if code := <-done; code != http.StatusCreated {
+		t.Errorf("original = %d, want 201", code)
+	}
+	if w := send(handler, idempotentRequest("/api/orders", "key-1", "{}")); w.Code != http.StatusCreated || w.Header().Get(IdempotentReplayHeader) != "true" {
+		t.Errorf("retry after completion = %d replayed=%q, want a 201 replay", w.Code, w.Header().Get(IdempotentReplayHeader))
+	}
+}
+
+func TestIdempotencyRejectsKeyReuse(t *testing.T) {
+	next := &countingHandler{}
+	handler := IdempotencyMiddleware(time.Hour)(next)
+	send(handler, idempotentRequest("/api/products?notify=true", "key-1", `{"name":"a"}`))
+
+	tests := []struct {
+		name   string
+		target string
+		body   string
+	}{
+		{"different body", "/api/products?notify=true", `{"name":"b"}`},
+		{"different query", "/api/products?notify=false", `{"name":"a"}`},
+		{"no query", "/api/products", `{"name":"a"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := send(handler, idempotentRequest(tt.target, "key-1", tt.body)); w.Code != http.StatusUnprocessableEntity {
+				t.Errorf("status = %d, want 422", w.Code)
+			}
+		})
+	}
+	if next.count() != 1 {
+		t.Errorf("handler ran %d times, want 1", next.count())
+	}
+}
+
+func TestIdempotencyDoesNotStoreServerErrors(t *testing.T) {
+	calls := 0
+	handler := IdempotencyMiddleware(time.Hour)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			http.Error(w, "boom", http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	if w := send(handler, idempotentRequest("/api/orders", "key-1", "{}")); w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("first = %d, want 503", w.Code)
+	}
+	if w := send(handler, idempotentRequest("/api/orders", "key-1", "{}")); w.Code != http.StatusCreated || w.Header().Get(IdempotentReplayHeader) != "" {
+		t.Errorf("retry after a server error = %d replayed=%q, want a fresh 201", w.Code, w.Header().Get(IdempotentReplayHeader))
+	}
+	if calls != 2 {
+		t.Errorf("handler ran %d times, want 2", calls)
+	}
+}
+
+func TestIdempotencyKeysExpire(t *testing.T) {
+	next := &countingHandler{}
+	handler := IdempotencyMiddleware(20 * time.Millisecond)(next)
+
+	send(handler, idempotentRequest("/api/orders", "key-1", "{}"))
+	if w := send(handler, idempotentRequest("/api/orders", "key-1", "{}")); w.Header().Get(IdempotentReplayHeader) != "true" {
+		t.Fatal("retry within the TTL was not replayed")
+	}
+	time.Sleep(40 * time.Millisecond)
+	if w := send(handler, idempotentRequest("/api/orders", "key-1", "{}")); w.Header().Get(IdempotentReplayHeader) != "" {
+		t.Error("retry after the TTL was replayed")
+	}
+	if next.count() != 2 {
+		t.Errorf("handler ran %d times, want 2", next.count())
+	}
+}
+
+func TestIdempotencyRejectsLongKeys(t *testing.T) {
+	next := &countingHandler{}
+	handler := IdempotencyMiddleware(time.Hour)(next)
+	if w := send(handler, idempotentRequest("/api/orders", strings.Repeat("k", maxIdempotencyKeyLength+1), "{}")); w.Code != http.StatusBadRequest {
+		t.Errorf("status = %d, want 400", w.Code)
+	}
+	if next.count() != 0 {
+		t.Errorf("handler ran %d times, want 0", next.count())
+	}
+}
+
+// TestIdempotencyKeysAreScopedToTheCaller checks that the same key and body
+// from different callers never replay each other's responses
+func TestIdempotencyKeysAreScopedToTheCaller(t *testing.T) {
+	signedIn := func(subject string) func(*http.Request) *http.Request {
+		return func(r *http.Request) *http.Request {
+			return r.WithContext(auth.WithClaims(r.Context(), &auth.Claims{Subject: subject}))
+		}
+	}
+	anonymous := func(r *http.Request) *http.Request { return r }
+	header := func(name, value string) func(*http.Request) *http.Request {
+		return func(r *http.Request) *http.Request {
+			r.Header.Set(name, value)
+			return r
+		}
+	}
+
+	tests := []struct {
+		name         string
+		body         string
+		first, other func(*http.Request) *http.Request
+	}{
+		{"signed-in users", `{"cartId":"c1"}`, signedIn("alice"), signedIn("bob")},
+		{"authorization headers", "{}", header("Authorization", "Bearer a"), header("Authorization", "Bearer b")},
+		{"guest order tokens", "{}", header(orderTokenHeader, "token-a"), header(orderTokenHeader, "token-b")},
+		{"guest and user", `{"cartId":"c1"}`, anonymous, signedIn("alice")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next := &countingHandler{}
+			handler := IdempotencyMiddleware(time.Hour)(next)
+
+			send(handler, tt.first(idempotentRequest("/api/orders", "key-1", tt.body)))
+			w := send(handler, tt.other(idempotentRequest("/api/orders", "key-1", tt.body)))
+			if w.Code != http.StatusCreated || w.Header().Get(IdempotentReplayHeader) != "" {
+				t.Errorf("other caller = %d replayed=%q, want a fresh 201", w.Code, w.Header().Get(IdempotentReplayHeader))
+			}
+			if w := send(handler, tt.first(idempotentRequest("/api/orders", "key-1", tt.body))); w.Header().Get(IdempotentReplayHeader) != "true" {
+				t.Error("retry by the first caller was not replayed")
+			}
+		})
+	}
+
+	// Guests ordering different carts don't share keys either
+	next := &countingHandler{}
+	handler := IdempotencyMiddleware(time.Hour)(next)
+	send(handler, idempotentRequest("/api/orders", "key-1", `{"cartId":"c1"}`))
+	if w := send(handler, idempotentRequest("/api/orders", "key-1", `{"cartId":"c2"}`)); w.Code != http.StatusCreated {
+		t.Errorf("guest with another cart = %d, want 201", w.Code)
+	}
+}
@@ -53,7 +53,8 @@ func LoggingMiddleware(next http.Handler) http.Handler {
 		duration := float64(time.Since(start).Nanoseconds()) / 1e6 // Convert to milliseconds
 
 		// Log the request
-		logger.LogHTTPRequest(
+		logger.LogHTTPRequestContext(
+			r.Context(),
 			r.Method,
 			r.URL.Path,
 			r.UserAgent(),
@@ -64,7 +65,7 @@ func LoggingMiddleware(next http.Handler) http.Handler {
 
 		// Log query parameters if present (for debugging)
 		if len(r.URL.RawQuery) > 0 {
-			logger.Debug("HTTP Request Query Parameters", map[string]interface{}{
+			logger.DebugContext(r.Context(), "HTTP Request Query Parameters", map[string]interface{}{
 				"method":      r.Method,
 				"path":        r.URL.Path,
 				"query":       r.URL.RawQuery,
@@ -111,7 +112,7 @@ func RecoveryMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		defer func() {
 			if err := recover(); err != nil {
-				logger.LogError("middleware", "panic_recovery", 
+				logger.LogErrorContext(r.Context(), "middleware", "panic_recovery",
 					nil, // We don't have an actual error object
 					map[string]interface{}{
 						"panic":      err,
@@ -0,0 +1,106 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// MaxCartItemQuantity caps the quantity of a single cart line
+const MaxCartItemQuantity = 99
+
+// Cart is a shopping cart as stored: just what the shopper picked, without prices.
+// Guest carts have no UserID; each signed-in user has at most one cart.
+type Cart struct {
+	ID        string     `json:"id"`
+	UserID    string     `json:"userId,omitempty"`
+	Items     []CartItem `json:"items"`
+	CreatedAt time.Time  `json:"createdAt"`
+	UpdatedAt time.Time  `json:"updatedAt"`
+}
+
+// CartItem is a cart line, identified by product ID, size and color
+type CartItem struct {
+	ProductID int    `json:"productId"`
+	Size      string `json:"size"`
+	Color     string `json:"color"`
+	Quantity  int    `json:"quantity"`
+}
+
+// SameLine reports whether two items refer to the same product, size and color
+func (item CartItem) SameLine(other CartItem) bool {
+	return item.ProductID == other.ProductID &&
+		strings.EqualFold(item.Size, other.Size) &&
+		strings.EqualFold(item.Color, other.Color)
+}
+
+// Clone returns a deep copy of the cart
+func (c Cart) Clone() Cart {
+	clone := c
+	clone.Items = make([]CartItem, len(c.Items))
+	copy(clone.Items, c.Items)
+	return clone
+}
+
+// FindItem returns the index of the line matching item's product, size and color
+func (c Cart) FindItem(item CartItem) (int, bool) {
+	for i, existing := range c.Items {
+		if existing.SameLine(item) {
+			return i, true
+		}
+	}
+	return -1, false
+}
+
+// PricedCart is a cart priced against the current catalog
+type PricedCart struct {
+	ID        string       `json:"id"`
+	UserID    string       `json:"userId,omitempty"`
+	Items     []PricedLine `json:"items"`
+	ItemCount int          `json:"itemCount"`
+	Subtotal  float64      `json:"subtotal"`
+	Total     float64      `json:"total"`
+	UpdatedAt time.Time    `json:"updatedAt"`
+}
+
+// PricedLine is a cart line with its current product details and price.
+// Unavailable lines (removed products, sold out variants) carry a Problem and
+// are left out of the cart totals.
+type PricedLine struct {
+	CartItem
+	Name      string  `json:"name"`
+	Image     string  `json:"image"`
+	UnitPrice float64 `json:"unitPrice"`
+	LineTotal float64 `json:"lineTotal"`
+	Available bool    `json:"available"`
+	Problem   string  `json:"problem,omitempty"`
+}
+
+// Outcomes of a guest cart line that could not be merged as is
+const (
+	CartMergeAdjusted = "adjusted"
+	CartMergeDropped  = "dropped"
+)
+
+// CartMergeRequest is the body of a cart merge request
+type CartMergeRequest struct {
+	GuestCartID string `json:"guestCartId,omitempty"`
+}
+
+// CartMergeAdjustment reports a guest cart line whose quantity could not be
+// kept. Requested is the summed quantity of the guest and user lines; Quantity
+// is what the merged cart has now (0 when the line was dropped).
+type CartMergeAdjustment struct {
+	ProductID int    `json:"productId"`
+	Size      string `json:"size"`
+	Color     string `json:"color"`
+	Action    string `json:"action"`
+	Requested int    `json:"requested"`
+	Quantity  int    `json:"quantity"`
+	Reason    string `json:"reason"`
+}
+
+// CartMergeResult is the user's cart after a merge and what changed on the way
+type CartMergeResult struct {
+	Cart        PricedCart            `json:"cart"`
+	Adjustments []CartMergeAdjustment `json:"adjustments"`
+}
@@ -0,0 +1,174 @@
+package models
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// PriceBucketBounds are the edges of the price facet buckets. The last bucket
+// has no upper bound.
+var PriceBucketBounds = []float64{25, 50, 100, 200}
+
+// FacetCount is how many products in a listing have a facet value
+type FacetCount struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+// PriceBucket counts the products with Min <= price < Max. A nil Max means no upper bound.
+type PriceBucket struct {
+	Min   float64  `json:"min"`
+	Max   *float64 `json:"max,omitempty"`
+	Count int      `json:"count"`
+}
+
+// ProductFacets holds the facet counts of a product listing
+type ProductFacets struct {
+	Category []FacetCount  `json:"category"`
+	Gender   []FacetCount  `json:"gender"`
+	Size     []FacetCount  `json:"size"`
+	Color    []FacetCount  `json:"color"`
+	InStock  []FacetCount  `json:"inStock"`
+	Price    []PriceBucket `json:"price"`
+}
+
+// ComputeFacets counts facet values over the products that match filter.
+// Each facet ignores its own constraint, so selecting a category still reports
+// the other categories (and how many products picking them would show) while
+// every other active filter narrows the counts. filter.Query is ignored;
+// callers pass products already matched by the text query.
+func ComputeFacets(products []Product, filter ProductFilter) ProductFacets {
+	filter.Query = ""
+
+	withoutCategory := filter
+	withoutCategory.Categories = nil
+	withoutGender := filter
+	withoutGender.Gender = ""
+	withoutSize := filter
+	withoutSize.Size = ""
+	withoutColor := filter
+	withoutColor.Color = ""
+	withoutInStock := filter
+	withoutInStock.InStock = nil
+	withoutPrice := filter
+	withoutPrice.MinPrice, withoutPrice.MaxPrice = nil, nil
+
+	// Category and gender filters match exactly, size and color ignore case
+	categories := newFacetCounter(false)
+	genders := newFacetCounter(false)
+	sizes := newFacetCounter(true)
+	colors := newFacetCounter(true)
+	inStock := newFacetCounter(false)
+	prices := make([]int, len(PriceBucketBounds)+1)
+
+	for _, product := range products {
+		if withoutCategory.Matches(product) {
+			categories.add(product.Category)
+		}
+		if withoutGender.Matches(product) {
+			genders.add(product.Gender)
+		}
+		if withoutSize.Matches(product) {
+			sizes.addAll(product.Sizes)
+		}
+		if withoutColor.Matches(product) {
+			colors.addAll(product.Colors)
+		}
+		if withoutInStock.Matches(product) {
+			inStock.add(strconv.FormatBool(product.InStock))
+		}
+		if withoutPrice.Matches(product) {
+			prices[priceBucket(product.Price)]++
+		}
+	}
+
+	facets := ProductFacets{
+		Category: categories.counts(),
+		Gender:   genders.counts(),
+		Size:     sizes.counts(),
+		Color:    colors.counts(),
+		InStock:  inStock.counts(),
+		Price:    make([]PriceBucket, len(prices)),
+	}
+	for i, count := range prices {
+		bucket := PriceBucket{Count: count}
+		if i > 0 {
+			bucket.Min = PriceBucketBounds[i-1]
+		}
+		if i < len(PriceBucketBounds) {
+			max := PriceBucketBounds[i]
+			bucket.Max = &max
+		}
+		facets.Price[i] = bucket
+	}
+	return facets
+}
+
+// priceBucket returns the index of the price bucket containing price
+func priceBucket(price float64) int {
+	return sort.Search(len(PriceBucketBounds), func(i int) bool {
+		return PriceBucketBounds[i] > price
+	})
+}
+
+// facetCounter counts values, optionally ignoring case. Case-insensitive
+// counters report the first spelling seen.
+type facetCounter struct {
+	fold     bool
+	spelling map[string]string
+	count    map[string]int
+}
+
+// newFacetCounter creates an empty counter
+func newFacetCounter(fold bool) *facetCounter {
+	return &facetCounter{fold: fold, spelling: make(map[string]string), count: make(map[string]int)}
+}
+
+// key returns the value used to group a facet value
+func (c *facetCounter) key(value string) string {
+	if c.fold {
+		return strings.ToLower(value)
+	}
+	return value
+}
+
+// add counts one product with the value; empty values are skipped
+func (c *facetCounter) add(value string) {
+	if value == "" {
+		return
+	}
+	key := c.key(value)
+	if _, seen := c.spelling[key]; !seen {
+		c.spelling[key] = value
+	}
+	c.count[key]++
+}
+
+// addAll counts one product for each distinct value
+func (c *facetCounter) addAll(values []string) {
+	seen := make(map[string]bool, len(values))
+	for _, value := range values {
+		key := c.key(value)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		c.add(value)
+	}
+}
+
+// counts returns the values, most common first and then alphabetically
+func (c *facetCounter) counts() []FacetCount {
+	counts := make([]FacetCount, 0, len(c.count))
+	for key, count := range c.count {
+		counts = append(counts, FacetCount{Value: c.spelling[key], Count: count})
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Count != counts[j].Count {
+			return counts[i].Count > counts[j].Count
+		}
+		return strings.ToLower(counts[i].Value) < strings.ToLower(counts[j].Value)
+	})
+	return counts
+}
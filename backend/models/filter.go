@@ -0,0 +1,75 @@
+package models
+
+import (
+	"strings"
+)
+
+// ProductFilter describes the criteria used to narrow down a product listing.
+// Zero values mean "no constraint" for the corresponding field and every
+// non-zero field must match. Categories match if the product is in any of them.
+type ProductFilter struct {
+	Gender     string   `json:"gender,omitempty"`
+	Categories []string `json:"categories,omitempty"`
+	MinPrice   *float64 `json:"minPrice,omitempty"`
+	MaxPrice   *float64 `json:"maxPrice,omitempty"`
+	Query      string   `json:"q,omitempty"`
+	Size       string   `json:"size,omitempty"`
+	Color      string   `json:"color,omitempty"`
+	InStock    *bool    `json:"inStock,omitempty"`
+}
+
+// Matches reports whether the product satisfies every constraint in the filter
+func (f ProductFilter) Matches(product Product) bool {
+	if f.Gender != "" && product.Gender != f.Gender {
+		return false
+	}
+	if len(f.Categories) > 0 && !containsString(f.Categories, product.Category) {
+		return false
+	}
+	if f.MinPrice != nil && product.Price < *f.MinPrice {
+		return false
+	}
+	if f.MaxPrice != nil && product.Price > *f.MaxPrice {
+		return false
+	}
+	if f.Query != "" && !MatchesQuery(product, f.Query) {
+		return false
+	}
+	if f.Size != "" && !containsFold(product.Sizes, f.Size) {
+		return false
+	}
+	if f.Color != "" && !containsFold(product.Colors, f.Color) {
+		return false
+	}
+	if f.InStock != nil && product.InStock != *f.InStock {
+		return false
+	}
+	return true
+}
+
+// MatchesQuery checks whether the product name or description contains the query (case insensitive)
+func MatchesQuery(product Product, query string) bool {
+	queryLower := strings.ToLower(query)
+	return strings.Contains(strings.ToLower(product.Name), queryLower) ||
+		strings.Contains(strings.ToLower(product.Description), queryLower)
+}
+
+// containsString reports whether values contains value exactly
+func containsString(values []string, value string) bool {
+	for _, candidate := range values {
+		if candidate == value {
+			return true
+		}
+	}
+	return false
+}
+
+// containsFold reports whether values contains value, ignoring case
+func containsFold(values []string, value string) bool {
+	for _, candidate := range values {
+		if strings.EqualFold(candidate, value) {
+			return true
+		}
+	}
+	return false
+}
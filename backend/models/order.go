@@ -0,0 +1,123 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// Order is a placed order. Line prices are snapshotted when the order is
+// placed, so later catalog changes don't affect it.
+type Order struct {
+	ID              string      `json:"id"`
+	Status          OrderStatus `json:"status"`
+	Items           []OrderLine `json:"items"`
+	ItemCount       int         `json:"itemCount"`
+	Subtotal        float64     `json:"subtotal"`
+	Total           float64     `json:"total"`
+	ShippingAddress Address     `json:"shippingAddress"`
+	CartID          string      `json:"cartId,omitempty"`
+	UserID          string      `json:"userId,omitempty"`
+	PaymentID       string      `json:"paymentId,omitempty"`
+	PaymentStatus   string      `json:"paymentStatus,omitempty"`
+	CreatedAt       time.Time   `json:"createdAt"`
+	UpdatedAt       time.Time   `json:"updatedAt"`
+	// AccessToken lets a guest read their order. It is only returned when the
+	// order is placed; the order keeps a hash of it.
+	AccessToken     string `json:"accessToken,omitempty"`
+	AccessTokenHash string `json:"-"`
+}
+
+// OrderAccess describes the caller reading an order
+type OrderAccess struct {
+	UserID      string // Token subject of a signed-in caller
+	ManageAll   bool   // The caller may read every order
+	AccessToken string // Guest order secret sent by the caller
+}
+
+// OrderLine is a purchased variant with the price paid for it
+type OrderLine struct {
+	ProductID int     `json:"productId"`
+	Name      string  `json:"name"`
+	Image     string  `json:"image"`
+	Size      string  `json:"size"`
+	Color     string  `json:"color"`
+	SKU       string  `json:"sku,omitempty"`
+	Quantity  int     `json:"quantity"`
+	UnitPrice float64 `json:"unitPrice"`
+	LineTotal float64 `json:"lineTotal"`
+}
+
+// CartItem returns the product, size, color and quantity of the line
+func (line OrderLine) CartItem() CartItem {
+	return CartItem{ProductID: line.ProductID, Size: line.Size, Color: line.Color, Quantity: line.Quantity}
+}
+
+// Clone returns a deep copy of the order
+func (o Order) Clone() Order {
+	clone := o
+	clone.Items = make([]OrderLine, len(o.Items))
+	copy(clone.Items, o.Items)
+	return clone
+}
+
+// Address is a postal address
+type Address struct {
+	Name       string `json:"name"`
+	Line1      string `json:"line1"`
+	Line2      string `json:"line2,omitempty"`
+	City       string `json:"city"`
+	Region     string `json:"region,omitempty"`
+	PostalCode string `json:"postalCode"`
+	Country    string `json:"country"`
+}
+
+// Validate checks that the address has everything needed to ship to it
+func (a Address) Validate() []string {
+	var problems []string
+	required := []struct {
+		field string
+		value string
+	}{
+		{"name", a.Name},
+		{"line1", a.Line1},
+		{"city", a.City},
+		{"postalCode", a.PostalCode},
+		{"country", a.Country},
+	}
+	for _, r := range required {
+		if strings.TrimSpace(r.value) == "" {
+			problems = append(problems, "shippingAddress."+r.field+" is required")
+		}
+	}
+	return problems
+}
+
+// OrderRequest is the body of a place order request. Either CartID or Items
+// must be given; ordering a cart empties it. PaymentToken identifies the
+// customer's payment method at the payment gateway.
+type OrderRequest struct {
+	CartID          string     `json:"cartId,omitempty"`
+	Items           []CartItem `json:"items,omitempty"`
+	ShippingAddress Address    `json:"shippingAddress"`
+	PaymentToken    string     `json:"paymentToken"`
+	// UserID is the signed-in customer placing the order, set by the server
+	UserID string `json:"-"`
+}
+
+// Validate checks the parts of the request that don't need the catalog
+func (r OrderRequest) Validate() error {
+	problems := r.ShippingAddress.Validate()
+	switch {
+	case r.CartID != "" && len(r.Items) > 0:
+		problems = append(problems, "give either cartId or items, not both")
+	case r.CartID == "" && len(r.Items) == 0:
+		problems = append(problems, "cartId or items is required")
+	}
+	if strings.TrimSpace(r.PaymentToken) == "" {
+		problems = append(problems, "paymentToken is required")
+	}
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package models
+
+import (
+	"time"
+)
+
+// OrderStatus is the lifecycle state of an order
+type OrderStatus string
+
+// Order statuses. The happy path is pending → paid → fulfilled → shipped →
+// delivered; orders can be cancelled before they are paid or refunded any time after.
+const (
+	OrderStatusPending   OrderStatus = "pending"
+	OrderStatusPaid      OrderStatus = "paid"
+	OrderStatusFulfilled OrderStatus = "fulfilled"
+	OrderStatusShipped   OrderStatus = "shipped"
+	OrderStatusDelivered OrderStatus = "delivered"
+	OrderStatusCancelled OrderStatus = "cancelled"
+	OrderStatusRefunded  OrderStatus = "refunded"
+)
+
+// orderTransitions lists the statuses each status may move to
+var orderTransitions = map[OrderStatus][]OrderStatus{
+	OrderStatusPending:   {OrderStatusPaid, OrderStatusCancelled},
+	OrderStatusPaid:      {OrderStatusFulfilled, OrderStatusRefunded},
+	OrderStatusFulfilled: {OrderStatusShipped, OrderStatusRefunded},
+	OrderStatusShipped:   {OrderStatusDelivered, OrderStatusRefunded},
+	OrderStatusDelivered: {OrderStatusRefunded},
+	OrderStatusCancelled: {},
+	OrderStatusRefunded:  {},
+}
+
+// Valid reports whether the status is one of the known order statuses
+func (s OrderStatus) Valid() bool {
+	_, known := orderTransitions[s]
+	return known
+}
+
+// CanTransitionTo reports whether an order in status s may move to next
+func (s OrderStatus) CanTransitionTo(next OrderStatus) bool {
+	for _, allowed := range orderTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// NextStatuses returns the statuses an order in status s may move to
+func (s OrderStatus) NextStatuses() []OrderStatus {
+	next := make([]OrderStatus, len(orderTransitions[s]))
+	copy(next, orderTransitions[s])
+	return next
+}
+
+// ReleasesStock reports whether moving to status s puts the order's items back
+// in stock, i.e. the order is called off before anything left the warehouse
+func (s OrderStatus) ReleasesStock(from OrderStatus) bool {
+	switch s {
+	case OrderStatusCancelled:
+		return true
+	case OrderStatusRefunded:
+		return from == OrderStatusPaid || from == OrderStatusFulfilled
+	}
+	return false
+}
+
+// OrderEvent records a status change of an order. The first event of every
+// order has an empty From and records its placement.
+type OrderEvent struct {
+	OrderID string      `json:"orderId"`
+	From    OrderStatus `json:"from,omitempty"`
+	To      OrderStatus `json:"to"`
+	Actor   string      `json:"actor"`
+	Note    string      `json:"note,omitempty"`
+	At      time.Time   `json:"at"`
+}
+
+// OrderTransition is the body of a status change request
+type OrderTransition struct {
+	Status OrderStatus `json:"status"`
+	Actor  string      `json:"actor"` // Set from the caller's token; a value in the body is ignored
+	Note   string      `json:"note,omitempty"`
+}
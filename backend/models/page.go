@@ -0,0 +1,45 @@
+package models
+
+// Default and maximum page sizes for product listings
+const (
+	DefaultPageLimit = 50
+	MaxPageLimit     = 100
+)
+
+// PageRequest describes which slice of a listing the client wants.
+// Either Offset or Cursor may be used, not both.
+type PageRequest struct {
+	Limit  int    `json:"limit"`
+	Offset int    `json:"offset,omitempty"`
+	Cursor string `json:"cursor,omitempty"`
+	Sort   string `json:"sort"`
+}
+
+// ProductPage is the envelope returned by paginated product listings
+type ProductPage struct {
+	Items      []ProductHit  `json:"items"`
+	Total      int           `json:"total"`
+	Limit      int           `json:"limit"`
+	Offset     int           `json:"offset"`
+	Sort       string        `json:"sort"`
+	NextCursor string        `json:"next_cursor,omitempty"`
+	Filters    ProductFilter `json:"filters"`
+	Facets     ProductFacets `json:"facets"`
+	// Suggestions are corrected queries offered when a text search had no exact matches
+	Suggestions []string `json:"suggestions,omitempty"`
+}
+
+// ProductListing is the unpaginated result of a product listing or search
+type ProductListing struct {
+	Hits        []ProductHit
+	Facets      ProductFacets
+	Suggestions []string
+}
+
+// ProductHit is a product in a listing together with its search relevance.
+// Score and Highlights are only set when the listing was filtered by a text query.
+type ProductHit struct {
+	Product
+	Score      float64           `json:"score,omitempty"`
+	Highlights map[string]string `json:"highlights,omitempty"`
+}
@@ -2,23 +2,57 @@ package models
 
 // Product represents a product in the e-commerce system
 type Product struct {
-	ID          int      `json:"id"`
-	Name        string   `json:"name"`
-	Price       float64  `json:"price"`
-	Description string   `json:"description"`
-	Category    string   `json:"category"`
-	Gender      string   `json:"gender"`
-	Image       string   `json:"image"`
-	Images      []string `json:"images"`
-	Sizes       []string `json:"sizes"`
-	Colors      []string `json:"colors"`
-	InStock     bool     `json:"inStock"`
+	ID          int       `json:"id"`
+	Name        string    `json:"name"`
+	Price       float64   `json:"price"`
+	Description string    `json:"description"`
+	Category    string    `json:"category"`
+	Gender      string    `json:"gender"`
+	Image       string    `json:"image"`
+	Images      []string  `json:"images"`
+	Sizes       []string  `json:"sizes"`
+	Colors      []string  `json:"colors"`
+	InStock     bool      `json:"inStock"`
+	Variants    []Variant `json:"variants,omitempty"`
+}
+
+// Clone returns a deep copy of the product so callers can't mutate shared slices
+func (p Product) Clone() Product {
+	clone := p
+	clone.Images = copyStrings(p.Images)
+	clone.Sizes = copyStrings(p.Sizes)
+	clone.Colors = copyStrings(p.Colors)
+	clone.Variants = copyVariants(p.Variants)
+	return clone
+}
+
+// EnsureSlices replaces nil attribute slices with empty ones so they encode as [] rather than null
+func (p *Product) EnsureSlices() {
+	if p.Images == nil {
+		p.Images = []string{}
+	}
+	if p.Sizes == nil {
+		p.Sizes = []string{}
+	}
+	if p.Colors == nil {
+		p.Colors = []string{}
+	}
+}
+
+// copyStrings copies a string slice, preserving nil-ness
+func copyStrings(values []string) []string {
+	if values == nil {
+		return nil
+	}
+	copied := make([]string, len(values))
+	copy(copied, values)
+	return copied
 }
 
 // GetSampleProducts returns the sample product data
 // In a real application, this would be replaced with database queries
 func GetSampleProducts() []Product {
-	return []Product{
+	return withSampleInventory([]Product{
 		// Men's Clothing
 		{
 			ID:          1,
@@ -178,5 +212,5 @@ func GetSampleProducts() []Product {
 			Colors:      []string{"White", "Black", "Pink", "Blue", "Green"},
 			InStock:     true,
 		},
-	}
+	})
 }
\ No newline at end of file
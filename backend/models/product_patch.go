@@ -0,0 +1,57 @@
+package models
+
+// ProductPatch describes a partial product update. Nil fields are left unchanged.
+type ProductPatch struct {
+	Name        *string    `json:"name"`
+	Price       *float64   `json:"price"`
+	Description *string    `json:"description"`
+	Category    *string    `json:"category"`
+	Gender      *string    `json:"gender"`
+	Image       *string    `json:"image"`
+	Images      *[]string  `json:"images"`
+	Sizes       *[]string  `json:"sizes"`
+	Colors      *[]string  `json:"colors"`
+	InStock     *bool      `json:"inStock"`
+	Variants    *[]Variant `json:"variants"`
+}
+
+// Apply returns a copy of the product with the patch fields applied
+func (patch ProductPatch) Apply(product Product) Product {
+	patched := product.Clone()
+
+	if patch.Name != nil {
+		patched.Name = *patch.Name
+	}
+	if patch.Price != nil {
+		patched.Price = *patch.Price
+	}
+	if patch.Description != nil {
+		patched.Description = *patch.Description
+	}
+	if patch.Category != nil {
+		patched.Category = *patch.Category
+	}
+	if patch.Gender != nil {
+		patched.Gender = *patch.Gender
+	}
+	if patch.Image != nil {
+		patched.Image = *patch.Image
+	}
+	if patch.Images != nil {
+		patched.Images = copyStrings(*patch.Images)
+	}
+	if patch.Sizes != nil {
+		patched.Sizes = copyStrings(*patch.Sizes)
+	}
+	if patch.Colors != nil {
+		patched.Colors = copyStrings(*patch.Colors)
+	}
+	if patch.InStock != nil {
+		patched.InStock = *patch.InStock
+	}
+	if patch.Variants != nil {
+		patched.Variants = copyVariants(*patch.Variants)
+	}
+
+	return patched
+}
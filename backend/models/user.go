@@ -0,0 +1,107 @@
+package models
+
+import (
+	"net/mail"
+	"strings"
+	"time"
+)
+
+// MinPasswordLength is the shortest password accepted at registration
+const MinPasswordLength = 8
+
+// User is a customer or staff account. Secrets never leave the server.
+type User struct {
+	ID            string     `json:"id"`
+	Email         string     `json:"email"`
+	Name          string     `json:"name,omitempty"`
+	Roles         []string   `json:"roles"`
+	EmailVerified bool       `json:"emailVerified"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	UpdatedAt     time.Time  `json:"updatedAt"`
+	PasswordHash  []byte     `json:"-"`
+	FailedLogins  int        `json:"-"`
+	LockedUntil   *time.Time `json:"-"`
+	// VerificationTokenHash is the SHA-256 of the outstanding email verification token
+	VerificationTokenHash string `json:"-"`
+}
+
+// Clone returns a deep copy of the user
+func (u User) Clone() User {
+	clone := u
+	clone.Roles = append([]string(nil), u.Roles...)
+	clone.PasswordHash = append([]byte(nil), u.PasswordHash...)
+	if u.LockedUntil != nil {
+		lockedUntil := *u.LockedUntil
+		clone.LockedUntil = &lockedUntil
+	}
+	return clone
+}
+
+// Locked reports whether the account is locked out at the given time
+func (u User) Locked(now time.Time) bool {
+	return u.LockedUntil != nil && now.Before(*u.LockedUntil)
+}
+
+// NormalizeEmail lowercases and trims an email address so it can be used as a lookup key
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// Registration is the body of a sign-up request
+type Registration struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+	Name     string `json:"name,omitempty"`
+}
+
+// Validate checks the email address and password strength
+func (r Registration) Validate() error {
+	var problems []string
+	if address, err := mail.ParseAddress(r.Email); err != nil || address.Address != strings.TrimSpace(r.Email) {
+		problems = append(problems, "email must be a valid email address")
+	}
+	if len(r.Password) < MinPasswordLength {
+		problems = append(problems, "password must be at least 8 characters")
+	}
+	// bcrypt ignores everything after 72 bytes
+	if len(r.Password) > 72 {
+		problems = append(problems, "password must be at most 72 bytes")
+	}
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// LoginRequest is the body of a login request
+type LoginRequest struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+// RefreshRequest is the body of a token refresh request
+type RefreshRequest struct {
+	RefreshToken string `json:"refreshToken"`
+}
+
+// VerificationRequest is the body of an email verification request
+type VerificationRequest struct {
+	Token string `json:"token"`
+}
+
+// TokenPair is issued on login and refresh. The access token is a JWT for the
+// Authorization header; the refresh token is an opaque, single-use secret.
+type TokenPair struct {
+	AccessToken  string `json:"accessToken"`
+	TokenType    string `json:"tokenType"`
+	ExpiresIn    int    `json:"expiresIn"`
+	RefreshToken string `json:"refreshToken"`
+	User         User   `json:"user"`
+}
+
+// RefreshToken is a stored refresh token. Only its hash is kept.
+type RefreshToken struct {
+	Hash      string
+	UserID    string
+	ExpiresAt time.Time
+}
@@ -0,0 +1,49 @@
+package models
+
+import (
+	"strings"
+)
+
+// KnownGenders lists the gender values accepted for products
+var KnownGenders = []string{"men", "women"}
+
+// ValidationError collects the problems found while validating a model
+type ValidationError struct {
+	Problems []string `json:"problems"`
+}
+
+// Error implements the error interface
+func (e *ValidationError) Error() string {
+	return "validation failed: " + strings.Join(e.Problems, "; ")
+}
+
+// Validate checks that the product can be stored in the catalog
+func (p Product) Validate() error {
+	var problems []string
+
+	if strings.TrimSpace(p.Name) == "" {
+		problems = append(problems, "name is required")
+	}
+	if p.Price <= 0 {
+		problems = append(problems, "price must be positive")
+	}
+	if !isKnownGender(p.Gender) {
+		problems = append(problems, "gender must be one of "+strings.Join(KnownGenders, ", "))
+	}
+	problems = append(problems, p.validateVariants()...)
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// isKnownGender reports whether gender is one of KnownGenders
+func isKnownGender(gender string) bool {
+	for _, known := range KnownGenders {
+		if gender == known {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,148 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Variant is a purchasable size and color combination of a product (a SKU)
+type Variant struct {
+	Size  string `json:"size"`
+	Color string `json:"color"`
+	// SKU is an optional stock keeping code
+	SKU   string `json:"sku,omitempty"`
+	Stock int    `json:"stock"`
+	// Price overrides the product price for this variant when set
+	Price *float64 `json:"price,omitempty"`
+	// Available is derived from Stock and ignored on input
+	Available bool `json:"available"`
+}
+
+// Matches reports whether the variant has the given size and color (case insensitive)
+func (v Variant) Matches(size, color string) bool {
+	return strings.EqualFold(v.Size, size) && strings.EqualFold(v.Color, color)
+}
+
+// FindVariant returns the index of the variant with the given size and color
+func (p Product) FindVariant(size, color string) (int, bool) {
+	for i, variant := range p.Variants {
+		if variant.Matches(size, color) {
+			return i, true
+		}
+	}
+	return -1, false
+}
+
+// PriceFor returns the price of a size and color, honoring variant price overrides
+func (p Product) PriceFor(size, color string) float64 {
+	if i, ok := p.FindVariant(size, color); ok && p.Variants[i].Price != nil {
+		return *p.Variants[i].Price
+	}
+	return p.Price
+}
+
+// SyncInventory brings the derived inventory fields up to date. Products that
+// track inventory (have any variants) get a variant for every size and color
+// combination, in Sizes x Colors order with missing ones at zero stock, and
+// InStock is set when any variant has stock. Products without variants keep
+// their InStock flag as is.
+func (p *Product) SyncInventory() {
+	if len(p.Variants) == 0 {
+		return
+	}
+
+	if len(p.Sizes) > 0 && len(p.Colors) > 0 {
+		variants := make([]Variant, 0, len(p.Sizes)*len(p.Colors))
+		for _, size := range p.Sizes {
+			for _, color := range p.Colors {
+				variant := Variant{Size: size, Color: color}
+				if i, ok := p.FindVariant(size, color); ok {
+					variant = p.Variants[i]
+					variant.Size, variant.Color = size, color
+				}
+				variants = append(variants, variant)
+			}
+		}
+		p.Variants = variants
+	}
+
+	p.InStock = false
+	for i := range p.Variants {
+		p.Variants[i].Available = p.Variants[i].Stock > 0
+		if p.Variants[i].Available {
+			p.InStock = true
+		}
+	}
+}
+
+// validateVariants returns the problems with the product's variants
+func (p Product) validateVariants() []string {
+	var problems []string
+	for i, variant := range p.Variants {
+		label := fmt.Sprintf("variants[%d]", i)
+		if !containsFold(p.Sizes, variant.Size) {
+			problems = append(problems, fmt.Sprintf("%s: size %q is not one of the product sizes", label, variant.Size))
+		}
+		if !containsFold(p.Colors, variant.Color) {
+			problems = append(problems, fmt.Sprintf("%s: color %q is not one of the product colors", label, variant.Color))
+		}
+		if variant.Stock < 0 {
+			problems = append(problems, label+": stock must not be negative")
+		}
+		if variant.Price != nil && *variant.Price <= 0 {
+			problems = append(problems, label+": price must be positive")
+		}
+		for _, earlier := range p.Variants[:i] {
+			if earlier.Matches(variant.Size, variant.Color) {
+				problems = append(problems, fmt.Sprintf("%s: duplicate variant %s/%s", label, variant.Size, variant.Color))
+				break
+			}
+		}
+	}
+	return problems
+}
+
+// copyVariants deep copies variants, preserving nil-ness
+func copyVariants(variants []Variant) []Variant {
+	if variants == nil {
+		return nil
+	}
+	copied := make([]Variant, len(variants))
+	for i, variant := range variants {
+		if variant.Price != nil {
+			price := *variant.Price
+			variant.Price = &price
+		}
+		copied[i] = variant
+	}
+	return copied
+}
+
+// withSampleInventory gives each sample product stock for every size and
+// color, leaving a few combinations sold out
+func withSampleInventory(products []Product) []Product {
+	for p := range products {
+		product := &products[p]
+		for s, size := range product.Sizes {
+			for c, color := range product.Colors {
+				stock := (product.ID*7 + s*5 + c*3) % 12
+				if !product.InStock {
+					stock = 0
+				}
+				product.Variants = append(product.Variants, Variant{
+					Size:  size,
+					Color: color,
+					SKU:   sampleSKU(product.ID, size, color),
+					Stock: stock,
+				})
+			}
+		}
+		product.SyncInventory()
+	}
+	return products
+}
+
+// sampleSKU builds a readable SKU code such as "P2-32-DARKBLUE"
+func sampleSKU(id int, size, color string) string {
+	return fmt.Sprintf("P%d-%s-%s", id, strings.ToUpper(size), strings.ToUpper(strings.ReplaceAll(color, " ", "")))
+}
@@ -0,0 +1,194 @@
+package payments
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"ecommerce-backend/logger"
+)
+
+// Payment method tokens the fake gateway treats specially. Any other non-empty
+// token is approved.
+const (
+	// TokenDecline is always declined at authorization
+	TokenDecline = "tok_decline"
+	// TokenTimeout never gets an answer; the call fails once its context expires
+	TokenTimeout = "tok_timeout"
+	// TokenDelayedWebhook is approved, but its webhooks are held back by the webhook delay
+	TokenDelayedWebhook = "tok_delayed_webhook"
+)
+
+// FakeOptions configures a FakeGateway
+type FakeOptions struct {
+	// Deliver sends a webhook event; nil discards events
+	Deliver func(Event) error
+	// WebhookDelay is how long webhooks of TokenDelayedWebhook payments are held back
+	WebhookDelay time.Duration
+}
+
+// FakeGateway is an in-process PaymentGateway for development. Its behaviour
+// depends only on the payment method token, and payment and event IDs are
+// sequential, so runs are reproducible. Webhooks are delivered in order from a
+// background goroutine, after the call that caused them has returned. Calls
+// never wait for delivery: when the webhook queue is full, events are dropped
+// and logged, much like a real gateway whose delivery fails.
+type FakeGateway struct {
+	mu       sync.Mutex
+	payments map[string]*fakePayment
+	paySeq   int
+	eventSeq int
+
+	deliver      func(Event) error
+	webhookDelay time.Duration
+	events       chan Event
+}
+
+// fakePayment is a payment and the token it was authorized with
+type fakePayment struct {
+	Payment
+	token string
+}
+
+// NewFakeGateway creates a FakeGateway and starts its webhook delivery
+func NewFakeGateway(options FakeOptions) *FakeGateway {
+	gateway := &FakeGateway{
+		payments:     make(map[string]*fakePayment),
+		deliver:      options.Deliver,
+		webhookDelay: options.WebhookDelay,
+		events:       make(chan Event, 64),
+	}
+	go gateway.deliverEvents()
+	return gateway
+}
+
+// Authorize holds the amount unless the token says otherwise
+func (g *FakeGateway) Authorize(ctx context.Context, request AuthorizeRequest) (Payment, error) {
+	switch request.Token {
+	case "":
+		return Payment{}, fmt.Errorf("%w: missing payment token", ErrDeclined)
+	case TokenDecline:
+		return Payment{}, fmt.Errorf("%w: card declined by issuer", ErrDeclined)
+	case TokenTimeout:
+		<-ctx.Done()
+		return Payment{}, fmt.Errorf("%w: %v", ErrTimeout, ctx.Err())
+	}
+	if request.Amount <= 0 {
+		return Payment{}, fmt.Errorf("%w: amount must be positive", ErrDeclined)
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.paySeq++
+	now := time.Now().UTC()
+	payment := &fakePayment{
+		Payment: Payment{
+			ID:        fmt.Sprintf("pay_%06d", g.paySeq),
+			OrderID:   request.OrderID,
+			Amount:    request.Amount,
+			Currency:  Currency,
+			Status:    StatusAuthorized,
+			CreatedAt: now,
+			UpdatedAt: now,
+		},
+		token: request.Token,
+	}
+	g.payments[payment.ID] = payment
+	g.emit(EventPaymentAuthorized, payment)
+	return payment.Payment, nil
+}
+
+// Capture takes an authorized payment
+func (g *FakeGateway) Capture(ctx context.Context, paymentID string) (Payment, error) {
+	return g.move(paymentID, StatusAuthorized, StatusCaptured, EventPaymentCaptured)
+}
+
+// Refund returns a captured payment
+func (g *FakeGateway) Refund(ctx context.Context, paymentID string) (Payment, error) {
+	return g.move(paymentID, StatusCaptured, StatusRefunded, EventPaymentRefunded)
+}
+
+// Void cancels an authorization that was never captured
+func (g *FakeGateway) Void(ctx context.Context, paymentID string) (Payment, error) {
+	return g.move(paymentID, StatusAuthorized, StatusVoided, EventPaymentVoided)
+}
+
+// move changes a payment's status if it is currently in status from
+func (g *FakeGateway) move(paymentID string, from, to Status, eventType string) (Payment, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	payment, exists := g.payments[paymentID]
+	if !exists {
+		return Payment{}, ErrPaymentNotFound
+	}
+	if payment.Status != from {
+		return Payment{}, fmt.Errorf("%w: payment %s is %s", ErrInvalidState, paymentID, payment.Status)
+	}
+
+	payment.Status = to
+	payment.UpdatedAt = time.Now().UTC()
+	g.emit(eventType, payment)
+	return payment.Payment, nil
+}
+
+// emit queues a webhook event for a payment. Callers hold g.mu.
+func (g *FakeGateway) emit(eventType string, payment *fakePayment) {
+	g.eventSeq++
+	event := Event{
+		ID:        fmt.Sprintf("evt_%06d", g.eventSeq),
+		Type:      eventType,
+		Payment:   payment.Payment,
+		CreatedAt: time.Now().UTC(),
+	}
+
+	if payment.token == TokenDelayedWebhook && g.webhookDelay > 0 {
+		logger.Debug("Delaying payment webhook", map[string]interface{}{
+			"event_id":   event.ID,
+			"event_type": event.Type,
+			"payment_id": payment.ID,
+			"delay":      g.webhookDelay.String(),
+		})
+		time.AfterFunc(g.webhookDelay, func() { g.queue(event) })
+		return
+	}
+	g.queue(event)
+}
+
+// queue hands an event to the delivery goroutine, dropping it if the queue is full
+func (g *FakeGateway) queue(event Event) {
+	select {
+	case g.events <- event:
+	default:
+		logger.Warn("Payment webhook queue full; dropping event", map[string]interface{}{
+			"event_id":   event.ID,
+			"event_type": event.Type,
+			"payment_id": event.Payment.ID,
+			"queue_size": cap(g.events),
+		})
+	}
+}
+
+// deliverEvents sends queued events one at a time
+func (g *FakeGateway) deliverEvents() {
+	for event := range g.events {
+		if g.deliver == nil {
+			continue
+		}
+		if err := g.deliver(event); err != nil {
+			logger.LogError("FakeGateway", "deliverEvents", err, map[string]interface{}{
+				"event_id":   event.ID,
+				"event_type": event.Type,
+				"payment_id": event.Payment.ID,
+			})
+			continue
+		}
+		logger.Debug("Payment webhook delivered", map[string]interface{}{
+			"event_id":   event.ID,
+			"event_type": event.Type,
+			"payment_id": event.Payment.ID,
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package payments
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	"ecommerce-backend/logger"
+)
+
+func TestMain(m *testing.M) {
+	if err := logger.Init(logger.LogConfig{Level: "error", Output: "stdout"}); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	logger.Logger.SetOutput(io.Discard)
+	os.Exit(m.Run())
+}
+
+// TestFakeGatewayDoesNotBlockOnStuckDelivery checks that gateway calls keep
+// working while webhook delivery is stuck and the queue has filled up
+func TestFakeGatewayDoesNotBlockOnStuckDelivery(t *testing.T) {
+	stuck := make(chan struct{})
+	defer close(stuck)
+	gateway := NewFakeGateway(FakeOptions{
+		Deliver: func(Event) error {
+			<-stuck
+			return nil
+		},
+	})
+
+	done := make(chan error)
+	go func() {
+		for i := 0; i < 2*cap(gateway.events)+10; i++ {
+			payment, err := gateway.Authorize(context.Background(), AuthorizeRequest{
+				OrderID: fmt.Sprintf("order-%d", i),
+				Amount:  10,
+				Token:   "tok_visa",
+			})
+			if err != nil {
+				done <- err
+				return
+			}
+			if _, err := gateway.Capture(context.Background(), payment.ID); err != nil {
+				done <- err
+				return
+			}
+		}
+		done <- nil
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("gateway call failed: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("gateway calls blocked on a full webhook queue")
+	}
+}
@@ -0,0 +1,65 @@
+package payments
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Currency is the currency every payment is taken in
+const Currency = "USD"
+
+// Payment gateway errors
+var (
+	// ErrDeclined is returned when the gateway refuses to authorize a payment
+	ErrDeclined = errors.New("payment declined")
+	// ErrTimeout is returned when the gateway does not answer in time. The
+	// outcome of the call is unknown.
+	ErrTimeout = errors.New("payment gateway timed out")
+	// ErrPaymentNotFound is returned for operations on an unknown payment
+	ErrPaymentNotFound = errors.New("payment not found")
+	// ErrInvalidState is returned when a payment can't take the requested operation,
+	// such as capturing a voided authorization
+	ErrInvalidState = errors.New("payment is not in a state that allows this operation")
+)
+
+// Status is the state of a payment at the gateway
+type Status string
+
+// Payment statuses
+const (
+	StatusAuthorized Status = "authorized"
+	StatusCaptured   Status = "captured"
+	StatusVoided     Status = "voided"
+	StatusRefunded   Status = "refunded"
+)
+
+// AuthorizeRequest asks the gateway to hold an amount on a payment method
+type AuthorizeRequest struct {
+	OrderID string
+	Amount  float64
+	// Token identifies the customer's payment method at the gateway
+	Token string
+}
+
+// Payment is the gateway's view of a payment
+type Payment struct {
+	ID        string    `json:"id"`
+	OrderID   string    `json:"orderId"`
+	Amount    float64   `json:"amount"`
+	Currency  string    `json:"currency"`
+	Status    Status    `json:"status"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// PaymentGateway is a payment provider. Authorize holds the money, Capture
+// takes it, Void releases an authorization that was never captured and Refund
+// returns captured money. Implementations honour context cancellation and
+// return ErrTimeout when the deadline passes.
+type PaymentGateway interface {
+	Authorize(ctx context.Context, request AuthorizeRequest) (Payment, error)
+	Capture(ctx context.Context, paymentID string) (Payment, error)
+	Refund(ctx context.Context, paymentID string) (Payment, error)
+	Void(ctx context.Context, paymentID string) (Payment, error)
+}
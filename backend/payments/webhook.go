@@ -0,0 +1,135 @@
+package payments
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SignatureHeader carries the HMAC signature of a webhook body
+const SignatureHeader = "X-Payment-Signature"
+
+// SignatureTolerance is how old a signed webhook may be before it is rejected as a replay
+const SignatureTolerance = 5 * time.Minute
+
+// ErrInvalidSignature is returned when a webhook signature is missing, malformed,
+// does not match the body or is too old
+var ErrInvalidSignature = errors.New("invalid webhook signature")
+
+// Event types sent to the webhook receiver
+const (
+	EventPaymentAuthorized = "payment.authorized"
+	EventPaymentCaptured   = "payment.captured"
+	EventPaymentVoided     = "payment.voided"
+	EventPaymentRefunded   = "payment.refunded"
+)
+
+// Event is a webhook notification about a payment
+type Event struct {
+	ID        string    `json:"id"`
+	Type      string    `json:"type"`
+	Payment   Payment   `json:"payment"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Sign returns the signature header value for a body sent at the given time.
+// The signature is an HMAC-SHA256 of "<unix timestamp>.<body>", formatted as
+// "t=<unix timestamp>,v1=<hex digest>".
+func Sign(secret string, body []byte, at time.Time) string {
+	timestamp := strconv.FormatInt(at.Unix(), 10)
+	return "t=" + timestamp + ",v1=" + hex.EncodeToString(signatureDigest(secret, timestamp, body))
+}
+
+// VerifySignature checks a signature header produced by Sign against the body
+func VerifySignature(secret, header string, body []byte, now time.Time) error {
+	var timestamp, signature string
+	for _, part := range strings.Split(header, ",") {
+		key, value, found := strings.Cut(strings.TrimSpace(part), "=")
+		if !found {
+			continue
+		}
+		switch key {
+		case "t":
+			timestamp = value
+		case "v1":
+			signature = value
+		}
+	}
+	if timestamp == "" || signature == "" {
+		return fmt.Errorf("%w: expected t=<timestamp>,v1=<signature>", ErrInvalidSignature)
+	}
+
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: bad timestamp", ErrInvalidSignature)
+	}
+	age := now.Sub(time.Unix(unix, 0))
+	if age > SignatureTolerance || age < -SignatureTolerance {
+		return fmt.Errorf("%w: timestamp outside tolerance", ErrInvalidSignature)
+	}
+
+	given, err := hex.DecodeString(signature)
+	if err != nil || !hmac.Equal(given, signatureDigest(secret, timestamp, body)) {
+		return fmt.Errorf("%w: signature mismatch", ErrInvalidSignature)
+	}
+	return nil
+}
+
+// signatureDigest computes the HMAC of a timestamp and body
+func signatureDigest(secret, timestamp string, body []byte) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return mac.Sum(nil)
+}
+
+// WebhookSender posts signed events to a webhook receiver
+type WebhookSender struct {
+	url    string
+	secret string
+	client *http.Client
+}
+
+// NewWebhookSender creates a sender for the receiver at url
+func NewWebhookSender(url, secret string) *WebhookSender {
+	return &WebhookSender{
+		url:    url,
+		secret: secret,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Send delivers an event. Any response other than 2xx is an error.
+func (s *WebhookSender) Send(event Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	request, err := http.NewRequest(http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set(SignatureHeader, Sign(s.secret, body, time.Now()))
+
+	response, err := s.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return fmt.Errorf("webhook %s rejected with status %d", event.ID, response.StatusCode)
+	}
+	return nil
+}
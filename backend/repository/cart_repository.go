@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"errors"
+	"sync"
+
+	"ecommerce-backend/models"
+)
+
+// ErrCartNotFound is returned when a cart with the requested ID does not exist
+var ErrCartNotFound = errors.New("cart not found")
+
+// CartRepository abstracts the storage backend used by CartService
+type CartRepository interface {
+	// Get returns a cart or ErrCartNotFound
+	Get(id string) (*models.Cart, error)
+	// GetByUser returns a user's cart or ErrCartNotFound
+	GetByUser(userID string) (*models.Cart, error)
+	// Save creates or replaces a cart
+	Save(cart models.Cart) error
+	// Delete removes a cart or returns ErrCartNotFound
+	Delete(id string) error
+}
+
+// MemoryCartRepository keeps carts in memory; data is lost on restart
+type MemoryCartRepository struct {
+	mu    sync.RWMutex
+	carts map[string]models.Cart
+	users map[string]string
+}
+
+// NewMemoryCartRepository creates an empty in-memory cart store
+func NewMemoryCartRepository() *MemoryCartRepository {
+	return &MemoryCartRepository{
+		carts: make(map[string]models.Cart),
+		users: make(map[string]string),
+	}
+}
+
+// Get returns a cart or ErrCartNotFound
+func (r *MemoryCartRepository) Get(id string) (*models.Cart, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	cart, exists := r.carts[id]
+	if !exists {
+		return nil, ErrCartNotFound
+	}
+	clone := cart.Clone()
+	return &clone, nil
+}
+
+// GetByUser returns a user's cart or ErrCartNotFound
+func (r *MemoryCartRepository) GetByUser(userID string) (*models.Cart, error) {
+	r.mu.RLock()
+	id, exists := r.users[userID]
+	r.mu.RUnlock()
+
+	if !exists {
+		return nil, ErrCartNotFound
+	}
+	return r.Get(id)
+}
+
+// Save creates or replaces a cart
+func (r *MemoryCartRepository) Save(cart models.Cart) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.carts[cart.ID] = cart.Clone()
+	if cart.UserID != "" {
+		r.users[cart.UserID] = cart.ID
+	}
+	return nil
+}
+
+// Delete removes a cart or returns ErrCartNotFound
+func (r *MemoryCartRepository) Delete(id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cart, exists := r.carts[id]
+	if !exists {
+		return ErrCartNotFound
+	}
+	if cart.UserID != "" && r.users[cart.UserID] == id {
+		delete(r.users, cart.UserID)
+	}
+	delete(r.carts, id)
+	return nil
+}
@@ -0,0 +1,159 @@
+package repository
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"ecommerce-backend/logger"
+	"ecommerce-backend/models"
+)
+
+// FileProductRepository persists products as a JSON document on disk.
+// The whole catalog is kept in memory and rewritten after every mutation.
+type FileProductRepository struct {
+	mu     sync.RWMutex
+	path   string
+	memory *MemoryProductRepository
+}
+
+// NewFileProductRepository loads products from path. If the file does not exist
+// it is created and seeded with the given products.
+func NewFileProductRepository(path string, seed []models.Product) (*FileProductRepository, error) {
+	repo := &FileProductRepository{path: path}
+
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		repo.memory = NewMemoryProductRepository(seed)
+		if err := repo.save(seed); err != nil {
+			return nil, err
+		}
+		logger.Info("Product file created from seed data", map[string]interface{}{
+			"component":      "FileProductRepository",
+			"path":           path,
+			"products_count": len(seed),
+		})
+	case err != nil:
+		return nil, fmt.Errorf("read product file %s: %w", path, err)
+	default:
+		var products []models.Product
+		if err := json.Unmarshal(data, &products); err != nil {
+			return nil, fmt.Errorf("decode product file %s: %w", path, err)
+		}
+		repo.memory = NewMemoryProductRepository(products)
+		logger.Info("Product file loaded", map[string]interface{}{
+			"component":      "FileProductRepository",
+			"path":           path,
+			"products_count": len(products),
+		})
+	}
+
+	return repo, nil
+}
+
+// List returns every product in the store
+func (r *FileProductRepository) List() ([]models.Product, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.memory.List()
+}
+
+// GetByID returns a single product or ErrProductNotFound
+func (r *FileProductRepository) GetByID(id int) (*models.Product, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.memory.GetByID(id)
+}
+
+// Filter returns the products matching the given filter
+func (r *FileProductRepository) Filter(filter models.ProductFilter) ([]models.Product, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.memory.Filter(filter)
+}
+
+// Create stores a new product and writes the catalog to disk
+func (r *FileProductRepository) Create(product models.Product) (models.Product, error) {
+	var created models.Product
+	err := r.mutate(func() error {
+		var err error
+		created, err = r.memory.Create(product)
+		return err
+	})
+	return created, err
+}
+
+// Update replaces an existing product and writes the catalog to disk
+func (r *FileProductRepository) Update(product models.Product) (models.Product, error) {
+	var updated models.Product
+	err := r.mutate(func() error {
+		var err error
+		updated, err = r.memory.Update(product)
+		return err
+	})
+	return updated, err
+}
+
+// Delete removes a product and writes the catalog to disk
+func (r *FileProductRepository) Delete(id int) error {
+	return r.mutate(func() error {
+		return r.memory.Delete(id)
+	})
+}
+
+// mutate applies a change to the in-memory catalog and persists it.
+// If the write fails the in-memory state is rolled back.
+func (r *FileProductRepository) mutate(change func() error) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	previous := r.memory
+	r.memory = previous.snapshot()
+
+	if err := change(); err != nil {
+		r.memory = previous
+		return err
+	}
+
+	products, _ := r.memory.List()
+	if err := r.save(products); err != nil {
+		r.memory = previous
+		return err
+	}
+	return nil
+}
+
+// save writes the products atomically by renaming a temporary file over the target
+func (r *FileProductRepository) save(products []models.Product) error {
+	data, err := json.MarshalIndent(products, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode products: %w", err)
+	}
+
+	dir := filepath.Dir(r.path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("create product directory %s: %w", dir, err)
+	}
+
+	tmp, err := os.CreateTemp(dir, filepath.Base(r.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("create temporary product file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write product file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("close product file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), r.path); err != nil {
+		return fmt.Errorf("replace product file %s: %w", r.path, err)
+	}
+	return nil
+}
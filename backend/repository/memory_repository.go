@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"sync"
+
+	"ecommerce-backend/models"
+)
+
+// MemoryProductRepository keeps products in memory; data is lost on restart
+type MemoryProductRepository struct {
+	mu       sync.RWMutex
+	products []models.Product
+	nextID   int
+}
+
+// NewMemoryProductRepository creates an in-memory repository seeded with the given products
+func NewMemoryProductRepository(seed []models.Product) *MemoryProductRepository {
+	repo := &MemoryProductRepository{
+		products: cloneProducts(seed),
+		nextID:   1,
+	}
+	for _, product := range repo.products {
+		if product.ID >= repo.nextID {
+			repo.nextID = product.ID + 1
+		}
+	}
+	return repo
+}
+
+// NewSampleProductRepository creates an in-memory repository with the sample catalog
+func NewSampleProductRepository() *MemoryProductRepository {
+	return NewMemoryProductRepository(models.GetSampleProducts())
+}
+
+// List returns every product in the store
+func (r *MemoryProductRepository) List() ([]models.Product, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return cloneProducts(r.products), nil
+}
+
+// GetByID returns a single product or ErrProductNotFound
+func (r *MemoryProductRepository) GetByID(id int) (*models.Product, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if index := r.indexOf(id); index != -1 {
+		product := r.products[index].Clone()
+		return &product, nil
+	}
+	return nil, ErrProductNotFound
+}
+
+// Filter returns the products matching the given filter
+func (r *MemoryProductRepository) Filter(filter models.ProductFilter) ([]models.Product, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var filtered []models.Product
+	for _, product := range r.products {
+		if filter.Matches(product) {
+			filtered = append(filtered, product.Clone())
+		}
+	}
+	return filtered, nil
+}
+
+// Create stores a new product and assigns it the next free ID
+func (r *MemoryProductRepository) Create(product models.Product) (models.Product, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	product.ID = r.nextID
+	r.nextID++
+	r.products = append(r.products, product.Clone())
+	return product, nil
+}
+
+// Update replaces an existing product
+func (r *MemoryProductRepository) Update(product models.Product) (models.Product, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	index := r.indexOf(product.ID)
+	if index == -1 {
+		return models.Product{}, ErrProductNotFound
+	}
+	r.products[index] = product.Clone()
+	return product, nil
+}
+
+// Delete removes a product
+func (r *MemoryProductRepository) Delete(id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	index := r.indexOf(id)
+	if index == -1 {
+		return ErrProductNotFound
+	}
+	r.products = append(r.products[:index], r.products[index+1:]...)
+	return nil
+}
+
+// snapshot returns an independent copy of the repository
+func (r *MemoryProductRepository) snapshot() *MemoryProductRepository {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return &MemoryProductRepository{
+		products: cloneProducts(r.products),
+		nextID:   r.nextID,
+	}
+}
+
+// indexOf returns the slice position of the product with the given ID, or -1.
+// Callers must hold the lock.
+func (r *MemoryProductRepository) indexOf(id int) int {
+	for i, product := range r.products {
+		if product.ID == id {
+			return i
+		}
+	}
+	return -1
+}
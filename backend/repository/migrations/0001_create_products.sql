@@ -0,0 +1,35 @@
+-- Products and their normalized attribute tables
+CREATE TABLE products (
+    id          INTEGER PRIMARY KEY AUTOINCREMENT,
+    name        TEXT    NOT NULL,
+    price       REAL    NOT NULL,
+    description TEXT    NOT NULL DEFAULT '',
+    category    TEXT    NOT NULL,
+    gender      TEXT    NOT NULL,
+    image       TEXT    NOT NULL DEFAULT '',
+    in_stock    INTEGER NOT NULL DEFAULT 1
+);
+
+CREATE INDEX idx_products_gender_category ON products (gender, category);
+CREATE INDEX idx_products_price ON products (price);
+
+CREATE TABLE product_sizes (
+    product_id INTEGER NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    position   INTEGER NOT NULL,
+    size       TEXT    NOT NULL,
+    PRIMARY KEY (product_id, position)
+);
+
+CREATE TABLE product_colors (
+    product_id INTEGER NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    position   INTEGER NOT NULL,
+    color      TEXT    NOT NULL,
+    PRIMARY KEY (product_id, position)
+);
+
+CREATE TABLE product_images (
+    product_id INTEGER NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    position   INTEGER NOT NULL,
+    url        TEXT    NOT NULL,
+    PRIMARY KEY (product_id, position)
+);
@@ -0,0 +1,13 @@
+-- Per size and color inventory (SKUs)
+CREATE TABLE product_variants (
+    product_id INTEGER NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+    position   INTEGER NOT NULL,
+    size       TEXT    NOT NULL,
+    color      TEXT    NOT NULL,
+    sku        TEXT    NOT NULL DEFAULT '',
+    stock      INTEGER NOT NULL DEFAULT 0 CHECK (stock >= 0),
+    price      REAL,
+    PRIMARY KEY (product_id, position)
+);
+
+CREATE INDEX idx_product_variants_sku ON product_variants (sku);
@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"errors"
+	"sync"
+
+	"ecommerce-backend/models"
+)
+
+// ErrOrderNotFound is returned when an order with the requested ID does not exist
+var ErrOrderNotFound = errors.New("order not found")
+
+// OrderRepository abstracts the storage backend used by OrderService
+type OrderRepository interface {
+	// Get returns an order or ErrOrderNotFound
+	Get(id string) (*models.Order, error)
+	// Save creates or replaces an order
+	Save(order models.Order) error
+	// AppendEvent adds an event to an order's history
+	AppendEvent(event models.OrderEvent) error
+	// Events returns an order's history, oldest first
+	Events(orderID string) ([]models.OrderEvent, error)
+}
+
+// MemoryOrderRepository keeps orders in memory; data is lost on restart
+type MemoryOrderRepository struct {
+	mu     sync.RWMutex
+	orders map[string]models.Order
+	events map[string][]models.OrderEvent
+}
+
+// NewMemoryOrderRepository creates an empty in-memory order store
+func NewMemoryOrderRepository() *MemoryOrderRepository {
+	return &MemoryOrderRepository{
+		orders: make(map[string]models.Order),
+		events: make(map[string][]models.OrderEvent),
+	}
+}
+
+// Get returns an order or ErrOrderNotFound
+func (r *MemoryOrderRepository) Get(id string) (*models.Order, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	order, exists := r.orders[id]
+	if !exists {
+		return nil, ErrOrderNotFound
+	}
+	clone := order.Clone()
+	return &clone, nil
+}
+
+// Save creates or replaces an order
+func (r *MemoryOrderRepository) Save(order models.Order) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.orders[order.ID] = order.Clone()
+	return nil
+}
+
+// AppendEvent adds an event to an order's history
+func (r *MemoryOrderRepository) AppendEvent(event models.OrderEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.events[event.OrderID] = append(r.events[event.OrderID], event)
+	return nil
+}
+
+// Events returns an order's history, oldest first
+func (r *MemoryOrderRepository) Events(orderID string) ([]models.OrderEvent, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if _, exists := r.orders[orderID]; !exists {
+		return nil, ErrOrderNotFound
+	}
+	events := make([]models.OrderEvent, len(r.events[orderID]))
+	copy(events, r.events[orderID])
+	return events, nil
+}
@@ -0,0 +1,35 @@
+package repository
+
+import (
+	"errors"
+
+	"ecommerce-backend/models"
+)
+
+// ErrProductNotFound is returned when a product with the requested ID does not exist
+var ErrProductNotFound = errors.New("product not found")
+
+// ProductRepository abstracts the storage backend used by ProductService
+type ProductRepository interface {
+	// List returns every product in the store
+	List() ([]models.Product, error)
+	// GetByID returns a single product or ErrProductNotFound
+	GetByID(id int) (*models.Product, error)
+	// Filter returns the products matching the given filter
+	Filter(filter models.ProductFilter) ([]models.Product, error)
+	// Create stores a new product, assigning its ID, and returns the stored copy
+	Create(product models.Product) (models.Product, error)
+	// Update replaces an existing product or returns ErrProductNotFound
+	Update(product models.Product) (models.Product, error)
+	// Delete removes a product or returns ErrProductNotFound
+	Delete(id int) error
+}
+
+// cloneProducts deep-copies a product slice
+func cloneProducts(products []models.Product) []models.Product {
+	cloned := make([]models.Product, 0, len(products))
+	for _, product := range products {
+		cloned = append(cloned, product.Clone())
+	}
+	return cloned
+}
@@ -0,0 +1,426 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"ecommerce-backend/logger"
+	"ecommerce-backend/models"
+
+	_ "modernc.org/sqlite" // registers the "sqlite" database/sql driver
+)
+
+// productColumns is the column list shared by every product SELECT
+const productColumns = `id, name, price, description, category, gender, image, in_stock`
+
+// SQLiteProductRepository stores products in a SQLite database with sizes,
+// colors, images and variants kept in normalized child tables
+type SQLiteProductRepository struct {
+	db *sql.DB
+}
+
+// execer is the subset of *sql.DB and *sql.Tx used by the logged exec helper
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// NewSQLiteProductRepository opens the database at path, applies pending
+// migrations and seeds an empty catalog with the given products
+func NewSQLiteProductRepository(path string, seed []models.Product) (*SQLiteProductRepository, error) {
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, fmt.Errorf("create database directory %s: %w", dir, err)
+		}
+	}
+
+	dsn := fmt.Sprintf("file:%s?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)", path)
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("open database %s: %w", path, err)
+	}
+	// SQLite serializes writers anyway; a single connection also keeps
+	// in-memory databases consistent across calls
+	db.SetMaxOpenConns(1)
+
+	if err := Migrate(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	repo := &SQLiteProductRepository{db: db}
+	if err := repo.seed(seed); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	logger.Info("SQLite product store ready", map[string]interface{}{
+		"component": "SQLiteProductRepository",
+		"path":      path,
+	})
+	return repo, nil
+}
+
+// Close releases the underlying database handle
+func (r *SQLiteProductRepository) Close() error {
+	return r.db.Close()
+}
+
+// seed imports products into an empty database, keeping their IDs
+func (r *SQLiteProductRepository) seed(products []models.Product) error {
+	var count int
+	if err := r.db.QueryRow(`SELECT COUNT(*) FROM products`).Scan(&count); err != nil {
+		return fmt.Errorf("count products: %w", err)
+	}
+	if count > 0 || len(products) == 0 {
+		return nil
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin seed: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, product := range products {
+		if _, err := exec(tx,
+			`INSERT INTO products (`+productColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+			product.ID, product.Name, product.Price, product.Description,
+			product.Category, product.Gender, product.Image, product.InStock,
+		); err != nil {
+			return fmt.Errorf("seed product %d: %w", product.ID, err)
+		}
+		if err := insertAttributes(tx, product); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit seed: %w", err)
+	}
+
+	logger.Info("Database seeded with sample products", map[string]interface{}{
+		"component":      "SQLiteProductRepository",
+		"products_count": len(products),
+	})
+	return nil
+}
+
+// List returns every product in the store
+func (r *SQLiteProductRepository) List() ([]models.Product, error) {
+	return r.load("1 = 1")
+}
+
+// GetByID returns a single product or ErrProductNotFound
+func (r *SQLiteProductRepository) GetByID(id int) (*models.Product, error) {
+	products, err := r.load("id = ?", id)
+	if err != nil {
+		return nil, err
+	}
+	if len(products) == 0 {
+		return nil, ErrProductNotFound
+	}
+	return &products[0], nil
+}
+
+// Filter returns the products matching the given filter
+func (r *SQLiteProductRepository) Filter(filter models.ProductFilter) ([]models.Product, error) {
+	conditions := []string{"1 = 1"}
+	var args []interface{}
+
+	if filter.Gender != "" {
+		conditions = append(conditions, "gender = ?")
+		args = append(args, filter.Gender)
+	}
+	if len(filter.Categories) > 0 {
+		placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(filter.Categories)), ", ")
+		conditions = append(conditions, "category IN ("+placeholders+")")
+		for _, category := range filter.Categories {
+			args = append(args, category)
+		}
+	}
+	if filter.MinPrice != nil {
+		conditions = append(conditions, "price >= ?")
+		args = append(args, *filter.MinPrice)
+	}
+	if filter.MaxPrice != nil {
+		conditions = append(conditions, "price <= ?")
+		args = append(args, *filter.MaxPrice)
+	}
+	if filter.Query != "" {
+		pattern := "%" + escapeLike(strings.ToLower(filter.Query)) + "%"
+		conditions = append(conditions, `(LOWER(name) LIKE ? ESCAPE '\' OR LOWER(description) LIKE ? ESCAPE '\')`)
+		args = append(args, pattern, pattern)
+	}
+	if filter.Size != "" {
+		conditions = append(conditions, "EXISTS (SELECT 1 FROM product_sizes s WHERE s.product_id = products.id AND LOWER(s.size) = LOWER(?))")
+		args = append(args, filter.Size)
+	}
+	if filter.Color != "" {
+		conditions = append(conditions, "EXISTS (SELECT 1 FROM product_colors c WHERE c.product_id = products.id AND LOWER(c.color) = LOWER(?))")
+		args = append(args, filter.Color)
+	}
+	if filter.InStock != nil {
+		conditions = append(conditions, "in_stock = ?")
+		args = append(args, *filter.InStock)
+	}
+
+	return r.load(strings.Join(conditions, " AND "), args...)
+}
+
+// Create stores a new product; SQLite assigns the ID
+func (r *SQLiteProductRepository) Create(product models.Product) (models.Product, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return models.Product{}, fmt.Errorf("begin create: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := exec(tx,
+		`INSERT INTO products (name, price, description, category, gender, image, in_stock) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		product.Name, product.Price, product.Description,
+		product.Category, product.Gender, product.Image, product.InStock,
+	)
+	if err != nil {
+		return models.Product{}, fmt.Errorf("insert product: %w", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return models.Product{}, fmt.Errorf("read product id: %w", err)
+	}
+	product.ID = int(id)
+
+	if err := insertAttributes(tx, product); err != nil {
+		return models.Product{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return models.Product{}, fmt.Errorf("commit create: %w", err)
+	}
+	return product, nil
+}
+
+// Update replaces an existing product and its attributes
+func (r *SQLiteProductRepository) Update(product models.Product) (models.Product, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return models.Product{}, fmt.Errorf("begin update: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := exec(tx,
+		`UPDATE products SET name = ?, price = ?, description = ?, category = ?, gender = ?, image = ?, in_stock = ? WHERE id = ?`,
+		product.Name, product.Price, product.Description,
+		product.Category, product.Gender, product.Image, product.InStock, product.ID,
+	)
+	if err != nil {
+		return models.Product{}, fmt.Errorf("update product %d: %w", product.ID, err)
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return models.Product{}, ErrProductNotFound
+	}
+
+	for _, table := range []string{"product_sizes", "product_colors", "product_images", "product_variants"} {
+		if _, err := exec(tx, `DELETE FROM `+table+` WHERE product_id = ?`, product.ID); err != nil {
+			return models.Product{}, fmt.Errorf("clear %s for product %d: %w", table, product.ID, err)
+		}
+	}
+	if err := insertAttributes(tx, product); err != nil {
+		return models.Product{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		return models.Product{}, fmt.Errorf("commit update: %w", err)
+	}
+	return product, nil
+}
+
+// Delete removes a product; child rows are removed by ON DELETE CASCADE
+func (r *SQLiteProductRepository) Delete(id int) error {
+	result, err := exec(r.db, `DELETE FROM products WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("delete product %d: %w", id, err)
+	}
+	if affected, err := result.RowsAffected(); err == nil && affected == 0 {
+		return ErrProductNotFound
+	}
+	return nil
+}
+
+// load fetches the products matching where (a SQL condition on the products
+// table) together with their sizes, colors, images and variants, ordered by ID
+func (r *SQLiteProductRepository) load(where string, args ...interface{}) ([]models.Product, error) {
+	start := time.Now()
+	query := `SELECT ` + productColumns + ` FROM products WHERE (` + where + `) ORDER BY id`
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query products: %w", err)
+	}
+	defer rows.Close()
+
+	var products []models.Product
+	index := make(map[int]int)
+	for rows.Next() {
+		var product models.Product
+		if err := rows.Scan(
+			&product.ID, &product.Name, &product.Price, &product.Description,
+			&product.Category, &product.Gender, &product.Image, &product.InStock,
+		); err != nil {
+			return nil, fmt.Errorf("scan product: %w", err)
+		}
+		index[product.ID] = len(products)
+		products = append(products, product)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("read products: %w", err)
+	}
+	logQuery(query, start, int64(len(products)))
+
+	if len(products) == 0 {
+		return products, nil
+	}
+
+	subquery := `SELECT id FROM products WHERE (` + where + `)`
+	attributes := []struct {
+		table  string
+		column string
+		assign func(product *models.Product, value string)
+	}{
+		{"product_sizes", "size", func(p *models.Product, v string) { p.Sizes = append(p.Sizes, v) }},
+		{"product_colors", "color", func(p *models.Product, v string) { p.Colors = append(p.Colors, v) }},
+		{"product_images", "url", func(p *models.Product, v string) { p.Images = append(p.Images, v) }},
+	}
+	for _, attribute := range attributes {
+		start := time.Now()
+		query := `SELECT product_id, ` + attribute.column + ` FROM ` + attribute.table +
+			` WHERE product_id IN (` + subquery + `) ORDER BY product_id, position`
+		rows, err := r.db.Query(query, args...)
+		if err != nil {
+			return nil, fmt.Errorf("query %s: %w", attribute.table, err)
+		}
+
+		var count int64
+		for rows.Next() {
+			var productID int
+			var value string
+			if err := rows.Scan(&productID, &value); err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("scan %s: %w", attribute.table, err)
+			}
+			if i, ok := index[productID]; ok {
+				attribute.assign(&products[i], value)
+			}
+			count++
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, fmt.Errorf("read %s: %w", attribute.table, err)
+		}
+		logQuery(query, start, count)
+	}
+
+	if err := r.loadVariants(subquery, args, products, index); err != nil {
+		return nil, err
+	}
+
+	// Products without sizes, colors or images encode them as [] like the other repositories
+	for i := range products {
+		products[i].EnsureSlices()
+	}
+
+	return products, nil
+}
+
+// loadVariants attaches the variants of the products selected by subquery
+func (r *SQLiteProductRepository) loadVariants(subquery string, args []interface{}, products []models.Product, index map[int]int) error {
+	start := time.Now()
+	query := `SELECT product_id, size, color, sku, stock, price FROM product_variants` +
+		` WHERE product_id IN (` + subquery + `) ORDER BY product_id, position`
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return fmt.Errorf("query product_variants: %w", err)
+	}
+	defer rows.Close()
+
+	var count int64
+	for rows.Next() {
+		var productID int
+		var variant models.Variant
+		var price sql.NullFloat64
+		if err := rows.Scan(&productID, &variant.Size, &variant.Color, &variant.SKU, &variant.Stock, &price); err != nil {
+			return fmt.Errorf("scan product_variants: %w", err)
+		}
+		if price.Valid {
+			variant.Price = &price.Float64
+		}
+		variant.Available = variant.Stock > 0
+		if i, ok := index[productID]; ok {
+			products[i].Variants = append(products[i].Variants, variant)
+		}
+		count++
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("read product_variants: %w", err)
+	}
+	logQuery(query, start, count)
+	return nil
+}
+
+// insertAttributes writes the sizes, colors, images and variants of a product
+func insertAttributes(q execer, product models.Product) error {
+	attributes := []struct {
+		table  string
+		column string
+		values []string
+	}{
+		{"product_sizes", "size", product.Sizes},
+		{"product_colors", "color", product.Colors},
+		{"product_images", "url", product.Images},
+	}
+	for _, attribute := range attributes {
+		for position, value := range attribute.values {
+			if _, err := exec(q,
+				`INSERT INTO `+attribute.table+` (product_id, position, `+attribute.column+`) VALUES (?, ?, ?)`,
+				product.ID, position, value,
+			); err != nil {
+				return fmt.Errorf("insert %s for product %d: %w", attribute.table, product.ID, err)
+			}
+		}
+	}
+	for position, variant := range product.Variants {
+		if _, err := exec(q,
+			`INSERT INTO product_variants (product_id, position, size, color, sku, stock, price) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			product.ID, position, variant.Size, variant.Color, variant.SKU, variant.Stock, variant.Price,
+		); err != nil {
+			return fmt.Errorf("insert product_variants for product %d: %w", product.ID, err)
+		}
+	}
+	return nil
+}
+
+// exec runs a statement and logs its duration and affected row count
+func exec(q execer, query string, args ...interface{}) (sql.Result, error) {
+	start := time.Now()
+	result, err := q.Exec(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	var affected int64
+	if n, err := result.RowsAffected(); err == nil {
+		affected = n
+	}
+	logQuery(query, start, affected)
+	return result, nil
+}
+
+// logQuery reports a completed query through the structured logger
+func logQuery(query string, start time.Time, rows int64) {
+	duration := float64(time.Since(start).Nanoseconds()) / 1e6
+	logger.LogDatabaseQuery(query, duration, rows)
+}
+
+// escapeLike escapes LIKE wildcards so the query is matched literally
+func escapeLike(value string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	return replacer.Replace(value)
+}
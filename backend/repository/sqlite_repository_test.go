@@ -0,0 +1,247 @@
+package repository
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"ecommerce-backend/logger"
+	"ecommerce-backend/models"
+)
+
+func TestMain(m *testing.M) {
+	if err := logger.Init(logger.LogConfig{Level: "error", Output: "stdout"}); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	logger.Logger.SetOutput(io.Discard)
+	os.Exit(m.Run())
+}
+
+// openTestStore opens a SQLite store in a temporary directory and closes it when the test ends
+func openTestStore(t *testing.T, path string, seed []models.Product) *SQLiteProductRepository {
+	t.Helper()
+	repo, err := NewSQLiteProductRepository(path, seed)
+	if err != nil {
+		t.Fatalf("NewSQLiteProductRepository: %v", err)
+	}
+	t.Cleanup(func() { repo.Close() })
+	return repo
+}
+
+// stored returns product as the store hands it back: empty attributes as [] and
+// variant availability derived from stock
+func stored(product models.Product) models.Product {
+	product = product.Clone()
+	product.EnsureSlices()
+	for i := range product.Variants {
+		product.Variants[i].Available = product.Variants[i].Stock > 0
+	}
+	return product
+}
+
+func TestSQLiteMigrateIsIdempotent(t *testing.T) {
+	repo := openTestStore(t, filepath.Join(t.TempDir(), "products.db"), nil)
+
+	migrations, err := loadMigrations()
+	if err != nil {
+		t.Fatalf("loadMigrations: %v", err)
+	}
+	for run := 0; run < 2; run++ {
+		if err := Migrate(repo.db); err != nil {
+			t.Fatalf("Migrate run %d: %v", run+1, err)
+		}
+	}
+
+	rows, err := repo.db.Query(`SELECT version, name FROM schema_migrations ORDER BY version`)
+	if err != nil {
+		t.Fatalf("read schema_migrations: %v", err)
+	}
+	defer rows.Close()
+	var applied []string
+	for rows.Next() {
+		var version int
+		var name string
+		if err := rows.Scan(&version, &name); err != nil {
+			t.Fatalf("scan schema_migrations: %v", err)
+		}
+		applied = append(applied, fmt.Sprintf("%d %s", version, name))
+	}
+	var want []string
+	for _, m := range migrations {
+		want = append(want, fmt.Sprintf("%d %s", m.version, m.name))
+	}
+	if !reflect.DeepEqual(applied, want) {
+		t.Errorf("schema_migrations = %v, want each migration once: %v", applied, want)
+	}
+}
+
+func TestSQLiteSeed(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data", "products.db")
+	seed := models.GetSampleProducts()
+	repo := openTestStore(t, path, seed)
+
+	products, err := repo.List()
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(products) != len(seed) {
+		t.Fatalf("List returned %d products, want the %d seeded", len(products), len(seed))
+	}
+	for i, product := range products {
+		if want := stored(seed[i]); !reflect.DeepEqual(product, want) {
+			t.Errorf("seeded product %d =\n%+v\nwant\n%+v", seed[i].ID, product, want)
+		}
+	}
+
+	// Reopening a catalog that has products leaves it alone
+	if _, err := repo.Create(models.Product{Name: "Extra", Price: 1, Category: "shirts", Gender: "men"}); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	repo.Close()
+	reopened := openTestStore(t, path, seed)
+	products, err = reopened.List()
+	if err != nil {
+		t.Fatalf("List after reopening: %v", err)
+	}
+	if len(products) != len(seed)+1 {
+		t.Errorf("reopened store has %d products, want %d (no second seed)", len(products), len(seed)+1)
+	}
+}
+
+func TestSQLiteCRUD(t *testing.T) {
+	repo := openTestStore(t, filepath.Join(t.TempDir(), "products.db"), nil)
+
+	variantPrice := 89.5
+	product := models.Product{
+		Name:        "Denim Jacket",
+		Price:       79.99,
+		Description: "Classic trucker jacket",
+		Category:    "jackets",
+		Gender:      "men",
+		Image:       "front.jpg",
+		Images:      []string{"front.jpg", "back.jpg"},
+		Sizes:       []string{"M", "L"},
+		Colors:      []string{"Blue"},
+		InStock:     true,
+		Variants: []models.Variant{
+			{Size: "M", Color: "Blue", SKU: "DJ-M-BLU", Stock: 3},
+			{Size: "L", Color: "Blue", Stock: 0, Price: &variantPrice},
+		},
+	}
+
+	created, err := repo.Create(product)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if created.ID == 0 {
+		t.Fatal("Create did not assign an ID")
+	}
+	product.ID = created.ID
+
+	got, err := repo.GetByID(created.ID)
+	if err != nil {
+		t.Fatalf("GetByID: %v", err)
+	}
+	if want := stored(product); !reflect.DeepEqual(*got, want) {
+		t.Errorf("GetByID after Create =\n%+v\nwant\n%+v", *got, want)
+	}
+
+	// Update replaces the attributes rather than appending to them
+	product.Price = 69.99
+	product.Sizes = []string{"S"}
+	product.Colors = nil
+	product.Variants = []models.Variant{{Size: "S", Color: "Black", Stock: 5}}
+	if _, err := repo.Update(product); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	got, err = repo.GetByID(product.ID)
+	if err != nil {
+		t.Fatalf("GetByID after Update: %v", err)
+	}
+	if want := stored(product); !reflect.DeepEqual(*got, want) {
+		t.Errorf("GetByID after Update =\n%+v\nwant\n%+v", *got, want)
+	}
+
+	filtered, err := repo.Filter(models.ProductFilter{Query: "TRUCKER", Size: "s"})
+	if err != nil {
+		t.Fatalf("Filter: %v", err)
+	}
+	if len(filtered) != 1 || filtered[0].ID != product.ID {
+		t.Errorf("Filter = %+v, want the updated product", filtered)
+	}
+
+	if err := repo.Delete(product.ID); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := repo.GetByID(product.ID); !errors.Is(err, ErrProductNotFound) {
+		t.Errorf("GetByID after Delete error = %v, want ErrProductNotFound", err)
+	}
+	for _, table := range []string{"product_sizes", "product_colors", "product_images", "product_variants"} {
+		var count int
+		if err := repo.db.QueryRow(`SELECT COUNT(*) FROM `+table+` WHERE product_id = ?`, product.ID).Scan(&count); err != nil {
+			t.Fatalf("count %s: %v", table, err)
+		}
+		if count != 0 {
+			t.Errorf("%s still has %d rows for the deleted product", table, count)
+		}
+	}
+
+	// Missing products
+	if _, err := repo.Update(models.Product{ID: 999, Name: "Ghost", Category: "shirts", Gender: "men"}); !errors.Is(err, ErrProductNotFound) {
+		t.Errorf("Update of a missing product error = %v, want ErrProductNotFound", err)
+	}
+	if err := repo.Delete(999); !errors.Is(err, ErrProductNotFound) {
+		t.Errorf("Delete of a missing product error = %v, want ErrProductNotFound", err)
+	}
+}
+
+func TestSQLiteEmptyAttributesEncodeAsArrays(t *testing.T) {
+	repo := openTestStore(t, filepath.Join(t.TempDir(), "products.db"), nil)
+
+	created, err := repo.Create(models.Product{Name: "Plain Tee", Price: 15, Category: "t-shirts", Gender: "women"})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	loaded := map[string]func() (models.Product, error){
+		"GetByID": func() (models.Product, error) {
+			product, err := repo.GetByID(created.ID)
+			if err != nil {
+				return models.Product{}, err
+			}
+			return *product, nil
+		},
+		"List": func() (models.Product, error) {
+			products, err := repo.List()
+			if err != nil || len(products) != 1 {
+				return models.Product{}, fmt.Errorf("List = %+v, %v", products, err)
+			}
+			return products[0], nil
+		},
+	}
+	for name, load := range loaded {
+		product, err := load()
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		body, err := json.Marshal(product)
+		if err != nil {
+			t.Fatalf("marshal: %v", err)
+		}
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(body, &fields); err != nil {
+			t.Fatalf("unmarshal: %v", err)
+		}
+		for _, field := range []string{"images", "sizes", "colors"} {
+			if string(fields[field]) != "[]" {
+				t.Errorf("%s: %s encodes as %s, want []", name, field, fields[field])
+			}
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package repository
+
+import (
+	"errors"
+	"sync"
+
+	"ecommerce-backend/models"
+)
+
+// User store errors
+var (
+	// ErrUserNotFound is returned when a user with the requested ID or email does not exist
+	ErrUserNotFound = errors.New("user not found")
+	// ErrEmailTaken is returned when registering an email that already has an account
+	ErrEmailTaken = errors.New("email is already registered")
+	// ErrRefreshTokenNotFound is returned for unknown or already used refresh tokens
+	ErrRefreshTokenNotFound = errors.New("refresh token not found")
+)
+
+// UserRepository abstracts the storage backend used by UserService. Emails are
+// stored normalized, so lookups by email are exact.
+type UserRepository interface {
+	// Get returns a user or ErrUserNotFound
+	Get(id string) (*models.User, error)
+	// GetByEmail returns a user or ErrUserNotFound
+	GetByEmail(email string) (*models.User, error)
+	// GetByVerificationToken returns the user with an outstanding verification token hash or ErrUserNotFound
+	GetByVerificationToken(hash string) (*models.User, error)
+	// Create stores a new user or returns ErrEmailTaken
+	Create(user models.User) error
+	// Update replaces an existing user or returns ErrUserNotFound
+	Update(user models.User) error
+
+	// SaveRefreshToken stores a refresh token
+	SaveRefreshToken(token models.RefreshToken) error
+	// TakeRefreshToken removes and returns a refresh token, or returns ErrRefreshTokenNotFound
+	TakeRefreshToken(hash string) (*models.RefreshToken, error)
+}
+
+// MemoryUserRepository keeps users in memory; data is lost on restart
+type MemoryUserRepository struct {
+	mu            sync.RWMutex
+	users         map[string]models.User
+	emails        map[string]string
+	refreshTokens map[string]models.RefreshToken
+}
+
+// NewMemoryUserRepository creates an empty in-memory user store
+func NewMemoryUserRepository() *MemoryUserRepository {
+	return &MemoryUserRepository{
+		users:         make(map[string]models.User),
+		emails:        make(map[string]string),
+		refreshTokens: make(map[string]models.RefreshToken),
+	}
+}
+
+// Get returns a user or ErrUserNotFound
+func (r *MemoryUserRepository) Get(id string) (*models.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	user, exists := r.users[id]
+	if !exists {
+		return nil, ErrUserNotFound
+	}
+	clone := user.Clone()
+	return &clone, nil
+}
+
+// GetByEmail returns a user or ErrUserNotFound
+func (r *MemoryUserRepository) GetByEmail(email string) (*models.User, error) {
+	r.mu.RLock()
+	id, exists := r.emails[email]
+	r.mu.RUnlock()
+
+	if !exists {
+		return nil, ErrUserNotFound
+	}
+	return r.Get(id)
+}
+
+// GetByVerificationToken returns the user with an outstanding verification token hash or ErrUserNotFound
+func (r *MemoryUserRepository) GetByVerificationToken(hash string) (*models.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, user := range r.users {
+		if hash != "" && user.VerificationTokenHash == hash {
+			clone := user.Clone()
+			return &clone, nil
+		}
+	}
+	return nil, ErrUserNotFound
+}
+
+// Create stores a new user or returns ErrEmailTaken
+func (r *MemoryUserRepository) Create(user models.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.emails[user.Email]; exists {
+		return ErrEmailTaken
+	}
+	r.users[user.ID] = user.Clone()
+	r.emails[user.Email] = user.ID
+	return nil
+}
+
+// Update replaces an existing user or returns ErrUserNotFound
+func (r *MemoryUserRepository) Update(user models.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, exists := r.users[user.ID]
+	if !exists {
+		return ErrUserNotFound
+	}
+	if existing.Email != user.Email {
+		if _, taken := r.emails[user.Email]; taken {
+			return ErrEmailTaken
+		}
+		delete(r.emails, existing.Email)
+		r.emails[user.Email] = user.ID
+	}
+	r.users[user.ID] = user.Clone()
+	return nil
+}
+
+// SaveRefreshToken stores a refresh token
+func (r *MemoryUserRepository) SaveRefreshToken(token models.RefreshToken) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.refreshTokens[token.Hash] = token
+	return nil
+}
+
+// TakeRefreshToken removes and returns a refresh token, or returns ErrRefreshTokenNotFound
+func (r *MemoryUserRepository) TakeRefreshToken(hash string) (*models.RefreshToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	token, exists := r.refreshTokens[hash]
+	if !exists {
+		return nil, ErrRefreshTokenNotFound
+	}
+	delete(r.refreshTokens, hash)
+	return &token, nil
+}
@@ -1,64 +1,301 @@
 package routes
 
 import (
+	"crypto/rand"
+	"fmt"
 	"net/http"
 
+	"ecommerce-backend/auth"
+	"ecommerce-backend/config"
 	"ecommerce-backend/handlers"
 	"ecommerce-backend/logger"
 	"ecommerce-backend/middleware"
+	"ecommerce-backend/models"
+	"ecommerce-backend/payments"
+	"ecommerce-backend/repository"
 	"ecommerce-backend/services"
 	"github.com/gorilla/mux"
 )
 
 // SetupRoutes configures all the routes for the application
-func SetupRoutes() *mux.Router {
+func SetupRoutes(cfg *config.Config) (*mux.Router, error) {
 	logger.Info("Setting up routes", map[string]interface{}{
 		"component": "routes",
 	})
 
+	// Initialize storage
+	productRepo, err := newProductRepository(cfg.Storage)
+	if err != nil {
+		return nil, err
+	}
+
+	gateway, err := newPaymentGateway(cfg.Payment, cfg.Server.IsDevelopment())
+	if err != nil {
+		return nil, err
+	}
+
+	verifier, signer, err := newTokenKeys(cfg.Auth, cfg.Server.IsDevelopment())
+	if err != nil {
+		return nil, err
+	}
+
 	// Initialize services
-	productService := services.NewProductService()
+	productService, err := services.NewProductService(productRepo)
+	if err != nil {
+		return nil, err
+	}
+	inventoryService := services.NewInventoryService(productService)
+	cartService := services.NewCartService(repository.NewMemoryCartRepository(), productService)
+	orderService := services.NewOrderService(repository.NewMemoryOrderRepository(), cartService, inventoryService, gateway, cfg.Payment.Timeout)
+	userService, err := newUserService(cfg.Auth, signer, cfg.Server.IsDevelopment())
+	if err != nil {
+		return nil, err
+	}
 
 	// Initialize handlers
 	productHandler := handlers.NewProductHandler(productService)
+	inventoryHandler := handlers.NewInventoryHandler(inventoryService)
+	cartHandler := handlers.NewCartHandler(cartService)
+	orderHandler := handlers.NewOrderHandler(orderService)
+	paymentHandler := handlers.NewPaymentHandler(orderService, cfg.Payment.WebhookSecret)
+	userHandler := handlers.NewUserHandler(userService)
+	logLevelHandler := handlers.NewLogLevelHandler()
 
 	// Create router
 	router := mux.NewRouter()
 
 	// Apply global middleware
+	router.Use(middleware.RequestIDMiddleware)
 	router.Use(middleware.LoggingMiddleware)
 	router.Use(middleware.RecoveryMiddleware)
+	router.Use(auth.Authenticate(verifier))
+	router.Use(middleware.IdempotencyMiddleware(cfg.Server.IdempotencyTTL))
+
+	// API routes
+	api := router.PathPrefix("/api").Subrouter()
+
+	// Back-office routes need a signed-in caller with the right role; see setupAdminRoutes
+	admin := api.PathPrefix("/admin").Subrouter()
 
 	// Setup product routes
-	setupProductRoutes(router, productHandler)
+	setupProductRoutes(api, productHandler)
+	setupCartRoutes(api, cartHandler)
+	setupOrderRoutes(api, orderHandler)
+	setupPaymentRoutes(api, paymentHandler)
+	setupUserRoutes(api, userHandler)
+	setupAdminRoutes(api, admin, productHandler, inventoryHandler, orderHandler, logLevelHandler)
 
 	logger.Info("Routes setup completed", map[string]interface{}{
 		"component": "routes",
 		"endpoints": []string{
 			"GET /api/products",
 			"GET /api/products/{id}",
+			"POST /api/admin/products",
+			"PUT /api/admin/products/{id}",
+			"PATCH /api/admin/products/{id}",
+			"DELETE /api/admin/products/{id}",
+			"POST /api/admin/products/{id}/inventory",
+			"POST /api/products",
+			"PUT /api/products/{id}",
+			"PATCH /api/products/{id}",
+			"DELETE /api/products/{id}",
+			"POST /api/products/{id}/inventory",
+			"POST /api/carts",
+			"GET /api/carts/{id}/items",
+			"PUT /api/carts/{id}/items",
+			"DELETE /api/carts/{id}/items",
+			"POST /api/carts/merge",
+			"POST /api/orders",
+			"GET /api/orders/{id}",
+			"POST /api/admin/orders/{id}/transitions",
+			"POST /api/orders/{id}/transitions",
+			"GET /api/admin/log-level",
+			"PUT /api/admin/log-level",
+			"GET /api/orders/{id}/events",
+			"POST /api/payments/webhook",
+			"POST /api/users",
+			"POST /api/users/verify",
+			"GET /api/users/me",
+			"POST /api/auth/login",
+			"POST /api/auth/refresh",
 			"GET /api/products/search",
+			"GET /api/products/suggest",
 			"GET /api/products/price-range",
 			"GET /api/categories",
 			"GET /api/genders",
 		},
 	})
 
-	return router
+	return router, nil
 }
 
-// setupProductRoutes configures all product-related routes
-func setupProductRoutes(router *mux.Router, productHandler *handlers.ProductHandler) {
-	// Product API routes
-	api := router.PathPrefix("/api").Subrouter()
+// newProductRepository selects the product storage backend from configuration
+func newProductRepository(storage config.StorageConfig) (repository.ProductRepository, error) {
+	logger.LogStartup("storage", map[string]interface{}{
+		"driver":        storage.Driver,
+		"path":          storage.Path,
+		"database_path": storage.DatabasePath,
+	})
+
+	switch storage.Driver {
+	case "", "memory":
+		return repository.NewSampleProductRepository(), nil
+	case "file":
+		return repository.NewFileProductRepository(storage.Path, models.GetSampleProducts())
+	case "sqlite":
+		return repository.NewSQLiteProductRepository(storage.DatabasePath, models.GetSampleProducts())
+	default:
+		return nil, fmt.Errorf("unknown product store driver %q", storage.Driver)
+	}
+}
+
+// newPaymentGateway creates the payment gateway selected by the configuration.
+// Outside development it refuses the fake gateway and the development webhook
+// secret: anyone who knows the secret can sign webhooks that mark orders paid.
+func newPaymentGateway(payment config.PaymentConfig, development bool) (payments.PaymentGateway, error) {
+	logger.LogStartup("payments", map[string]interface{}{
+		"gateway":       payment.Gateway,
+		"webhook_url":   payment.WebhookURL,
+		"webhook_delay": payment.WebhookDelay.String(),
+		"timeout":       payment.Timeout.String(),
+	})
+	if payment.WebhookSecret == "" || payment.WebhookSecret == config.DefaultWebhookSecret {
+		if !development {
+			return nil, fmt.Errorf("no payment webhook secret configured: set PAYMENT_WEBHOOK_SECRET")
+		}
+		logger.Warn("Using the development payment webhook secret; set PAYMENT_WEBHOOK_SECRET", map[string]interface{}{
+			"component": "payments",
+		})
+	}
+
+	switch payment.Gateway {
+	case "", "fake":
+		if !development {
+			return nil, fmt.Errorf("the fake payment gateway is for development only: set ENV=development or choose another PAYMENT_GATEWAY")
+		}
+		sender := payments.NewWebhookSender(payment.WebhookURL, payment.WebhookSecret)
+		return payments.NewFakeGateway(payments.FakeOptions{
+			Deliver:      sender.Send,
+			WebhookDelay: payment.WebhookDelay,
+		}), nil
+	default:
+		return nil, fmt.Errorf("unknown payment gateway %q", payment.Gateway)
+	}
+}
+
+// newTokenKeys creates the JWT verifier and the signer used for login from the
+// configuration. Login signs RS256 when a private key is configured and HS256 otherwise.
+func newTokenKeys(authConfig config.AuthConfig, development bool) (*auth.Verifier, *auth.Signer, error) {
+	logger.LogStartup("auth", map[string]interface{}{
+		"hs256":    authConfig.JWTSecret != "",
+		"rs256":    authConfig.JWTPublicKeyPath != "" || authConfig.JWTPrivateKeyPath != "",
+		"issuer":   authConfig.Issuer,
+		"audience": authConfig.Audience,
+	})
+
+	verifierConfig := auth.VerifierConfig{
+		HMACSecret: []byte(authConfig.JWTSecret),
+		Issuer:     authConfig.Issuer,
+		Audience:   authConfig.Audience,
+		Leeway:     authConfig.Leeway,
+	}
+	if authConfig.JWTPublicKeyPath != "" {
+		key, err := auth.LoadRSAPublicKey(authConfig.JWTPublicKeyPath)
+		if err != nil {
+			return nil, nil, err
+		}
+		verifierConfig.RSAPublicKey = key
+	}
 
+	var signer *auth.Signer
+	if authConfig.JWTPrivateKeyPath != "" {
+		key, err := auth.LoadRSAPrivateKey(authConfig.JWTPrivateKeyPath)
+		if err != nil {
+			return nil, nil, err
+		}
+		signer = auth.NewRS256Signer(key)
+		if verifierConfig.RSAPublicKey == nil {
+			verifierConfig.RSAPublicKey = &key.PublicKey
+		}
+	}
+
+	if authConfig.JWTSecret == "" && verifierConfig.RSAPublicKey == nil {
+		if !development {
+			return nil, nil, fmt.Errorf("no JWT key configured: set JWT_HMAC_SECRET, JWT_RSA_PUBLIC_KEY_PATH or JWT_RSA_PRIVATE_KEY_PATH")
+		}
+		// A random secret per process; tokens stop working when the server restarts
+		secret := make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return nil, nil, fmt.Errorf("generate development JWT secret: %w", err)
+		}
+		logger.Warn("No JWT key configured; using a random HS256 secret for this process (ENV=development)", map[string]interface{}{
+			"component": "auth",
+		})
+		verifierConfig.HMACSecret = secret
+	}
+	if signer == nil {
+		if len(verifierConfig.HMACSecret) == 0 {
+			return nil, nil, fmt.Errorf("JWT_RSA_PRIVATE_KEY_PATH or JWT_HMAC_SECRET is needed to issue tokens")
+		}
+		signer = auth.NewHS256Signer(verifierConfig.HMACSecret)
+	}
+
+	verifier, err := auth.NewVerifier(verifierConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+	return verifier, signer, nil
+}
+
+// newUserService creates the account service and the bootstrap admin account, if
+// configured. No email sender exists yet, so verification tokens are only shown
+// on stderr in development and are otherwise not delivered.
+func newUserService(authConfig config.AuthConfig, signer *auth.Signer, development bool) (*services.UserService, error) {
+	var verification services.VerificationSender = services.UndeliveredVerificationSender{}
+	if development {
+		verification = services.DevVerificationSender{}
+	}
+
+	userService, err := services.NewUserService(repository.NewMemoryUserRepository(), signer, verification, services.UserServiceConfig{
+		AccessTokenTTL:  authConfig.AccessTokenTTL,
+		RefreshTokenTTL: authConfig.RefreshTokenTTL,
+		Issuer:          authConfig.Issuer,
+		Audience:        authConfig.Audience,
+		MaxFailedLogins: authConfig.MaxFailedLogins,
+		LockoutDuration: authConfig.LockoutDuration,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if authConfig.AdminEmail != "" {
+		admin, err := userService.EnsureUser(models.Registration{
+			Email:    authConfig.AdminEmail,
+			Password: authConfig.AdminPassword,
+			Name:     "Administrator",
+		}, []string{auth.RoleAdmin})
+		if err != nil {
+			return nil, fmt.Errorf("create admin account: %w", err)
+		}
+		logger.LogStartup("users", map[string]interface{}{
+			"admin_user_id": admin.ID,
+			"admin_email":   admin.Email,
+		})
+	}
+	return userService, nil
+}
+
+// setupProductRoutes configures all product-related routes
+func setupProductRoutes(api *mux.Router, productHandler *handlers.ProductHandler) {
 	// Extended endpoints for better functionality (must come BEFORE parameterized routes)
 	api.HandleFunc("/products/search", productHandler.SearchProducts).Methods("GET")
+	api.HandleFunc("/products/suggest", productHandler.SuggestProducts).Methods("GET")
 	api.HandleFunc("/products/price-range", productHandler.GetProductsByPriceRange).Methods("GET")
 	
 	// Core product endpoints
 	api.HandleFunc("/products", productHandler.GetProducts).Methods("GET")
 	api.HandleFunc("/products/{id:[0-9]+}", productHandler.GetProduct).Methods("GET")
+
 	
 	// Category and gender endpoints
 	api.HandleFunc("/categories", productHandler.GetCategories).Methods("GET")
@@ -66,6 +303,7 @@ func setupProductRoutes(router *mux.Router, productHandler *handlers.ProductHand
 
 	// Add OPTIONS method for CORS preflight requests
 	api.HandleFunc("/products/search", optionsHandler).Methods("OPTIONS")
+	api.HandleFunc("/products/suggest", optionsHandler).Methods("OPTIONS")
 	api.HandleFunc("/products/price-range", optionsHandler).Methods("OPTIONS")
 	api.HandleFunc("/products", optionsHandler).Methods("OPTIONS")
 	api.HandleFunc("/products/{id:[0-9]+}", optionsHandler).Methods("OPTIONS")
@@ -73,10 +311,94 @@ func setupProductRoutes(router *mux.Router, productHandler *handlers.ProductHand
 	api.HandleFunc("/genders", optionsHandler).Methods("OPTIONS")
 }
 
+// setupCartRoutes configures the shopping cart routes
+func setupCartRoutes(api *mux.Router, cartHandler *handlers.CartHandler) {
+	api.HandleFunc("/carts", cartHandler.CreateCart).Methods("POST")
+	api.HandleFunc("/carts/{id:[0-9a-f]+}/items", cartHandler.GetCartItems).Methods("GET")
+	api.HandleFunc("/carts/{id:[0-9a-f]+}/items", cartHandler.PutCartItem).Methods("PUT")
+	api.HandleFunc("/carts/{id:[0-9a-f]+}/items", cartHandler.DeleteCartItems).Methods("DELETE")
+
+	api.Handle("/carts/merge", auth.RequireAuth(http.HandlerFunc(cartHandler.MergeCart))).Methods("POST")
+
+	api.HandleFunc("/carts", optionsHandler).Methods("OPTIONS")
+	api.HandleFunc("/carts/merge", optionsHandler).Methods("OPTIONS")
+	api.HandleFunc("/carts/{id:[0-9a-f]+}/items", optionsHandler).Methods("OPTIONS")
+}
+
+// setupOrderRoutes configures the checkout and order routes
+func setupOrderRoutes(api *mux.Router, orderHandler *handlers.OrderHandler) {
+	api.HandleFunc("/orders", orderHandler.PlaceOrder).Methods("POST")
+	api.HandleFunc("/orders/{id:[0-9a-f]+}", orderHandler.GetOrder).Methods("GET")
+	api.HandleFunc("/orders/{id:[0-9a-f]+}/events", orderHandler.GetOrderEvents).Methods("GET")
+
+	api.HandleFunc("/orders", optionsHandler).Methods("OPTIONS")
+	api.HandleFunc("/orders/{id:[0-9a-f]+}", optionsHandler).Methods("OPTIONS")
+	api.HandleFunc("/orders/{id:[0-9a-f]+}/events", optionsHandler).Methods("OPTIONS")
+}
+
+// setupPaymentRoutes configures the payment gateway webhook receiver
+func setupPaymentRoutes(api *mux.Router, paymentHandler *handlers.PaymentHandler) {
+	api.HandleFunc("/payments/webhook", paymentHandler.ReceiveWebhook).Methods("POST")
+}
+
+// setupUserRoutes configures the account and session routes
+func setupUserRoutes(api *mux.Router, userHandler *handlers.UserHandler) {
+	api.HandleFunc("/users", userHandler.Register).Methods("POST")
+	api.HandleFunc("/users/verify", userHandler.VerifyEmail).Methods("POST")
+	api.Handle("/users/me", auth.RequireAuth(http.HandlerFunc(userHandler.GetCurrentUser))).Methods("GET")
+	api.HandleFunc("/auth/login", userHandler.Login).Methods("POST")
+	api.HandleFunc("/auth/refresh", userHandler.Refresh).Methods("POST")
+
+	api.HandleFunc("/users", optionsHandler).Methods("OPTIONS")
+	api.HandleFunc("/users/verify", optionsHandler).Methods("OPTIONS")
+	api.HandleFunc("/users/me", optionsHandler).Methods("OPTIONS")
+	api.HandleFunc("/auth/login", optionsHandler).Methods("OPTIONS")
+	api.HandleFunc("/auth/refresh", optionsHandler).Methods("OPTIONS")
+}
+
+// setupAdminRoutes configures the back-office routes. Each group of routes
+// checks its own permission, so merchandisers can manage the catalog while
+// order operations stay with admins. The catalog, stock and order routes also
+// answer at their original paths outside /api/admin, with the same checks, for
+// clients written before /api/admin existed.
+func setupAdminRoutes(api, admin *mux.Router, productHandler *handlers.ProductHandler, inventoryHandler *handlers.InventoryHandler, orderHandler *handlers.OrderHandler, logLevelHandler *handlers.LogLevelHandler) {
+	for _, base := range []*mux.Router{admin, api} {
+		catalog := base.NewRoute().Subrouter()
+		catalog.Use(auth.RequirePermission(auth.PermissionManageCatalog))
+		catalog.HandleFunc("/products", productHandler.CreateProduct).Methods("POST")
+		catalog.HandleFunc("/products/{id:[0-9]+}", productHandler.UpdateProduct).Methods("PUT")
+		catalog.HandleFunc("/products/{id:[0-9]+}", productHandler.PatchProduct).Methods("PATCH")
+		catalog.HandleFunc("/products/{id:[0-9]+}", productHandler.DeleteProduct).Methods("DELETE")
+
+		inventory := base.NewRoute().Subrouter()
+		inventory.Use(auth.RequirePermission(auth.PermissionManageInventory))
+		inventory.HandleFunc("/products/{id:[0-9]+}/inventory", inventoryHandler.AdjustStock).Methods("POST")
+
+		orders := base.NewRoute().Subrouter()
+		orders.Use(auth.RequirePermission(auth.PermissionManageOrders))
+		orders.HandleFunc("/orders/{id:[0-9a-f]+}/transitions", orderHandler.TransitionOrder).Methods("POST")
+	}
+
+	system := admin.NewRoute().Subrouter()
+	system.Use(auth.RequirePermission(auth.PermissionManageSystem))
+	system.HandleFunc("/log-level", logLevelHandler.GetLogLevel).Methods("GET")
+	system.HandleFunc("/log-level", logLevelHandler.PutLogLevel).Methods("PUT")
+
+	// Preflight requests carry no credentials, so they bypass the admin checks.
+	// /products and /products/{id} already answer them; see setupProductRoutes.
+	api.HandleFunc("/admin/products", optionsHandler).Methods("OPTIONS")
+	api.HandleFunc("/admin/products/{id:[0-9]+}", optionsHandler).Methods("OPTIONS")
+	api.HandleFunc("/admin/products/{id:[0-9]+}/inventory", optionsHandler).Methods("OPTIONS")
+	api.HandleFunc("/admin/orders/{id:[0-9a-f]+}/transitions", optionsHandler).Methods("OPTIONS")
+	api.HandleFunc("/admin/log-level", optionsHandler).Methods("OPTIONS")
+	api.HandleFunc("/products/{id:[0-9]+}/inventory", optionsHandler).Methods("OPTIONS")
+	api.HandleFunc("/orders/{id:[0-9a-f]+}/transitions", optionsHandler).Methods("OPTIONS")
+}
+
 // optionsHandler handles CORS preflight requests
 func optionsHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+	w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+	w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, Idempotency-Key, X-Request-ID, X-Order-Token")
 	w.WriteHeader(http.StatusOK)
 }
\ No newline at end of file
@@ -0,0 +1,65 @@
+package search
+
+import (
+	"strings"
+	"unicode"
+)
+
+// stopWords are common English words that carry no search value
+var stopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true,
+	"be": true, "but": true, "by": true, "for": true, "from": true, "if": true,
+	"in": true, "into": true, "is": true, "it": true, "its": true, "no": true,
+	"not": true, "of": true, "on": true, "or": true, "our": true, "so": true,
+	"such": true, "that": true, "the": true, "their": true, "then": true,
+	"there": true, "these": true, "they": true, "this": true, "to": true,
+	"was": true, "we": true, "were": true, "will": true, "with": true,
+	"you": true, "your": true,
+}
+
+// Token is a word found in a text together with its byte offsets
+type Token struct {
+	Word  string // lower-cased surface form
+	Term  string // normalized (lower-cased, stemmed) form used for matching
+	Start int    // byte offset of the first character in the original text
+	End   int    // byte offset just past the last character
+}
+
+// Tokenize splits text into words, lower-cases and stems them and drops stop words
+func Tokenize(text string) []Token {
+	var tokens []Token
+	start := -1
+	flush := func(end int) {
+		if start < 0 {
+			return
+		}
+		word := strings.ToLower(text[start:end])
+		if !stopWords[word] {
+			tokens = append(tokens, Token{Word: word, Term: Stem(word), Start: start, End: end})
+		}
+		start = -1
+	}
+
+	for i, r := range text {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		flush(i)
+	}
+	flush(len(text))
+
+	return tokens
+}
+
+// Analyze returns just the normalized terms of text
+func Analyze(text string) []string {
+	tokens := Tokenize(text)
+	terms := make([]string, len(tokens))
+	for i, token := range tokens {
+		terms[i] = token.Term
+	}
+	return terms
+}
@@ -0,0 +1,178 @@
+package search
+
+import (
+	"sort"
+	"strings"
+)
+
+// Typo tolerance settings
+const (
+	// maxCorrections is how many vocabulary words a misspelled word expands to
+	maxCorrections = 3
+	// maxSuggestions caps the number of "did you mean" queries returned
+	maxSuggestions = 3
+)
+
+// correction is a vocabulary word close to a misspelled query word
+type correction struct {
+	word     string
+	term     string
+	distance int
+	docs     int
+}
+
+// maxEditDistance returns how many edits are tolerated for a word of the given
+// length. Very short words are not corrected since almost anything is close to them.
+func maxEditDistance(word string) int {
+	switch length := len([]rune(word)); {
+	case length <= 3:
+		return 0
+	case length <= 5:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// fuzzyWeight scales the score of a corrected match so exact matches rank first
+func fuzzyWeight(distance int) float64 {
+	if distance <= 0 {
+		return 1
+	}
+	return 0.5 / float64(distance)
+}
+
+// corrections returns the vocabulary words within the allowed edit distance of
+// word, closest and most common first. Callers must hold the read lock.
+func (idx *Index) corrections(word string) []correction {
+	limit := maxEditDistance(word)
+	if limit == 0 {
+		return nil
+	}
+
+	var candidates []correction
+	for vocabWord, entry := range idx.vocab {
+		distance := editDistance(word, vocabWord, limit)
+		if distance > limit {
+			continue
+		}
+		candidates = append(candidates, correction{
+			word:     vocabWord,
+			term:     entry.term,
+			distance: distance,
+			docs:     entry.docs,
+		})
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.distance != b.distance {
+			return a.distance < b.distance
+		}
+		if a.docs != b.docs {
+			return a.docs > b.docs
+		}
+		return a.word < b.word
+	})
+	if len(candidates) > maxCorrections {
+		candidates = candidates[:maxCorrections]
+	}
+	return candidates
+}
+
+// buildSuggestions rewrites the query with corrected words. The n-th suggestion
+// uses the n-th best correction for every misspelled word (or the best one when
+// a word has fewer candidates). corrections is parallel to tokens and nil for
+// words that were found in the index.
+func buildSuggestions(query string, tokens []Token, corrections [][]correction) []string {
+	options := 0
+	for _, candidates := range corrections {
+		if len(candidates) > options {
+			options = len(candidates)
+		}
+	}
+	if options > maxSuggestions {
+		options = maxSuggestions
+	}
+
+	var suggestions []string
+	seen := make(map[string]bool)
+	for n := 0; n < options; n++ {
+		var suggestion strings.Builder
+		position := 0
+		for i, token := range tokens {
+			candidates := corrections[i]
+			if len(candidates) == 0 {
+				continue
+			}
+			choice := candidates[0]
+			if n < len(candidates) {
+				choice = candidates[n]
+			}
+			suggestion.WriteString(strings.ToLower(query[position:token.Start]))
+			suggestion.WriteString(choice.word)
+			position = token.End
+		}
+		suggestion.WriteString(strings.ToLower(query[position:]))
+
+		text := strings.TrimSpace(suggestion.String())
+		if !seen[text] {
+			seen[text] = true
+			suggestions = append(suggestions, text)
+		}
+	}
+	return suggestions
+}
+
+// editDistance returns the optimal string alignment distance between a and b:
+// the number of insertions, deletions, substitutions and adjacent
+// transpositions needed to turn one into the other. Once the distance is known
+// to exceed limit it returns limit+1 early.
+func editDistance(a, b string, limit int) int {
+	s, t := []rune(a), []rune(b)
+	if diff := len(s) - len(t); diff > limit || -diff > limit {
+		return limit + 1
+	}
+
+	// Three rolling rows: two back (for transpositions), previous and current
+	prevPrev := make([]int, len(t)+1)
+	prev := make([]int, len(t)+1)
+	curr := make([]int, len(t)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(s); i++ {
+		curr[0] = i
+		rowMin := curr[0]
+		for j := 1; j <= len(t); j++ {
+			cost := 1
+			if s[i-1] == t[j-1] {
+				cost = 0
+			}
+			curr[j] = minInt(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && s[i-1] == t[j-2] && s[i-2] == t[j-1] {
+				curr[j] = minInt(curr[j], prevPrev[j-2]+1)
+			}
+			if curr[j] < rowMin {
+				rowMin = curr[j]
+			}
+		}
+		if rowMin > limit {
+			return limit + 1
+		}
+		prevPrev, prev, curr = prev, curr, prevPrev
+	}
+	return prev[len(t)]
+}
+
+// minInt returns the smallest of its arguments
+func minInt(first int, rest ...int) int {
+	smallest := first
+	for _, value := range rest {
+		if value < smallest {
+			smallest = value
+		}
+	}
+	return smallest
+}
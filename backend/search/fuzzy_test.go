@@ -0,0 +1,168 @@
+package search
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b  string
+		limit int
+		want  int
+	}{
+		{"jeans", "jeans", 2, 0},
+		{"jaens", "jeans", 2, 1},   // adjacent transposition
+		{"jeens", "jeans", 2, 1},   // substitution
+		{"jens", "jeans", 2, 1},    // insertion
+		{"jeanss", "jeans", 2, 1},  // deletion
+		{"jackte", "jacket", 2, 1}, // transposition at the end
+		{"jcaket", "jacket", 2, 1},
+		{"shurt", "skirt", 2, 2},
+		{"café", "cafe", 2, 1}, // counted in runes, not bytes
+		{"kitten", "sitting", 3, 3},
+		{"kitten", "sitting", 2, 3}, // over the limit: limit+1
+		{"tee", "sweater", 2, 3},    // lengths too far apart: limit+1
+		{"abcdef", "ghijkl", 2, 3},  // stops early once every row is over the limit
+		{"", "abc", 3, 3},
+	}
+	for _, tt := range tests {
+		if got := editDistance(tt.a, tt.b, tt.limit); got != tt.want {
+			t.Errorf("editDistance(%q, %q, %d) = %d, want %d", tt.a, tt.b, tt.limit, got, tt.want)
+		}
+	}
+}
+
+func TestMaxEditDistance(t *testing.T) {
+	tests := []struct {
+		word string
+		want int
+	}{
+		{"xl", 0},
+		{"tee", 0},
+		{"jean", 1},
+		{"jaens", 1},
+		{"jacket", 2},
+		{"sweatshirt", 2},
+		{"thé", 0}, // three runes, four bytes
+	}
+	for _, tt := range tests {
+		if got := maxEditDistance(tt.word); got != tt.want {
+			t.Errorf("maxEditDistance(%q) = %d, want %d", tt.word, got, tt.want)
+		}
+	}
+}
+
+func TestFuzzyWeight(t *testing.T) {
+	if fuzzyWeight(0) != 1 {
+		t.Errorf("fuzzyWeight(0) = %v, want 1", fuzzyWeight(0))
+	}
+	if !(fuzzyWeight(1) < 1 && fuzzyWeight(2) < fuzzyWeight(1)) {
+		t.Errorf("fuzzyWeight(1) = %v, fuzzyWeight(2) = %v; want both below 1 and decreasing", fuzzyWeight(1), fuzzyWeight(2))
+	}
+}
+
+// newFuzzyTestIndex indexes product names
+func newFuzzyTestIndex(names map[int]string) *Index {
+	idx := NewIndex(Field{Name: "name", Weight: 1})
+	for id, name := range names {
+		idx.Upsert(id, map[string]string{"name": name})
+	}
+	return idx
+}
+
+func TestSearchCorrectsTypos(t *testing.T) {
+	idx := newFuzzyTestIndex(map[int]string{
+		1: "Slim Fit Jeans",
+		2: "Denim Jacket",
+		3: "Running Shoes",
+		4: "Cotton Tee",
+	})
+
+	tests := []struct {
+		name        string
+		query       string
+		hits        []int
+		exactHits   int
+		suggestions []string
+	}{
+		{"transposed letters", "jaens", []int{1}, 0, []string{"jeans"}},
+		{"long word, two edits", "jakcte", []int{2}, 0, []string{"jacket"}},
+		{"several words", "Dneim Jackte", []int{2}, 0, []string{"denim jacket"}},
+		{"query text is kept around corrections", "blue jaens!", []int{1}, 0, []string{"blue jeans!"}},
+		{"exact word keeps its spelling", "slim jaens", []int{1}, 1, nil},
+		{"short words are not corrected", "tea", []int{}, 0, nil},
+		{"too far off", "jxxns", []int{}, 0, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := idx.Search(tt.query)
+			if got := hitIDs(result.Hits); !reflect.DeepEqual(got, tt.hits) {
+				t.Errorf("hits = %v, want %v", got, tt.hits)
+			}
+			if result.ExactHits != tt.exactHits {
+				t.Errorf("ExactHits = %d, want %d", result.ExactHits, tt.exactHits)
+			}
+			if !reflect.DeepEqual(result.Suggestions, tt.suggestions) {
+				t.Errorf("Suggestions = %q, want %q", result.Suggestions, tt.suggestions)
+			}
+		})
+	}
+
+	// Corrected terms are reported for highlighting
+	if terms := idx.Search("jaens").Terms; !reflect.DeepEqual(terms, []string{"jean"}) {
+		t.Errorf("Terms = %v, want [jean]", terms)
+	}
+}
+
+// TestFuzzyMatchesRankBelowExactMatches checks that a corrected word scores less
+// than the same word spelled right
+func TestFuzzyMatchesRankBelowExactMatches(t *testing.T) {
+	idx := newFuzzyTestIndex(map[int]string{
+		1: "Wide Jeans",
+		2: "Slim Chinos",
+	})
+	result := idx.Search("jaens slim")
+	if got := hitIDs(result.Hits); !reflect.DeepEqual(got, []int{2, 1}) {
+		t.Errorf("hits = %+v, want the exact match 2 before the corrected match 1", result.Hits)
+	}
+	if result.ExactHits != 1 {
+		t.Errorf("ExactHits = %d, want 1", result.ExactHits)
+	}
+}
+
+// TestSuggestionsOrder checks that closer and more common corrections come first
+func TestSuggestionsOrder(t *testing.T) {
+	idx := newFuzzyTestIndex(map[int]string{
+		1: "Oxford Shirt",
+		2: "Cargo Board Short",
+		3: "Denim Short",
+		4: "Pleated Skirt",
+	})
+
+	// "short" and "shirt" are one edit away, "short" in more products; "skirt" is two edits away
+	if got, want := idx.Search("shurt").Suggestions, []string{"short", "shirt"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Suggestions for shurt = %q, want %q", got, want)
+	}
+
+	// Each suggestion takes the n-th correction of every misspelled word
+	if got, want := idx.Search("dneim shurt").Suggestions, []string{"denim short", "denim shirt"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Suggestions for dneim shurt = %q, want %q", got, want)
+	}
+}
+
+func TestSuggestionsAreCapped(t *testing.T) {
+	idx := newFuzzyTestIndex(map[int]string{
+		1: "bat", 2: "cat", 3: "hat", 4: "mat", 5: "rat",
+		6: "bats", 7: "cats", 8: "hats", 9: "mats", 10: "rats",
+	})
+	result := idx.Search("xats")
+	if want := []string{"bats", "cats", "hats"}; !reflect.DeepEqual(result.Suggestions, want) {
+		t.Errorf("Suggestions = %q, want %q", result.Suggestions, want)
+	}
+
+	// Only the best corrections are searched, each matching both forms of its stem
+	if got, want := hitIDs(result.Hits), []int{1, 2, 3, 6, 7, 8}; !reflect.DeepEqual(got, want) {
+		t.Errorf("hits = %v, want %v", got, want)
+	}
+}
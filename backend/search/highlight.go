@@ -0,0 +1,94 @@
+package search
+
+import (
+	"html"
+	"strings"
+)
+
+// Markers wrapped around matched words in highlighted snippets
+const (
+	HighlightStart = "<mark>"
+	HighlightEnd   = "</mark>"
+	ellipsis       = "…"
+)
+
+// Highlight returns an HTML-escaped snippet of text with the words matching the
+// query wrapped in <mark> tags. Texts longer than maxLen bytes are cut to a
+// window around the first match (maxLen <= 0 keeps the whole text). The second
+// return value is false when nothing in the text matches.
+func Highlight(text, query string, maxLen int) (string, bool) {
+	return HighlightTerms(text, Analyze(query), maxLen)
+}
+
+// HighlightTerms is like Highlight but takes already analyzed terms, such as
+// Result.Terms, so typo-corrected matches are highlighted too
+func HighlightTerms(text string, terms []string, maxLen int) (string, bool) {
+	queryTerms := make(map[string]bool)
+	for _, term := range terms {
+		queryTerms[term] = true
+	}
+	if len(queryTerms) == 0 {
+		return "", false
+	}
+
+	var matches []Token
+	for _, token := range Tokenize(text) {
+		if queryTerms[token.Term] {
+			matches = append(matches, token)
+		}
+	}
+	if len(matches) == 0 {
+		return "", false
+	}
+
+	windowStart, windowEnd := 0, len(text)
+	if maxLen > 0 && len(text) > maxLen {
+		windowStart = matches[0].Start - maxLen/4
+		if windowStart < 0 {
+			windowStart = 0
+		}
+		windowEnd = windowStart + maxLen
+		if windowEnd > len(text) {
+			windowEnd = len(text)
+			windowStart = windowEnd - maxLen
+		}
+		windowStart, windowEnd = snapToWords(text, windowStart, windowEnd)
+	}
+
+	var snippet strings.Builder
+	if windowStart > 0 {
+		snippet.WriteString(ellipsis)
+	}
+	position := windowStart
+	for _, match := range matches {
+		if match.Start < windowStart || match.End > windowEnd {
+			continue
+		}
+		snippet.WriteString(html.EscapeString(text[position:match.Start]))
+		snippet.WriteString(HighlightStart)
+		snippet.WriteString(html.EscapeString(text[match.Start:match.End]))
+		snippet.WriteString(HighlightEnd)
+		position = match.End
+	}
+	snippet.WriteString(html.EscapeString(text[position:windowEnd]))
+	if windowEnd < len(text) {
+		snippet.WriteString(ellipsis)
+	}
+
+	return snippet.String(), true
+}
+
+// snapToWords moves the window edges to the nearest spaces so words aren't cut in half
+func snapToWords(text string, start, end int) (int, int) {
+	if start > 0 {
+		if space := strings.IndexByte(text[start:end], ' '); space >= 0 {
+			start += space + 1
+		}
+	}
+	if end < len(text) {
+		if space := strings.LastIndexByte(text[start:end], ' '); space > 0 {
+			end = start + space
+		}
+	}
+	return start, end
+}
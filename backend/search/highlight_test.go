@@ -0,0 +1,103 @@
+package search
+
+import "testing"
+
+func TestHighlight(t *testing.T) {
+	long := "This relaxed fit jacket is cut from heavyweight organic cotton canvas and finished with a corduroy collar, " +
+		"brass buttons and two deep patch pockets for everyday wear."
+
+	tests := []struct {
+		name   string
+		text   string
+		query  string
+		maxLen int
+		want   string
+		found  bool
+	}{
+		{
+			name:  "stemmed match keeps original case",
+			text:  "Striped Shirts for Men",
+			query: "shirt",
+			want:  "Striped <mark>Shirts</mark> for Men",
+			found: true,
+		},
+		{
+			name:  "several words",
+			text:  "Slim Fit Jeans",
+			query: "jeans slim",
+			want:  "<mark>Slim</mark> Fit <mark>Jeans</mark>",
+			found: true,
+		},
+		{
+			name:  "escapes HTML",
+			text:  `Tom & Jerry's <b>Tee</b>`,
+			query: "tee",
+			want:  "Tom &amp; Jerry&#39;s &lt;b&gt;<mark>Tee</mark>&lt;/b&gt;",
+			found: true,
+		},
+		{
+			name:  "no match",
+			text:  "Slim Fit Jeans",
+			query: "dress",
+			found: false,
+		},
+		{
+			name:  "only stop words",
+			text:  "The Shirt",
+			query: "the",
+			found: false,
+		},
+		{
+			name:   "short text is not cut",
+			text:   long,
+			query:  "jacket",
+			maxLen: 0,
+			want: "This relaxed fit <mark>jacket</mark> is cut from heavyweight organic cotton canvas and finished with a " +
+				"corduroy collar, brass buttons and two deep patch pockets for everyday wear.",
+			found: true,
+		},
+		{
+			name:   "window around a match in the middle",
+			text:   long,
+			query:  "collar",
+			maxLen: 60,
+			want:   "…a corduroy <mark>collar</mark>, brass buttons and two deep patch…",
+			found:  true,
+		},
+		{
+			name:   "window at the start",
+			text:   long,
+			query:  "relaxed",
+			maxLen: 60,
+			want:   "This <mark>relaxed</mark> fit jacket is cut from heavyweight organic…",
+			found:  true,
+		},
+		{
+			name:   "window at the end",
+			text:   long,
+			query:  "wear",
+			maxLen: 60,
+			want:   "…brass buttons and two deep patch pockets for everyday <mark>wear</mark>.",
+			found:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, found := Highlight(tt.text, tt.query, tt.maxLen)
+			if found != tt.found || got != tt.want {
+				t.Errorf("Highlight = %q, %v; want %q, %v", got, found, tt.want, tt.found)
+			}
+		})
+	}
+}
+
+func TestHighlightTerms(t *testing.T) {
+	// Result.Terms carries stems, including typo corrections
+	got, found := HighlightTerms("Classic Denim Jacket", []string{"denim"}, 0)
+	if want := "Classic <mark>Denim</mark> Jacket"; !found || got != want {
+		t.Errorf("HighlightTerms = %q, %v; want %q", got, found, want)
+	}
+	if _, found := HighlightTerms("Classic Denim Jacket", nil, 0); found {
+		t.Error("HighlightTerms without terms found a match")
+	}
+}
@@ -0,0 +1,265 @@
+package search
+
+import (
+	"math"
+	"sort"
+	"sync"
+)
+
+// BM25 tuning parameters
+const (
+	// DefaultK1 controls term frequency saturation
+	DefaultK1 = 1.2
+	// DefaultB controls how strongly scores are normalized by field length
+	DefaultB = 0.75
+)
+
+// Field declares an indexed field and its weight in the combined score
+type Field struct {
+	Name   string
+	Weight float64
+}
+
+// Hit is a single search result
+type Hit struct {
+	ID    int     `json:"id"`
+	Score float64 `json:"score"`
+}
+
+// Result is the outcome of a search
+type Result struct {
+	// Hits are the matching documents, best match first
+	Hits []Hit
+	// ExactHits counts the documents matched without typo correction
+	ExactHits int
+	// Terms are the index terms that matched, including typo corrections,
+	// for highlighting
+	Terms []string
+	// Suggestions are corrected queries, offered when nothing matched exactly
+	Suggestions []string
+}
+
+// Index is an in-memory inverted index scored with BM25. Each field is scored
+// separately and the per-field scores are combined using the field weights, so
+// a match in a heavier field (e.g. a product name) ranks above the same match
+// in a lighter one. Query words that are not in the index are expanded to
+// similarly spelled vocabulary words (see fuzzy.go). It is safe for concurrent use.
+type Index struct {
+	mu       sync.RWMutex
+	fields   []Field
+	k1       float64
+	b        float64
+	postings map[string]map[int][]int // term -> document ID -> frequency per field
+	docTerms map[int][]string         // document ID -> distinct terms, for removal
+	docWords map[int][]string         // document ID -> distinct surface words, for removal
+	docLens  map[int][]int            // document ID -> token count per field
+	totalLen []int                    // sum of field lengths across all documents
+	vocab    map[string]*vocabEntry   // surface word -> stem and document frequency
+}
+
+// vocabEntry records a surface word seen in indexed documents
+type vocabEntry struct {
+	term string
+	docs int
+}
+
+// NewIndex creates an empty index over the given fields
+func NewIndex(fields ...Field) *Index {
+	return &Index{
+		fields:   fields,
+		k1:       DefaultK1,
+		b:        DefaultB,
+		postings: make(map[string]map[int][]int),
+		docTerms: make(map[int][]string),
+		docWords: make(map[int][]string),
+		docLens:  make(map[int][]int),
+		totalLen: make([]int, len(fields)),
+		vocab:    make(map[string]*vocabEntry),
+	}
+}
+
+// Upsert indexes a document, replacing any previous version with the same ID.
+// values maps field names to their text; unknown fields are ignored.
+func (idx *Index) Upsert(id int, values map[string]string) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	idx.remove(id)
+
+	lengths := make([]int, len(idx.fields))
+	var terms []string
+	seenWords := make(map[string]bool)
+	var words []string
+	for f, field := range idx.fields {
+		for _, token := range Tokenize(values[field.Name]) {
+			docs := idx.postings[token.Term]
+			if docs == nil {
+				docs = make(map[int][]int)
+				idx.postings[token.Term] = docs
+			}
+			freqs := docs[id]
+			if freqs == nil {
+				freqs = make([]int, len(idx.fields))
+				docs[id] = freqs
+				terms = append(terms, token.Term)
+			}
+			freqs[f]++
+			lengths[f]++
+
+			if !seenWords[token.Word] {
+				seenWords[token.Word] = true
+				words = append(words, token.Word)
+				entry := idx.vocab[token.Word]
+				if entry == nil {
+					entry = &vocabEntry{term: token.Term}
+					idx.vocab[token.Word] = entry
+				}
+				entry.docs++
+			}
+		}
+		idx.totalLen[f] += lengths[f]
+	}
+
+	idx.docTerms[id] = terms
+	idx.docWords[id] = words
+	idx.docLens[id] = lengths
+}
+
+// Remove deletes a document from the index
+func (idx *Index) Remove(id int) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	idx.remove(id)
+}
+
+// remove deletes a document; callers must hold the write lock
+func (idx *Index) remove(id int) {
+	lengths, exists := idx.docLens[id]
+	if !exists {
+		return
+	}
+
+	for _, term := range idx.docTerms[id] {
+		docs := idx.postings[term]
+		delete(docs, id)
+		if len(docs) == 0 {
+			delete(idx.postings, term)
+		}
+	}
+	for _, word := range idx.docWords[id] {
+		if entry := idx.vocab[word]; entry != nil {
+			entry.docs--
+			if entry.docs <= 0 {
+				delete(idx.vocab, word)
+			}
+		}
+	}
+	for f, length := range lengths {
+		idx.totalLen[f] -= length
+	}
+	delete(idx.docTerms, id)
+	delete(idx.docWords, id)
+	delete(idx.docLens, id)
+}
+
+// Len returns the number of indexed documents
+func (idx *Index) Len() int {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	return len(idx.docLens)
+}
+
+// Search returns the documents matching any query word. Words missing from the
+// index are matched against similarly spelled vocabulary words at a reduced
+// weight. Ties are broken by ascending ID so results are stable.
+func (idx *Index) Search(query string) Result {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	var result Result
+	docCount := len(idx.docLens)
+	if docCount == 0 {
+		return result
+	}
+
+	tokens := Tokenize(query)
+	exactScores := make(map[int]float64)
+	fuzzyScores := make(map[int]float64)
+	matchedTerms := make(map[string]bool)
+	var corrections [][]correction
+
+	for _, token := range tokens {
+		if _, exists := idx.postings[token.Term]; exists {
+			if !matchedTerms[token.Term] {
+				matchedTerms[token.Term] = true
+				idx.score(token.Term, 1, exactScores)
+			}
+			corrections = append(corrections, nil)
+			continue
+		}
+
+		candidates := idx.corrections(token.Word)
+		corrections = append(corrections, candidates)
+		for _, candidate := range candidates {
+			if matchedTerms[candidate.term] {
+				continue
+			}
+			matchedTerms[candidate.term] = true
+			idx.score(candidate.term, fuzzyWeight(candidate.distance), fuzzyScores)
+		}
+	}
+
+	result.ExactHits = len(exactScores)
+	for id, score := range fuzzyScores {
+		exactScores[id] += score
+	}
+
+	result.Hits = make([]Hit, 0, len(exactScores))
+	for id, score := range exactScores {
+		result.Hits = append(result.Hits, Hit{ID: id, Score: score})
+	}
+	sort.Slice(result.Hits, func(i, j int) bool {
+		if result.Hits[i].Score != result.Hits[j].Score {
+			return result.Hits[i].Score > result.Hits[j].Score
+		}
+		return result.Hits[i].ID < result.Hits[j].ID
+	})
+
+	for term := range matchedTerms {
+		result.Terms = append(result.Terms, term)
+	}
+	sort.Strings(result.Terms)
+
+	if result.ExactHits == 0 {
+		result.Suggestions = buildSuggestions(query, tokens, corrections)
+	}
+	return result
+}
+
+// score adds the weighted BM25 contribution of term to scores.
+// Callers must hold the read lock.
+func (idx *Index) score(term string, weight float64, scores map[int]float64) {
+	docs := idx.postings[term]
+	if len(docs) == 0 {
+		return
+	}
+
+	docCount := float64(len(idx.docLens))
+	df := float64(len(docs))
+	idf := math.Log(1 + (docCount-df+0.5)/(df+0.5))
+
+	for id, freqs := range docs {
+		lengths := idx.docLens[id]
+		for f, field := range idx.fields {
+			tf := float64(freqs[f])
+			if tf == 0 || idx.totalLen[f] == 0 {
+				continue
+			}
+			avgLen := float64(idx.totalLen[f]) / docCount
+			norm := 1 - idx.b + idx.b*float64(lengths[f])/avgLen
+			scores[id] += weight * field.Weight * idf * tf * (idx.k1 + 1) / (tf + idx.k1*norm)
+		}
+	}
+}
@@ -0,0 +1,247 @@
+package search
+
+import (
+	"math"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// hitIDs returns the IDs of hits in order
+func hitIDs(hits []Hit) []int {
+	ids := make([]int, len(hits))
+	for i, hit := range hits {
+		ids[i] = hit.ID
+	}
+	return ids
+}
+
+func TestSearchBM25Score(t *testing.T) {
+	idx := NewIndex(Field{Name: "text", Weight: 1})
+	idx.Upsert(1, map[string]string{"text": "apple apple banana"})
+	idx.Upsert(2, map[string]string{"text": "cherry"})
+
+	result := idx.Search("apple")
+	if len(result.Hits) != 1 || result.Hits[0].ID != 1 {
+		t.Fatalf("hits = %+v, want only document 1", result.Hits)
+	}
+
+	// One of two documents has the term twice in a field of 3 tokens (average 2)
+	idf := math.Log(1 + (2-1+0.5)/(1+0.5))
+	norm := 1 - DefaultB + DefaultB*3/2
+	want := idf * 2 * (DefaultK1 + 1) / (2 + DefaultK1*norm)
+	if got := result.Hits[0].Score; math.Abs(got-want) > 1e-9 {
+		t.Errorf("score = %v, want %v", got, want)
+	}
+}
+
+func TestSearchRanking(t *testing.T) {
+	tests := []struct {
+		name  string
+		docs  map[int]map[string]string
+		query string
+		want  []int
+	}{
+		{
+			name: "name outweighs description",
+			docs: map[int]map[string]string{
+				1: {"name": "soft cotton", "description": "red shirt"},
+				2: {"name": "red shirt", "description": "soft cotton"},
+			},
+			query: "red",
+			want:  []int{2, 1},
+		},
+		{
+			name: "shorter field ranks higher",
+			docs: map[int]map[string]string{
+				1: {"name": "shirt with long sleeves and buttons"},
+				2: {"name": "shirt"},
+				3: {"name": "jeans"},
+			},
+			query: "shirt",
+			want:  []int{2, 1},
+		},
+		{
+			name: "more occurrences rank higher",
+			docs: map[int]map[string]string{
+				1: {"description": "linen blend"},
+				2: {"description": "linen linen"},
+				3: {"description": "wool blend"},
+			},
+			query: "linen",
+			want:  []int{2, 1},
+		},
+		{
+			name: "rare terms count more",
+			docs: map[int]map[string]string{
+				1: {"name": "black shirt"},
+				2: {"name": "linen pants"},
+				3: {"name": "black jeans"},
+				4: {"name": "black dress"},
+			},
+			query: "black linen",
+			want:  []int{2, 1, 3, 4},
+		},
+		{
+			name: "ties by ID",
+			docs: map[int]map[string]string{
+				3: {"name": "denim jacket"},
+				1: {"name": "denim jacket"},
+				2: {"name": "denim jacket"},
+			},
+			query: "jacket",
+			want:  []int{1, 2, 3},
+		},
+		{
+			name: "stemmed and stop words dropped",
+			docs: map[int]map[string]string{
+				1: {"name": "Running Shoes"},
+				2: {"name": "The Shirt"},
+			},
+			query: "the shirts",
+			want:  []int{2},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			idx := NewIndex(Field{Name: "name", Weight: 3}, Field{Name: "description", Weight: 1})
+			for id, values := range tt.docs {
+				idx.Upsert(id, values)
+			}
+			result := idx.Search(tt.query)
+			if got := hitIDs(result.Hits); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Search(%q) = %v, want %v (hits %+v)", tt.query, got, tt.want, result.Hits)
+			}
+			if result.ExactHits != len(tt.want) {
+				t.Errorf("ExactHits = %d, want %d", result.ExactHits, len(tt.want))
+			}
+		})
+	}
+}
+
+func TestSearchResultTerms(t *testing.T) {
+	idx := NewIndex(Field{Name: "name", Weight: 1})
+	idx.Upsert(1, map[string]string{"name": "Striped Shirts"})
+
+	result := idx.Search("shirt striped shirt jumper")
+	if want := []string{"shirt", "stripe"}; !reflect.DeepEqual(result.Terms, want) {
+		t.Errorf("Terms = %v, want %v", result.Terms, want)
+	}
+	if len(result.Suggestions) != 0 {
+		t.Errorf("Suggestions = %v, want none when something matched", result.Suggestions)
+	}
+
+	if empty := NewIndex(Field{Name: "name", Weight: 1}).Search("shirt"); len(empty.Hits) != 0 || empty.ExactHits != 0 {
+		t.Errorf("search of an empty index = %+v", empty)
+	}
+}
+
+// checkBookkeeping compares the index's internal counters with the documents it should hold
+func checkBookkeeping(t *testing.T, idx *Index, docs map[int]map[string]string) {
+	t.Helper()
+
+	wantLen := make([]int, len(idx.fields))
+	wantVocab := make(map[string]int)
+	wantTerms := make(map[string]map[int]bool)
+	for id, values := range docs {
+		seen := make(map[string]bool)
+		for f, field := range idx.fields {
+			tokens := Tokenize(values[field.Name])
+			wantLen[f] += len(tokens)
+			for _, token := range tokens {
+				if !seen[token.Word] {
+					seen[token.Word] = true
+					wantVocab[token.Word]++
+				}
+				if wantTerms[token.Term] == nil {
+					wantTerms[token.Term] = make(map[int]bool)
+				}
+				wantTerms[token.Term][id] = true
+			}
+		}
+	}
+
+	if idx.Len() != len(docs) {
+		t.Errorf("Len = %d, want %d", idx.Len(), len(docs))
+	}
+	if !reflect.DeepEqual(idx.totalLen, wantLen) {
+		t.Errorf("totalLen = %v, want %v", idx.totalLen, wantLen)
+	}
+	gotVocab := make(map[string]int)
+	for word, entry := range idx.vocab {
+		gotVocab[word] = entry.docs
+	}
+	if !reflect.DeepEqual(gotVocab, wantVocab) {
+		t.Errorf("vocab document counts = %v, want %v", gotVocab, wantVocab)
+	}
+	gotTerms := make(map[string]map[int]bool)
+	for term, postings := range idx.postings {
+		gotTerms[term] = make(map[int]bool)
+		for id := range postings {
+			gotTerms[term][id] = true
+		}
+	}
+	if !reflect.DeepEqual(gotTerms, wantTerms) {
+		t.Errorf("postings = %v, want %v", gotTerms, wantTerms)
+	}
+}
+
+func TestUpsertAndRemoveBookkeeping(t *testing.T) {
+	idx := NewIndex(Field{Name: "name", Weight: 3}, Field{Name: "description", Weight: 1})
+	docs := map[int]map[string]string{
+		1: {"name": "Black Shirt", "description": "A black cotton shirt"},
+		2: {"name": "Blue Jeans", "description": "Shirt not included"},
+	}
+	for id, values := range docs {
+		idx.Upsert(id, values)
+	}
+	checkBookkeeping(t, idx, docs)
+	if docs := idx.vocab["shirt"].docs; docs != 2 {
+		t.Errorf(`vocab["shirt"] is in %d documents, want 2 (each document counts once)`, docs)
+	}
+
+	// Replacing a document drops its old words
+	docs[1] = map[string]string{"name": "Linen Dress", "description": "Summer dress"}
+	idx.Upsert(1, docs[1])
+	checkBookkeeping(t, idx, docs)
+	if hits := idx.Search("black").Hits; len(hits) != 0 {
+		t.Errorf("search for a replaced word = %+v, want no hits", hits)
+	}
+
+	idx.Remove(2)
+	delete(docs, 2)
+	checkBookkeeping(t, idx, docs)
+
+	// Removing an unknown document is a no-op
+	idx.Remove(42)
+	checkBookkeeping(t, idx, docs)
+
+	idx.Remove(1)
+	delete(docs, 1)
+	checkBookkeeping(t, idx, docs)
+	if len(idx.postings) != 0 || len(idx.vocab) != 0 || len(idx.docTerms) != 0 || len(idx.docWords) != 0 {
+		t.Errorf("empty index still has postings %v, vocab %v", idx.postings, idx.vocab)
+	}
+}
+
+func TestTokenize(t *testing.T) {
+	text := "The Men's T-Shirts, size XL"
+	got := Tokenize(text)
+	want := []Token{
+		{Word: "men", Term: "men", Start: 4, End: 7},
+		{Word: "s", Term: "s", Start: 8, End: 9},
+		{Word: "t", Term: "t", Start: 10, End: 11},
+		{Word: "shirts", Term: "shirt", Start: 12, End: 18},
+		{Word: "size", Term: "size", Start: 20, End: 24},
+		{Word: "xl", Term: "xl", Start: 25, End: 27},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Tokenize(%q) =\n%+v\nwant\n%+v", text, got, want)
+	}
+
+	terms := Analyze("Dresses and Skirts")
+	sort.Strings(terms)
+	if want := []string{"dress", "skirt"}; !reflect.DeepEqual(terms, want) {
+		t.Errorf("Analyze = %v, want %v", terms, want)
+	}
+}
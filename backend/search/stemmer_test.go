@@ -0,0 +1,110 @@
+package search
+
+import "testing"
+
+// TestStem checks words from Porter's paper and the reference vocabulary, one
+// or more per rule, plus the words Stem leaves alone
+func TestStem(t *testing.T) {
+	tests := []struct {
+		word string
+		want string
+	}{
+		// Step 1a: plurals
+		{"caresses", "caress"},
+		{"ponies", "poni"},
+		{"ties", "ti"},
+		{"caress", "caress"},
+		{"cats", "cat"},
+
+		// Step 1b: -eed, -ed, -ing
+		{"feed", "feed"},
+		{"agreed", "agre"},
+		{"plastered", "plaster"},
+		{"bled", "bled"},
+		{"motoring", "motor"},
+		{"sing", "sing"},
+		{"conflated", "conflat"},
+		{"troubled", "troubl"},
+		{"sized", "size"},
+		{"hopping", "hop"},
+		{"tanned", "tan"},
+		{"falling", "fall"},
+		{"hissing", "hiss"},
+		{"fizzed", "fizz"},
+		{"failing", "fail"},
+		{"filing", "file"},
+
+		// Step 1c: y to i
+		{"happy", "happi"},
+		{"sky", "sky"},
+
+		// Step 2: double suffixes
+		{"relational", "relat"},
+		{"conditional", "condit"},
+		{"rational", "ration"},
+		{"digitizer", "digit"},
+		{"vietnamization", "vietnam"},
+		{"predication", "predic"},
+		{"operator", "oper"},
+		{"feudalism", "feudal"},
+		{"decisiveness", "decis"},
+		{"hopefulness", "hope"},
+		{"callousness", "callous"},
+
+		// Step 3: -ic-, -full, -ness and friends
+		{"triplicate", "triplic"},
+		{"formative", "form"},
+		{"formalize", "formal"},
+		{"electrical", "electr"},
+		{"hopeful", "hope"},
+		{"goodness", "good"},
+
+		// Step 4: suffixes removed when m > 1
+		{"revival", "reviv"},
+		{"allowance", "allow"},
+		{"inference", "infer"},
+		{"airliner", "airlin"},
+		{"gyroscopic", "gyroscop"},
+		{"adjustable", "adjust"},
+		{"defensible", "defens"},
+		{"irritant", "irrit"},
+		{"replacement", "replac"},
+		{"adjustment", "adjust"},
+		{"dependent", "depend"},
+		{"adoption", "adopt"},
+		{"communism", "commun"},
+		{"activate", "activ"},
+		{"homologous", "homolog"},
+		{"effective", "effect"},
+		{"bowdlerize", "bowdler"},
+
+		// Step 5: final -e and -ll
+		{"probate", "probat"},
+		{"rate", "rate"},
+		{"cease", "ceas"},
+		{"controlling", "control"},
+		{"roll", "roll"},
+
+		// Several steps
+		{"generalizations", "gener"},
+		{"oscillators", "oscil"},
+
+		// Catalog words
+		{"shirts", "shirt"},
+		{"jeans", "jean"},
+		{"running", "run"},
+		{"dresses", "dress"},
+
+		// Left alone: short words and anything but a-z
+		{"is", "is"},
+		{"xs", "xs"},
+		{"501s", "501s"},
+		{"t-shirts", "t-shirts"},
+		{"café", "café"},
+	}
+	for _, tt := range tests {
+		if got := Stem(tt.word); got != tt.want {
+			t.Errorf("Stem(%q) = %q, want %q", tt.word, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,243 @@
+package search
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// Entry is a phrase a document contributes to a Trie, such as a product name
+type Entry struct {
+	Kind string
+	Text string
+}
+
+// Completion is a phrase that starts with, or has a word starting with, a prefix
+type Completion struct {
+	Text  string `json:"text"`
+	Kind  string `json:"kind"`
+	Count int    `json:"count"`
+}
+
+// Trie is a prefix tree of phrases used for type-ahead completion. Every phrase
+// is reachable from the start of each of its words, so "jea" completes
+// "Slim Fit Denim Jeans". Completions are ranked by whether the prefix matches
+// the start of the phrase, then by how many documents contain the phrase.
+// It is safe for concurrent use.
+type Trie struct {
+	mu      sync.RWMutex
+	root    *trieNode
+	phrases map[string]*triePhrase // kind + lower-cased text -> phrase
+	docKeys map[int][]string       // document ID -> phrase keys, for removal
+}
+
+// trieNode is one character in the tree. leaves holds the phrases whose
+// suffix ends here, mapped to whether that suffix is the whole phrase.
+type trieNode struct {
+	children map[rune]*trieNode
+	leaves   map[string]bool
+}
+
+// triePhrase is a distinct phrase and the documents containing it
+type triePhrase struct {
+	kind string
+	text string
+	docs map[int]bool
+}
+
+// NewTrie creates an empty Trie
+func NewTrie() *Trie {
+	return &Trie{
+		root:    newTrieNode(),
+		phrases: make(map[string]*triePhrase),
+		docKeys: make(map[int][]string),
+	}
+}
+
+// newTrieNode creates an empty node
+func newTrieNode() *trieNode {
+	return &trieNode{children: make(map[rune]*trieNode), leaves: make(map[string]bool)}
+}
+
+// Upsert records the phrases of a document, replacing any previous ones with the same ID
+func (t *Trie) Upsert(id int, entries []Entry) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.remove(id)
+
+	var keys []string
+	for _, entry := range entries {
+		text := strings.Join(strings.Fields(entry.Text), " ")
+		if text == "" {
+			continue
+		}
+		key := entry.Kind + "\x00" + strings.ToLower(text)
+		phrase := t.phrases[key]
+		if phrase == nil {
+			phrase = &triePhrase{kind: entry.Kind, text: text, docs: make(map[int]bool)}
+			t.phrases[key] = phrase
+			t.link(key, text)
+		}
+		if !phrase.docs[id] {
+			phrase.docs[id] = true
+			keys = append(keys, key)
+		}
+	}
+	t.docKeys[id] = keys
+}
+
+// Remove deletes a document's phrases
+func (t *Trie) Remove(id int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.remove(id)
+}
+
+// remove deletes a document's phrases; callers must hold the write lock
+func (t *Trie) remove(id int) {
+	for _, key := range t.docKeys[id] {
+		phrase := t.phrases[key]
+		if phrase == nil {
+			continue
+		}
+		delete(phrase.docs, id)
+		if len(phrase.docs) == 0 {
+			delete(t.phrases, key)
+			t.unlink(key, phrase.text)
+		}
+	}
+	delete(t.docKeys, id)
+}
+
+// link inserts the phrase under every word start; callers must hold the write lock
+func (t *Trie) link(key, text string) {
+	lower := strings.ToLower(text)
+	for _, offset := range wordStarts(lower) {
+		node := t.root
+		for _, r := range lower[offset:] {
+			child := node.children[r]
+			if child == nil {
+				child = newTrieNode()
+				node.children[r] = child
+			}
+			node = child
+		}
+		node.leaves[key] = offset == 0
+	}
+}
+
+// unlink removes the phrase from every word start and prunes empty branches.
+// Callers must hold the write lock.
+func (t *Trie) unlink(key, text string) {
+	lower := strings.ToLower(text)
+	for _, offset := range wordStarts(lower) {
+		path := []*trieNode{t.root}
+		runes := []rune(lower[offset:])
+		for _, r := range runes {
+			next := path[len(path)-1].children[r]
+			if next == nil {
+				break
+			}
+			path = append(path, next)
+		}
+		if len(path) != len(runes)+1 {
+			continue
+		}
+		delete(path[len(path)-1].leaves, key)
+		for i := len(path) - 1; i > 0; i-- {
+			node := path[i]
+			if len(node.leaves) > 0 || len(node.children) > 0 {
+				break
+			}
+			delete(path[i-1].children, runes[i-1])
+		}
+	}
+}
+
+// Complete returns up to limit completions of prefix, best first. Matching is
+// case-insensitive and a blank prefix returns nothing.
+func (t *Trie) Complete(prefix string, limit int) []Completion {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	prefix = strings.ToLower(strings.Join(strings.Fields(prefix), " "))
+	if prefix == "" || limit <= 0 {
+		return []Completion{}
+	}
+
+	node := t.root
+	for _, r := range prefix {
+		node = node.children[r]
+		if node == nil {
+			return []Completion{}
+		}
+	}
+
+	// A phrase may be reachable through several of its words; keep its best match
+	leading := make(map[string]bool)
+	var collect func(n *trieNode)
+	collect = func(n *trieNode) {
+		for key, atStart := range n.leaves {
+			leading[key] = leading[key] || atStart
+		}
+		for _, child := range n.children {
+			collect(child)
+		}
+	}
+	collect(node)
+
+	type candidate struct {
+		Completion
+		atStart bool
+	}
+	candidates := make([]candidate, 0, len(leading))
+	for key, atStart := range leading {
+		phrase := t.phrases[key]
+		candidates = append(candidates, candidate{
+			Completion: Completion{Text: phrase.text, Kind: phrase.kind, Count: len(phrase.docs)},
+			atStart:    atStart,
+		})
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.atStart != b.atStart {
+			return a.atStart
+		}
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		if len(a.Text) != len(b.Text) {
+			return len(a.Text) < len(b.Text)
+		}
+		if a.Text != b.Text {
+			return a.Text < b.Text
+		}
+		return a.Kind < b.Kind
+	})
+
+	if len(candidates) > limit {
+		candidates = candidates[:limit]
+	}
+	completions := make([]Completion, len(candidates))
+	for i, c := range candidates {
+		completions[i] = c.Completion
+	}
+	return completions
+}
+
+// wordStarts returns the byte offsets at which words begin in text
+func wordStarts(text string) []int {
+	var starts []int
+	inWord := false
+	for i, r := range text {
+		isWordChar := unicode.IsLetter(r) || unicode.IsDigit(r)
+		if isWordChar && !inWord {
+			starts = append(starts, i)
+		}
+		inWord = isWordChar
+	}
+	return starts
+}
@@ -0,0 +1,117 @@
+package search
+
+import (
+	"reflect"
+	"testing"
+)
+
+// completionTexts returns the text of each completion in order
+func completionTexts(completions []Completion) []string {
+	texts := make([]string, len(completions))
+	for i, c := range completions {
+		texts[i] = c.Text
+	}
+	return texts
+}
+
+// countNodes returns the number of nodes below n
+func countNodes(n *trieNode) int {
+	count := 0
+	for _, child := range n.children {
+		count += 1 + countNodes(child)
+	}
+	return count
+}
+
+func TestTrieComplete(t *testing.T) {
+	trie := NewTrie()
+	trie.Upsert(1, []Entry{{Kind: "product", Text: "Slim Fit Denim Jeans"}, {Kind: "category", Text: "Jeans"}})
+	trie.Upsert(2, []Entry{{Kind: "product", Text: "Relaxed Jeans"}, {Kind: "category", Text: "Jeans"}})
+	trie.Upsert(3, []Entry{{Kind: "product", Text: "Denim Jacket"}, {Kind: "category", Text: "Jackets"}})
+	trie.Upsert(4, []Entry{{Kind: "product", Text: "Jean-Paul  Tee"}, {Kind: "category", Text: "T-Shirts"}})
+
+	tests := []struct {
+		name   string
+		prefix string
+		limit  int
+		want   []string
+	}{
+		{"leading matches first, then by count", "jea", 10, []string{"Jeans", "Jean-Paul Tee", "Relaxed Jeans", "Slim Fit Denim Jeans"}},
+		{"mid-phrase word", "fit", 10, []string{"Slim Fit Denim Jeans"}},
+		{"mid-phrase prefix spanning words", "denim ja", 10, []string{"Denim Jacket"}},
+		{"leading match beats a more common mid-phrase one", "den", 10, []string{"Denim Jacket", "Slim Fit Denim Jeans"}},
+		{"case and spacing are ignored", "  SLIM   fit ", 10, []string{"Slim Fit Denim Jeans"}},
+		{"word after punctuation", "shirt", 10, []string{"T-Shirts"}},
+		{"limit", "j", 2, []string{"Jeans", "Jackets"}},
+		{"no match", "dress", 10, []string{}},
+		{"not a word start", "eans", 10, []string{}},
+		{"blank prefix", "   ", 10, []string{}},
+		{"zero limit", "jea", 0, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := completionTexts(trie.Complete(tt.prefix, tt.limit)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Complete(%q, %d) = %q, want %q", tt.prefix, tt.limit, got, tt.want)
+			}
+		})
+	}
+
+	// Kind and count are reported, and a phrase is listed once however many of its words match
+	got := trie.Complete("jeans", 10)
+	want := []Completion{
+		{Text: "Jeans", Kind: "category", Count: 2},
+		{Text: "Relaxed Jeans", Kind: "product", Count: 1},
+		{Text: "Slim Fit Denim Jeans", Kind: "product", Count: 1},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Complete(jeans) = %+v, want %+v", got, want)
+	}
+}
+
+func TestTrieRemoveAndUpsertPrune(t *testing.T) {
+	trie := NewTrie()
+	trie.Upsert(1, []Entry{{Kind: "product", Text: "Slim Fit Jeans"}, {Kind: "category", Text: "Jeans"}})
+	trie.Upsert(2, []Entry{{Kind: "product", Text: "Denim Jacket"}, {Kind: "category", Text: "Jeans"}})
+
+	// A phrase shared by another document stays until its last document goes
+	trie.Remove(1)
+	if got := trie.Complete("jeans", 10); !reflect.DeepEqual(got, []Completion{{Text: "Jeans", Kind: "category", Count: 1}}) {
+		t.Errorf("after removing 1, Complete(jeans) = %+v", got)
+	}
+	if got := trie.Complete("slim", 10); len(got) != 0 {
+		t.Errorf("after removing 1, Complete(slim) = %+v, want nothing", got)
+	}
+
+	// Replacing a document's phrases prunes the old branches
+	trie.Upsert(2, []Entry{{Kind: "product", Text: "Wool Coat"}})
+	if got := trie.Complete("j", 10); len(got) != 0 {
+		t.Errorf("after replacing 2, Complete(j) = %+v, want nothing", got)
+	}
+	fresh := NewTrie()
+	fresh.Upsert(2, []Entry{{Kind: "product", Text: "Wool Coat"}})
+	if got, want := countNodes(trie.root), countNodes(fresh.root); got != want {
+		t.Errorf("after replacing 2, trie has %d nodes, want %d", got, want)
+	}
+
+	// Removing an unknown document is a no-op
+	trie.Remove(42)
+
+	trie.Remove(2)
+	if len(trie.root.children) != 0 || len(trie.root.leaves) != 0 {
+		t.Errorf("empty trie still has %d nodes", countNodes(trie.root))
+	}
+	if len(trie.phrases) != 0 || len(trie.docKeys) != 0 {
+		t.Errorf("empty trie still has phrases %v, documents %v", trie.phrases, trie.docKeys)
+	}
+}
+
+func TestTrieUpsertCountsDocumentsOnce(t *testing.T) {
+	trie := NewTrie()
+	trie.Upsert(1, []Entry{{Kind: "category", Text: "Jeans"}, {Kind: "category", Text: "jeans"}})
+	trie.Upsert(1, []Entry{{Kind: "category", Text: "Jeans"}})
+	trie.Upsert(2, []Entry{{Kind: "category", Text: ""}})
+
+	if got := trie.Complete("jeans", 10); !reflect.DeepEqual(got, []Completion{{Text: "Jeans", Kind: "category", Count: 1}}) {
+		t.Errorf("Complete(jeans) = %+v, want Jeans in one document", got)
+	}
+}
@@ -0,0 +1,605 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"ecommerce-backend/logger"
+	"ecommerce-backend/models"
+	"ecommerce-backend/repository"
+)
+
+// ErrCartNotFound is returned when a requested cart does not exist
+var ErrCartNotFound = repository.ErrCartNotFound
+
+// ErrCartItemNotFound is returned when removing a line that is not in the cart
+var ErrCartItemNotFound = errors.New("cart item not found")
+
+// CartService manages shopping carts. Carts only store product IDs, sizes,
+// colors and quantities; every read prices them against the current catalog,
+// so shoppers always see current prices and availability. Guest carts can be
+// used by anyone with their ID, user carts only by their user. Changes lock
+// only the carts they touch, so a slow checkout doesn't hold up other shoppers.
+type CartService struct {
+	locks    keyedMutex
+	repo     repository.CartRepository
+	products *ProductService
+}
+
+// NewCartService creates a CartService that prices carts with the given catalog
+func NewCartService(repo repository.CartRepository, products *ProductService) *CartService {
+	return &CartService{
+		repo:     repo,
+		products: products,
+	}
+}
+
+// CreateCart starts a new empty cart with a random, unguessable ID
+func (cs *CartService) CreateCart(ctx context.Context) (models.PricedCart, error) {
+	start := time.Now()
+	logger.LogServiceCallContext(ctx, "CartService", "CreateCart", map[string]interface{}{})
+
+	id, err := newRandomID()
+	if err != nil {
+		logger.LogErrorContext(ctx, "CartService", "CreateCart", err, nil)
+		return models.PricedCart{}, err
+	}
+	now := time.Now().UTC()
+	cart := models.Cart{ID: id, Items: []models.CartItem{}, CreatedAt: now, UpdatedAt: now}
+	if err := cs.repo.Save(cart); err != nil {
+		logger.LogErrorContext(ctx, "CartService", "CreateCart", err, nil)
+		return models.PricedCart{}, err
+	}
+
+	duration := float64(time.Since(start).Nanoseconds()) / 1e6
+	logger.LogServiceResultContext(ctx, "CartService", "CreateCart", 1, duration)
+
+	logger.InfoContext(ctx, "Cart created", map[string]interface{}{
+		"cart_id": id,
+	})
+
+	return cs.price(ctx, cart)
+}
+
+// GetCart returns a cart priced against the current catalog. A cart that
+// belongs to another user than userID is reported as ErrCartNotFound.
+func (cs *CartService) GetCart(ctx context.Context, id, userID string) (models.PricedCart, error) {
+	defer cs.locks.lock(cartLockKey(id))()
+
+	start := time.Now()
+	params := map[string]interface{}{"cart_id": id}
+	logger.LogServiceCallContext(ctx, "CartService", "GetCart", params)
+
+	cart, err := cs.ownedCart(ctx, id, userID)
+	if err != nil {
+		if !errors.Is(err, ErrCartNotFound) {
+			logger.LogErrorContext(ctx, "CartService", "GetCart", err, params)
+		}
+		return models.PricedCart{}, err
+	}
+
+	priced, err := cs.price(ctx, *cart)
+	if err != nil {
+		logger.LogErrorContext(ctx, "CartService", "GetCart", err, params)
+		return models.PricedCart{}, err
+	}
+
+	duration := float64(time.Since(start).Nanoseconds()) / 1e6
+	logger.LogServiceResultContext(ctx, "CartService", "GetCart", len(priced.Items), duration)
+
+	return priced, nil
+}
+
+// SetItem sets the quantity of a cart line, adding the line if needed.
+// A quantity of zero removes the line.
+func (cs *CartService) SetItem(ctx context.Context, id, userID string, item models.CartItem) (models.PricedCart, error) {
+	defer cs.locks.lock(cartLockKey(id))()
+
+	start := time.Now()
+	params := map[string]interface{}{
+		"cart_id":    id,
+		"product_id": item.ProductID,
+		"size":       item.Size,
+		"color":      item.Color,
+		"quantity":   item.Quantity,
+	}
+	logger.LogServiceCallContext(ctx, "CartService", "SetItem", params)
+
+	cart, err := cs.ownedCart(ctx, id, userID)
+	if err != nil {
+		if !errors.Is(err, ErrCartNotFound) {
+			logger.LogErrorContext(ctx, "CartService", "SetItem", err, params)
+		}
+		return models.PricedCart{}, err
+	}
+
+	index, exists := cart.FindItem(item)
+	if item.Quantity == 0 {
+		if exists {
+			cart.Items = append(cart.Items[:index], cart.Items[index+1:]...)
+		}
+	} else {
+		canonical, err := cs.validateItem(ctx, item)
+		if err != nil {
+			logger.WarnContext(ctx, "Cart item rejected", map[string]interface{}{
+				"cart_id":    id,
+				"product_id": item.ProductID,
+				"error":      err.Error(),
+			})
+			return models.PricedCart{}, err
+		}
+		if exists {
+			cart.Items[index] = canonical
+		} else {
+			cart.Items = append(cart.Items, canonical)
+		}
+	}
+
+	priced, err := cs.save(ctx, *cart)
+	if err != nil {
+		logger.LogErrorContext(ctx, "CartService", "SetItem", err, params)
+		return models.PricedCart{}, err
+	}
+
+	duration := float64(time.Since(start).Nanoseconds()) / 1e6
+	logger.LogServiceResultContext(ctx, "CartService", "SetItem", len(priced.Items), duration)
+
+	logger.InfoContext(ctx, "Cart item set", params)
+
+	return priced, nil
+}
+
+// RemoveItem removes a cart line or returns ErrCartItemNotFound
+func (cs *CartService) RemoveItem(ctx context.Context, id, userID string, item models.CartItem) (models.PricedCart, error) {
+	defer cs.locks.lock(cartLockKey(id))()
+
+	params := map[string]interface{}{
+		"cart_id":    id,
+		"product_id": item.ProductID,
+		"size":       item.Size,
+		"color":      item.Color,
+	}
+	logger.LogServiceCallContext(ctx, "CartService", "RemoveItem", params)
+
+	cart, err := cs.ownedCart(ctx, id, userID)
+	if err != nil {
+		if !errors.Is(err, ErrCartNotFound) {
+			logger.LogErrorContext(ctx, "CartService", "RemoveItem", err, params)
+		}
+		return models.PricedCart{}, err
+	}
+
+	index, exists := cart.FindItem(item)
+	if !exists {
+		return models.PricedCart{}, ErrCartItemNotFound
+	}
+	cart.Items = append(cart.Items[:index], cart.Items[index+1:]...)
+
+	priced, err := cs.save(ctx, *cart)
+	if err != nil {
+		logger.LogErrorContext(ctx, "CartService", "RemoveItem", err, params)
+		return models.PricedCart{}, err
+	}
+
+	logger.InfoContext(ctx, "Cart item removed", params)
+
+	return priced, nil
+}
+
+// ClearCart removes every line from a cart
+func (cs *CartService) ClearCart(ctx context.Context, id, userID string) (models.PricedCart, error) {
+	defer cs.locks.lock(cartLockKey(id))()
+
+	params := map[string]interface{}{"cart_id": id}
+	logger.LogServiceCallContext(ctx, "CartService", "ClearCart", params)
+
+	cart, err := cs.ownedCart(ctx, id, userID)
+	if err != nil {
+		if !errors.Is(err, ErrCartNotFound) {
+			logger.LogErrorContext(ctx, "CartService", "ClearCart", err, params)
+		}
+		return models.PricedCart{}, err
+	}
+
+	cart.Items = []models.CartItem{}
+	priced, err := cs.save(ctx, *cart)
+	if err != nil {
+		logger.LogErrorContext(ctx, "CartService", "ClearCart", err, params)
+		return models.PricedCart{}, err
+	}
+
+	logger.InfoContext(ctx, "Cart cleared", params)
+
+	return priced, nil
+}
+
+// MergeCart folds a guest cart into the user's cart, creating the user's cart
+// if needed, and deletes the guest cart. Lines for the same product, size and
+// color are summed and capped by the available stock and MaxCartItemQuantity;
+// guest lines that can't be kept as they were are reported in the result.
+// Without a guest cart ID it just returns the user's cart.
+func (cs *CartService) MergeCart(ctx context.Context, userID, guestCartID string) (models.CartMergeResult, error) {
+	// The user's lock keeps two merges from each starting a cart for them
+	defer cs.locks.lock(userLockKey(userID))()
+
+	start := time.Now()
+	params := map[string]interface{}{
+		"user_id":       userID,
+		"guest_cart_id": guestCartID,
+	}
+	logger.LogServiceCallContext(ctx, "CartService", "MergeCart", params)
+
+	userCart, err := cs.userCart(userID)
+	if err != nil {
+		logger.LogErrorContext(ctx, "CartService", "MergeCart", err, params)
+		return models.CartMergeResult{}, err
+	}
+	cartKeys := []string{cartLockKey(userCart.ID)}
+	if guestCartID != "" {
+		cartKeys = append(cartKeys, cartLockKey(guestCartID))
+	}
+	defer cs.locks.lock(cartKeys...)()
+	// The user's cart may have changed before its lock was taken
+	if latest, err := cs.repo.Get(userCart.ID); err == nil {
+		userCart = latest
+	} else if !errors.Is(err, ErrCartNotFound) {
+		logger.LogErrorContext(ctx, "CartService", "MergeCart", err, params)
+		return models.CartMergeResult{}, err
+	}
+
+	adjustments := []models.CartMergeAdjustment{}
+	merging := guestCartID != "" && guestCartID != userCart.ID
+	if merging {
+		guest, err := cs.repo.Get(guestCartID)
+		if err != nil {
+			if !errors.Is(err, ErrCartNotFound) {
+				logger.LogErrorContext(ctx, "CartService", "MergeCart", err, params)
+			}
+			return models.CartMergeResult{}, err
+		}
+		// Another user's cart is reported as missing rather than confirming it exists
+		if guest.UserID != "" && guest.UserID != userID {
+			logger.WarnContext(ctx, "Refused to merge another user's cart", params)
+			return models.CartMergeResult{}, ErrCartNotFound
+		}
+
+		adjustments, err = cs.mergeItems(ctx, userCart, guest.Items)
+		if err != nil {
+			logger.LogErrorContext(ctx, "CartService", "MergeCart", err, params)
+			return models.CartMergeResult{}, err
+		}
+	}
+
+	priced, err := cs.save(ctx, *userCart)
+	if err != nil {
+		logger.LogErrorContext(ctx, "CartService", "MergeCart", err, params)
+		return models.CartMergeResult{}, err
+	}
+	if merging {
+		if err := cs.repo.Delete(guestCartID); err != nil && !errors.Is(err, ErrCartNotFound) {
+			// The user's cart already has the items; a leftover guest cart is harmless
+			logger.LogErrorContext(ctx, "CartService", "MergeCart", err, params)
+		}
+	}
+
+	duration := float64(time.Since(start).Nanoseconds()) / 1e6
+	logger.LogServiceResultContext(ctx, "CartService", "MergeCart", len(priced.Items), duration)
+
+	if merging {
+		logger.InfoContext(ctx, "Guest cart merged", map[string]interface{}{
+			"user_id":       userID,
+			"cart_id":       userCart.ID,
+			"guest_cart_id": guestCartID,
+			"adjustments":   len(adjustments),
+		})
+	}
+
+	return models.CartMergeResult{Cart: priced, Adjustments: adjustments}, nil
+}
+
+// ownedCart returns a cart the caller may use: a guest cart, or a user cart
+// when userID is its user. Other users' carts are reported as ErrCartNotFound
+// rather than confirming they exist.
+func (cs *CartService) ownedCart(ctx context.Context, id, userID string) (*models.Cart, error) {
+	cart, err := cs.repo.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if cart.UserID != "" && cart.UserID != userID {
+		logger.WarnContext(ctx, "Refused access to another user's cart", map[string]interface{}{
+			"cart_id": id,
+			"user_id": userID,
+		})
+		return nil, ErrCartNotFound
+	}
+	return cart, nil
+}
+
+// userCart returns a user's cart, starting an empty one if they have none;
+// callers must hold the user's lock
+func (cs *CartService) userCart(userID string) (*models.Cart, error) {
+	cart, err := cs.repo.GetByUser(userID)
+	if err == nil || !errors.Is(err, ErrCartNotFound) {
+		return cart, err
+	}
+
+	id, err := newRandomID()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	return &models.Cart{ID: id, UserID: userID, Items: []models.CartItem{}, CreatedAt: now, UpdatedAt: now}, nil
+}
+
+// mergeItems adds guest lines to cart and returns the lines it had to change
+func (cs *CartService) mergeItems(ctx context.Context, cart *models.Cart, items []models.CartItem) ([]models.CartMergeAdjustment, error) {
+	ids := make([]int, 0, len(items))
+	for _, item := range items {
+		ids = append(ids, item.ProductID)
+	}
+	products, err := cs.products.lookupProducts(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	adjustments := []models.CartMergeAdjustment{}
+	for _, item := range items {
+		adjustment := models.CartMergeAdjustment{
+			ProductID: item.ProductID,
+			Size:      item.Size,
+			Color:     item.Color,
+			Action:    models.CartMergeDropped,
+			Requested: item.Quantity,
+		}
+
+		product, exists := products[item.ProductID]
+		if !exists {
+			adjustment.Reason = "product is no longer available"
+			adjustments = append(adjustments, adjustment)
+			continue
+		}
+		size, sizeOK := findFold(product.Sizes, item.Size)
+		color, colorOK := findFold(product.Colors, item.Color)
+		if !sizeOK && (len(product.Sizes) > 0 || item.Size != "") || !colorOK && (len(product.Colors) > 0 || item.Color != "") {
+			adjustment.Reason = "size or color is no longer available"
+			adjustments = append(adjustments, adjustment)
+			continue
+		}
+		if sizeOK {
+			item.Size = size
+		}
+		if colorOK {
+			item.Color = color
+		}
+		adjustment.Size, adjustment.Color = item.Size, item.Color
+
+		index, exists := cart.FindItem(item)
+		if exists {
+			adjustment.Requested += cart.Items[index].Quantity
+		}
+		available, tracked := lineAvailability(product, item)
+		quantity := adjustment.Requested
+		reason := ""
+		if quantity > available {
+			quantity = available
+			switch {
+			case available == 0 && tracked:
+				reason = "sold out"
+			case available == 0:
+				reason = "out of stock"
+			default:
+				reason = fmt.Sprintf("only %d left", available)
+			}
+		}
+		if quantity > models.MaxCartItemQuantity {
+			quantity = models.MaxCartItemQuantity
+			reason = fmt.Sprintf("at most %d per line", models.MaxCartItemQuantity)
+		}
+
+		item.Quantity = quantity
+		switch {
+		case quantity == 0 && exists:
+			cart.Items = append(cart.Items[:index], cart.Items[index+1:]...)
+		case quantity == 0:
+		case exists:
+			cart.Items[index] = item
+		default:
+			cart.Items = append(cart.Items, item)
+		}
+
+		if reason != "" {
+			adjustment.Quantity = quantity
+			adjustment.Reason = reason
+			if quantity > 0 {
+				adjustment.Action = models.CartMergeAdjusted
+			}
+			adjustments = append(adjustments, adjustment)
+		}
+	}
+	return adjustments, nil
+}
+
+// checkout calls place with the items of a cart and empties the cart if place
+// succeeds. The cart stays locked meanwhile, so it can't change while it is
+// being ordered; other carts are not held up.
+func (cs *CartService) checkout(ctx context.Context, id, userID string, place func(items []models.CartItem) error) error {
+	defer cs.locks.lock(cartLockKey(id))()
+
+	cart, err := cs.ownedCart(ctx, id, userID)
+	if err != nil {
+		return err
+	}
+	if len(cart.Items) == 0 {
+		return &models.ValidationError{Problems: []string{"cart is empty"}}
+	}
+	if err := place(cart.Items); err != nil {
+		return err
+	}
+
+	cart.Items = []models.CartItem{}
+	cart.UpdatedAt = time.Now().UTC()
+	if err := cs.repo.Save(*cart); err != nil {
+		// The order went through; a stale cart is only an inconvenience
+		logger.LogErrorContext(ctx, "CartService", "checkout", err, map[string]interface{}{
+			"cart_id": id,
+		})
+	}
+	return nil
+}
+
+// save stores a cart with a fresh UpdatedAt and prices it; callers must hold the cart's lock
+func (cs *CartService) save(ctx context.Context, cart models.Cart) (models.PricedCart, error) {
+	cart.UpdatedAt = time.Now().UTC()
+	if err := cs.repo.Save(cart); err != nil {
+		return models.PricedCart{}, err
+	}
+	return cs.price(ctx, cart)
+}
+
+// validateItem checks a cart line against the catalog and returns it with the
+// catalog's spelling of the size and color
+func (cs *CartService) validateItem(ctx context.Context, item models.CartItem) (models.CartItem, error) {
+	var problems []string
+	if item.Quantity < 0 || item.Quantity > models.MaxCartItemQuantity {
+		problems = append(problems, fmt.Sprintf("quantity must be between 0 and %d", models.MaxCartItemQuantity))
+	}
+
+	products, err := cs.products.lookupProducts(ctx, []int{item.ProductID})
+	if err != nil {
+		return item, err
+	}
+	product, exists := products[item.ProductID]
+	if !exists {
+		problems = append(problems, fmt.Sprintf("product %d does not exist", item.ProductID))
+		return item, &models.ValidationError{Problems: problems}
+	}
+
+	if size, ok := findFold(product.Sizes, item.Size); ok {
+		item.Size = size
+	} else if len(product.Sizes) > 0 || item.Size != "" {
+		problems = append(problems, fmt.Sprintf("size %q is not available for this product", item.Size))
+	}
+	if color, ok := findFold(product.Colors, item.Color); ok {
+		item.Color = color
+	} else if len(product.Colors) > 0 || item.Color != "" {
+		problems = append(problems, fmt.Sprintf("color %q is not available for this product", item.Color))
+	}
+	if len(problems) > 0 {
+		return item, &models.ValidationError{Problems: problems}
+	}
+
+	if available, _ := lineAvailability(product, item); available < item.Quantity {
+		return item, fmt.Errorf("%w: %d of %s/%s left", ErrInsufficientStock, available, item.Size, item.Color)
+	}
+	return item, nil
+}
+
+// price prices a cart against the current catalog
+func (cs *CartService) price(ctx context.Context, cart models.Cart) (models.PricedCart, error) {
+	ids := make([]int, 0, len(cart.Items))
+	for _, item := range cart.Items {
+		ids = append(ids, item.ProductID)
+	}
+	products, err := cs.products.lookupProducts(ctx, ids)
+	if err != nil {
+		return models.PricedCart{}, err
+	}
+
+	priced := models.PricedCart{
+		ID:        cart.ID,
+		UserID:    cart.UserID,
+		Items:     make([]models.PricedLine, 0, len(cart.Items)),
+		UpdatedAt: cart.UpdatedAt,
+	}
+	for _, item := range cart.Items {
+		line := models.PricedLine{CartItem: item}
+		product, exists := products[item.ProductID]
+		if !exists {
+			line.Problem = "product is no longer available"
+			priced.Items = append(priced.Items, line)
+			continue
+		}
+
+		line.Name = product.Name
+		line.Image = product.Image
+		line.UnitPrice = product.PriceFor(item.Size, item.Color)
+		line.LineTotal = roundCents(line.UnitPrice * float64(item.Quantity))
+
+		available, tracked := lineAvailability(product, item)
+		switch {
+		case available >= item.Quantity:
+			line.Available = true
+		case available == 0 && tracked:
+			line.Problem = "sold out"
+		case available == 0:
+			line.Problem = "out of stock"
+		default:
+			line.Problem = fmt.Sprintf("only %d left", available)
+		}
+
+		if line.Available {
+			priced.ItemCount += item.Quantity
+			priced.Subtotal += line.LineTotal
+		}
+		priced.Items = append(priced.Items, line)
+	}
+	priced.Subtotal = roundCents(priced.Subtotal)
+	priced.Total = priced.Subtotal
+	return priced, nil
+}
+
+// lineAvailability returns how many units of a cart line can be bought and
+// whether the product tracks stock per variant. Untracked products are
+// unlimited while in stock.
+func lineAvailability(product models.Product, item models.CartItem) (int, bool) {
+	if len(product.Variants) == 0 {
+		if product.InStock {
+			return math.MaxInt32, false
+		}
+		return 0, false
+	}
+	if i, ok := product.FindVariant(item.Size, item.Color); ok {
+		return product.Variants[i].Stock, true
+	}
+	return 0, true
+}
+
+// findFold returns the element of values equal to value ignoring case
+func findFold(values []string, value string) (string, bool) {
+	for _, candidate := range values {
+		if strings.EqualFold(candidate, value) {
+			return candidate, true
+		}
+	}
+	return "", false
+}
+
+// roundCents rounds an amount to whole cents
+func roundCents(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
+
+// cartLockKey is the keyedMutex key of a cart
+func cartLockKey(id string) string {
+	return "cart:" + id
+}
+
+// userLockKey is the keyedMutex key of a user's carts
+func userLockKey(userID string) string {
+	return "user:" + userID
+}
+
+// newRandomID returns a random 128-bit hex ID for carts and orders
+func newRandomID() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("generate id: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
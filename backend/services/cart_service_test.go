@@ -0,0 +1,157 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"ecommerce-backend/models"
+	"ecommerce-backend/repository"
+)
+
+// newTestCartService creates a CartService over the sample catalog
+func newTestCartService(t *testing.T) *CartService {
+	t.Helper()
+	products, err := NewProductService(repository.NewSampleProductRepository())
+	if err != nil {
+		t.Fatalf("NewProductService: %v", err)
+	}
+	return NewCartService(repository.NewMemoryCartRepository(), products)
+}
+
+// TestCartCheckoutLocksOnlyItsCart checks that a checkout waiting on the
+// payment gateway holds up changes to its own cart but not to other carts
+func TestCartCheckoutLocksOnlyItsCart(t *testing.T) {
+	cs := newTestCartService(t)
+	ctx := context.Background()
+	item := models.CartItem{ProductID: 1, Size: "M", Color: "Black", Quantity: 1}
+
+	var ids []string
+	for i := 0; i < 2; i++ {
+		cart, err := cs.CreateCart(ctx)
+		if err != nil {
+			t.Fatalf("CreateCart: %v", err)
+		}
+		if _, err := cs.SetItem(ctx, cart.ID, "", item); err != nil {
+			t.Fatalf("SetItem: %v", err)
+		}
+		ids = append(ids, cart.ID)
+	}
+	checkingOut, other := ids[0], ids[1]
+
+	placing := make(chan struct{})
+	release := make(chan struct{})
+	checkoutDone := make(chan error)
+	go func() {
+		checkoutDone <- cs.checkout(ctx, checkingOut, "", func([]models.CartItem) error {
+			close(placing)
+			<-release
+			return nil
+		})
+	}()
+	<-placing
+
+	otherDone := make(chan error)
+	go func() {
+		_, err := cs.SetItem(ctx, other, "", models.CartItem{ProductID: 1, Size: "M", Color: "Black", Quantity: 2})
+		otherDone <- err
+	}()
+	select {
+	case err := <-otherDone:
+		if err != nil {
+			t.Fatalf("SetItem on another cart: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("SetItem on another cart waited for the checkout")
+	}
+
+	sameDone := make(chan error)
+	go func() {
+		_, err := cs.SetItem(ctx, checkingOut, "", item)
+		sameDone <- err
+	}()
+	select {
+	case <-sameDone:
+		t.Fatal("SetItem on the cart being checked out did not wait for the checkout")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	if err := <-checkoutDone; err != nil {
+		t.Fatalf("checkout: %v", err)
+	}
+	if err := <-sameDone; err != nil {
+		t.Fatalf("SetItem after checkout: %v", err)
+	}
+
+	// The change made after the checkout lands in the emptied cart
+	cart, err := cs.GetCart(ctx, checkingOut, "")
+	if err != nil {
+		t.Fatalf("GetCart: %v", err)
+	}
+	if cart.ItemCount != 1 {
+		t.Errorf("cart has %d items after checkout and re-add, want 1", cart.ItemCount)
+	}
+	if len(cs.locks.locks) != 0 {
+		t.Errorf("%d cart locks left behind", len(cs.locks.locks))
+	}
+}
+
+// TestUserCartsOnlyServeTheirUser checks that a user's cart can't be read,
+// changed or checked out by anyone else, while guest carts stay open
+func TestUserCartsOnlyServeTheirUser(t *testing.T) {
+	cs := newTestCartService(t)
+	ctx := context.Background()
+	item := models.CartItem{ProductID: 1, Size: "M", Color: "Black", Quantity: 1}
+
+	guest, err := cs.CreateCart(ctx)
+	if err != nil {
+		t.Fatalf("CreateCart: %v", err)
+	}
+	if _, err := cs.SetItem(ctx, guest.ID, "", item); err != nil {
+		t.Fatalf("SetItem on a guest cart: %v", err)
+	}
+	merged, err := cs.MergeCart(ctx, "alice", guest.ID)
+	if err != nil {
+		t.Fatalf("MergeCart: %v", err)
+	}
+	id := merged.Cart.ID
+
+	placed := false
+	place := func([]models.CartItem) error {
+		placed = true
+		return nil
+	}
+	for _, caller := range []string{"", "bob"} {
+		if _, err := cs.GetCart(ctx, id, caller); !errors.Is(err, ErrCartNotFound) {
+			t.Errorf("GetCart by %q = %v, want ErrCartNotFound", caller, err)
+		}
+		if _, err := cs.SetItem(ctx, id, caller, item); !errors.Is(err, ErrCartNotFound) {
+			t.Errorf("SetItem by %q = %v, want ErrCartNotFound", caller, err)
+		}
+		if _, err := cs.RemoveItem(ctx, id, caller, item); !errors.Is(err, ErrCartNotFound) {
+			t.Errorf("RemoveItem by %q = %v, want ErrCartNotFound", caller, err)
+		}
+		if _, err := cs.ClearCart(ctx, id, caller); !errors.Is(err, ErrCartNotFound) {
+			t.Errorf("ClearCart by %q = %v, want ErrCartNotFound", caller, err)
+		}
+		if err := cs.checkout(ctx, id, caller, place); !errors.Is(err, ErrCartNotFound) {
+			t.Errorf("checkout by %q = %v, want ErrCartNotFound", caller, err)
+		}
+	}
+	if placed {
+		t.Error("another caller checked out alice's cart")
+	}
+
+	cart, err := cs.GetCart(ctx, id, "alice")
+	if err != nil {
+		t.Fatalf("GetCart by alice: %v", err)
+	}
+	if cart.ItemCount != 1 {
+		t.Errorf("alice's cart has %d items, want 1", cart.ItemCount)
+	}
+	if err := cs.checkout(ctx, id, "alice", place); err != nil || !placed {
+		t.Errorf("checkout by alice = %v, placed = %v", err, placed)
+	}
+}
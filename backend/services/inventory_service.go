@@ -0,0 +1,260 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"ecommerce-backend/logger"
+	"ecommerce-backend/models"
+)
+
+// Inventory errors
+var (
+	// ErrVariantNotFound is returned when a product has no variant with the requested size and color
+	ErrVariantNotFound = errors.New("variant not found")
+	// ErrInsufficientStock is returned when a reservation or adjustment would make stock negative
+	ErrInsufficientStock = errors.New("insufficient stock")
+	// ErrInvalidQuantity is returned for reservations and releases of less than one unit
+	ErrInvalidQuantity = errors.New("quantity must be at least 1")
+)
+
+// InventoryService manages the stock of product variants. Stock changes go
+// through the ProductService write lock, so they are atomic with respect to
+// catalog reads and writes.
+type InventoryService struct {
+	products *ProductService
+}
+
+// NewInventoryService creates an InventoryService for the products of the given catalog
+func NewInventoryService(products *ProductService) *InventoryService {
+	return &InventoryService{products: products}
+}
+
+// Reserve takes quantity units of a variant out of stock, e.g. for an order
+func (is *InventoryService) Reserve(ctx context.Context, productID int, size, color string, quantity int) (models.Variant, error) {
+	if quantity < 1 {
+		return models.Variant{}, ErrInvalidQuantity
+	}
+	return is.changeStock(ctx, "Reserve", productID, size, color, -quantity)
+}
+
+// Release puts quantity previously reserved units of a variant back in stock
+func (is *InventoryService) Release(ctx context.Context, productID int, size, color string, quantity int) (models.Variant, error) {
+	if quantity < 1 {
+		return models.Variant{}, ErrInvalidQuantity
+	}
+	return is.changeStock(ctx, "Release", productID, size, color, quantity)
+}
+
+// Adjust changes the stock of a variant by delta, e.g. after a delivery (positive)
+// or a stock count correction (negative). Stock never goes below zero.
+func (is *InventoryService) Adjust(ctx context.Context, productID int, size, color string, delta int) (models.Variant, error) {
+	return is.changeStock(ctx, "Adjust", productID, size, color, delta)
+}
+
+// changeStock applies a stock delta to one variant and stores the product
+func (is *InventoryService) changeStock(ctx context.Context, method string, productID int, size, color string, delta int) (models.Variant, error) {
+	ps := is.products
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	start := time.Now()
+
+	params := map[string]interface{}{
+		"product_id": productID,
+		"size":       size,
+		"color":      color,
+		"delta":      delta,
+	}
+	logger.LogServiceCallContext(ctx, "InventoryService", method, params)
+
+	product, err := ps.repo.GetByID(productID)
+	if err != nil {
+		if !errors.Is(err, ErrProductNotFound) {
+			logger.LogErrorContext(ctx, "InventoryService", method, err, params)
+		}
+		return models.Variant{}, err
+	}
+
+	i, ok := product.FindVariant(size, color)
+	if !ok {
+		return models.Variant{}, fmt.Errorf("%w: product %d has no %s/%s variant", ErrVariantNotFound, productID, size, color)
+	}
+	stock := product.Variants[i].Stock + delta
+	if stock < 0 {
+		logger.WarnContext(ctx, "Insufficient stock", map[string]interface{}{
+			"product_id": productID,
+			"size":       size,
+			"color":      color,
+			"stock":      product.Variants[i].Stock,
+			"requested":  -delta,
+		})
+		return models.Variant{}, fmt.Errorf("%w: %d of %s/%s left", ErrInsufficientStock, product.Variants[i].Stock, size, color)
+	}
+
+	product.Variants[i].Stock = stock
+	product.SyncInventory()
+	updated, err := ps.repo.Update(*product)
+	if err != nil {
+		logger.LogErrorContext(ctx, "InventoryService", method, err, params)
+		return models.Variant{}, err
+	}
+
+	duration := float64(time.Since(start).Nanoseconds()) / 1e6
+	logger.LogServiceResultContext(ctx, "InventoryService", method, 1, duration)
+
+	logger.InfoContext(ctx, "Variant stock changed", map[string]interface{}{
+		"product_id": productID,
+		"size":       size,
+		"color":      color,
+		"delta":      delta,
+		"stock":      stock,
+		"in_stock":   updated.InStock,
+	})
+
+	return updated.Variants[i], nil
+}
+
+// ReserveItems validates a set of cart lines against the catalog and takes
+// them out of stock all together: either every line is reserved or, on any
+// problem, none is. It returns the reserved products as they were at the time
+// of the reservation, keyed by ID, so callers can snapshot prices. Unknown
+// products, sizes or colors are reported as a ValidationError and shortages
+// as ErrInsufficientStock.
+func (is *InventoryService) ReserveItems(ctx context.Context, items []models.CartItem) (map[int]models.Product, error) {
+	ps := is.products
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	start := time.Now()
+	params := map[string]interface{}{"lines": len(items)}
+	logger.LogServiceCallContext(ctx, "InventoryService", "ReserveItems", params)
+
+	products := make(map[int]models.Product)
+	updated := make(map[int]*models.Product)
+	var problems []string
+	for i, item := range items {
+		label := fmt.Sprintf("items[%d]", i)
+		if item.Quantity < 1 || item.Quantity > models.MaxCartItemQuantity {
+			problems = append(problems, fmt.Sprintf("%s: quantity must be between 1 and %d", label, models.MaxCartItemQuantity))
+			continue
+		}
+
+		product, exists := updated[item.ProductID]
+		if !exists {
+			found, err := ps.repo.GetByID(item.ProductID)
+			if errors.Is(err, ErrProductNotFound) {
+				problems = append(problems, fmt.Sprintf("%s: product %d does not exist", label, item.ProductID))
+				continue
+			}
+			if err != nil {
+				logger.LogErrorContext(ctx, "InventoryService", "ReserveItems", err, params)
+				return nil, err
+			}
+			products[found.ID] = found.Clone()
+			product = found
+			updated[found.ID] = product
+		}
+
+		if _, ok := findFold(product.Sizes, item.Size); !ok && (len(product.Sizes) > 0 || item.Size != "") {
+			problems = append(problems, fmt.Sprintf("%s: size %q is not available for product %d", label, item.Size, item.ProductID))
+			continue
+		}
+		if _, ok := findFold(product.Colors, item.Color); !ok && (len(product.Colors) > 0 || item.Color != "") {
+			problems = append(problems, fmt.Sprintf("%s: color %q is not available for product %d", label, item.Color, item.ProductID))
+			continue
+		}
+
+		// Earlier lines for the same variant have already been taken off product
+		available, tracked := lineAvailability(*product, item)
+		if available < item.Quantity {
+			return nil, fmt.Errorf("%w: %d of product %d %s/%s left", ErrInsufficientStock, available, item.ProductID, item.Size, item.Color)
+		}
+		if tracked {
+			v, _ := product.FindVariant(item.Size, item.Color)
+			product.Variants[v].Stock -= item.Quantity
+		}
+	}
+	if len(problems) > 0 {
+		return nil, &models.ValidationError{Problems: problems}
+	}
+
+	// Every line checked out; store the new stock levels, undoing on failure
+	var stored []models.Product
+	for id, product := range updated {
+		if len(product.Variants) == 0 {
+			continue
+		}
+		product.SyncInventory()
+		if _, err := ps.repo.Update(*product); err != nil {
+			logger.LogErrorContext(ctx, "InventoryService", "ReserveItems", err, params)
+			for _, original := range stored {
+				if _, rollbackErr := ps.repo.Update(original); rollbackErr != nil {
+					logger.LogErrorContext(ctx, "InventoryService", "ReserveItems", rollbackErr, map[string]interface{}{
+						"product_id": original.ID,
+						"rollback":   true,
+					})
+				}
+			}
+			return nil, err
+		}
+		stored = append(stored, products[id])
+	}
+
+	duration := float64(time.Since(start).Nanoseconds()) / 1e6
+	logger.LogServiceResultContext(ctx, "InventoryService", "ReserveItems", len(items), duration)
+
+	logger.InfoContext(ctx, "Stock reserved", map[string]interface{}{
+		"lines":    len(items),
+		"products": len(products),
+	})
+
+	return products, nil
+}
+
+// ReleaseItems puts the stock of previously reserved lines back, e.g. when an
+// order is cancelled. Lines whose product or variant no longer exists are skipped.
+func (is *InventoryService) ReleaseItems(ctx context.Context, items []models.CartItem) error {
+	ps := is.products
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	params := map[string]interface{}{"lines": len(items)}
+	logger.LogServiceCallContext(ctx, "InventoryService", "ReleaseItems", params)
+
+	updated := make(map[int]*models.Product)
+	for _, item := range items {
+		product, exists := updated[item.ProductID]
+		if !exists {
+			found, err := ps.repo.GetByID(item.ProductID)
+			if errors.Is(err, ErrProductNotFound) {
+				continue
+			}
+			if err != nil {
+				logger.LogErrorContext(ctx, "InventoryService", "ReleaseItems", err, params)
+				return err
+			}
+			product = found
+			updated[found.ID] = product
+		}
+		if v, ok := product.FindVariant(item.Size, item.Color); ok {
+			product.Variants[v].Stock += item.Quantity
+		}
+	}
+
+	for _, product := range updated {
+		if len(product.Variants) == 0 {
+			continue
+		}
+		product.SyncInventory()
+		if _, err := ps.repo.Update(*product); err != nil {
+			logger.LogErrorContext(ctx, "InventoryService", "ReleaseItems", err, params)
+			return err
+		}
+	}
+
+	logger.InfoContext(ctx, "Stock released", params)
+	return nil
+}
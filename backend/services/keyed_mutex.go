@@ -0,0 +1,76 @@
+package services
+
+import (
+	"sort"
+	"sync"
+)
+
+// keyedMutex hands out one lock per key, such as a cart ID, so work on one
+// key never waits for work on another. Locks are dropped once nobody holds or
+// waits for them.
+type keyedMutex struct {
+	mu    sync.Mutex
+	locks map[string]*keyedLock
+}
+
+// keyedLock is the lock of one key and the number of callers using it
+type keyedLock struct {
+	mu   sync.Mutex
+	refs int
+}
+
+// lock locks every key and returns a function that unlocks them. Keys are
+// locked in sorted order, so callers locking several keys can't deadlock.
+func (km *keyedMutex) lock(keys ...string) func() {
+	sorted := make([]string, 0, len(keys))
+	seen := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		if !seen[key] {
+			seen[key] = true
+			sorted = append(sorted, key)
+		}
+	}
+	sort.Strings(sorted)
+
+	held := make([]*keyedLock, 0, len(sorted))
+	for _, key := range sorted {
+		lock := km.acquire(key)
+		lock.mu.Lock()
+		held = append(held, lock)
+	}
+
+	return func() {
+		for i := len(held) - 1; i >= 0; i-- {
+			held[i].mu.Unlock()
+			km.release(sorted[i], held[i])
+		}
+	}
+}
+
+// acquire returns the lock of key, creating it if needed, and counts the caller
+func (km *keyedMutex) acquire(key string) *keyedLock {
+	km.mu.Lock()
+	defer km.mu.Unlock()
+
+	if km.locks == nil {
+		km.locks = make(map[string]*keyedLock)
+	}
+	lock, exists := km.locks[key]
+	if !exists {
+		lock = &keyedLock{}
+		km.locks[key] = lock
+	}
+	lock.refs++
+	return lock
+}
+
+// release stops counting a caller and drops the lock when it was the last one
+func (km *keyedMutex) release(key string, lock *keyedLock) {
+	km.mu.Lock()
+	defer km.mu.Unlock()
+
+	lock.refs--
+	if lock.refs == 0 {
+		delete(km.locks, key)
+	}
+}
@@ -0,0 +1,673 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"ecommerce-backend/logger"
+	"ecommerce-backend/models"
+	"ecommerce-backend/payments"
+	"ecommerce-backend/repository"
+)
+
+// ErrOrderNotFound is returned when a requested order does not exist
+var ErrOrderNotFound = repository.ErrOrderNotFound
+
+// ErrInvalidTransition is returned when an order cannot move to the requested status
+var ErrInvalidTransition = errors.New("invalid order status transition")
+
+// Payment errors surfaced by the order flow
+var (
+	// ErrPaymentDeclined is returned when the gateway refuses the payment
+	ErrPaymentDeclined = payments.ErrDeclined
+	// ErrPaymentTimeout is returned when the gateway doesn't answer in time
+	ErrPaymentTimeout = payments.ErrTimeout
+	// ErrPaymentState is returned when the order's payment can't take the operation
+	// a status change needs, such as refunding a payment that was never captured
+	ErrPaymentState = payments.ErrInvalidState
+)
+
+// Actors recorded on order events that don't come from a transition request
+const (
+	// PlacementActor places orders
+	PlacementActor = "customer"
+	// PaymentActor applies status changes reported by payment webhooks
+	PaymentActor = "payment-gateway"
+)
+
+// abandonedAuthorizationTTL is how long an authorization that timed out is
+// remembered, waiting for a webhook that shows whether it went through
+const abandonedAuthorizationTTL = 24 * time.Hour
+
+// OrderService turns carts into orders. Placing an order validates every line
+// against the catalog, takes the stock out of inventory in one step and
+// snapshots the current prices into the order. The total is authorized with the
+// payment gateway before the order is stored. Status changes afterwards follow
+// the order lifecycle, settle the payment and are recorded as events.
+type OrderService struct {
+	// locks serializes status changes per order ID, so a slow gateway call
+	// holds up only its own order
+	locks          keyedMutex
+	repo           repository.OrderRepository
+	carts          *CartService
+	inventory      *InventoryService
+	gateway        payments.PaymentGateway
+	paymentTimeout time.Duration
+	// abandoned maps IDs of orders that weren't placed because authorizing their
+	// payment timed out to when that happened. The gateway may still have
+	// authorized the payment; its webhook shows whether it needs voiding.
+	abandoned map[string]time.Time
+	// abandonedMu guards abandoned
+	abandonedMu sync.Mutex
+}
+
+// NewOrderService creates an OrderService. paymentTimeout bounds each gateway call.
+func NewOrderService(repo repository.OrderRepository, carts *CartService, inventory *InventoryService, gateway payments.PaymentGateway, paymentTimeout time.Duration) *OrderService {
+	return &OrderService{
+		repo:           repo,
+		carts:          carts,
+		inventory:      inventory,
+		gateway:        gateway,
+		paymentTimeout: paymentTimeout,
+		abandoned:      make(map[string]time.Time),
+	}
+}
+
+// PlaceOrder places an order for a cart or an explicit list of items. Unknown
+// products, sizes or colors fail with a ValidationError and shortages with
+// ErrInsufficientStock, and payments the gateway refuses with ErrPaymentDeclined;
+// in all cases no stock is taken. Ordering a cart empties it.
+func (ors *OrderService) PlaceOrder(ctx context.Context, request models.OrderRequest) (models.Order, error) {
+	start := time.Now()
+
+	params := map[string]interface{}{
+		"cart_id": request.CartID,
+		"lines":   len(request.Items),
+	}
+	logger.LogServiceCallContext(ctx, "OrderService", "PlaceOrder", params)
+
+	if err := request.Validate(); err != nil {
+		return models.Order{}, err
+	}
+
+	var order models.Order
+	place := func(items []models.CartItem) error {
+		var err error
+		order, err = ors.place(ctx, items, request)
+		return err
+	}
+
+	var err error
+	if request.CartID != "" {
+		err = ors.carts.checkout(ctx, request.CartID, request.UserID, place)
+	} else {
+		err = place(request.Items)
+	}
+	if err != nil {
+		var validationErr *models.ValidationError
+		if errors.As(err, &validationErr) || errors.Is(err, ErrInsufficientStock) || errors.Is(err, ErrCartNotFound) || errors.Is(err, ErrPaymentDeclined) {
+			logger.WarnContext(ctx, "Order rejected", map[string]interface{}{
+				"cart_id": request.CartID,
+				"error":   err.Error(),
+			})
+		} else {
+			logger.LogErrorContext(ctx, "OrderService", "PlaceOrder", err, params)
+		}
+		return models.Order{}, err
+	}
+
+	duration := float64(time.Since(start).Nanoseconds()) / 1e6
+	logger.LogServiceResultContext(ctx, "OrderService", "PlaceOrder", len(order.Items), duration)
+
+	logger.InfoContext(ctx, "Order placed", map[string]interface{}{
+		"order_id":   order.ID,
+		"cart_id":    order.CartID,
+		"item_count": order.ItemCount,
+		"total":      order.Total,
+	})
+
+	return order, nil
+}
+
+// place reserves the stock for items, authorizes the payment and stores the order
+func (ors *OrderService) place(ctx context.Context, items []models.CartItem, request models.OrderRequest) (models.Order, error) {
+	id, err := newRandomID()
+	if err != nil {
+		return models.Order{}, err
+	}
+
+	products, err := ors.inventory.ReserveItems(ctx, items)
+	if err != nil {
+		return models.Order{}, err
+	}
+
+	// Guests have no account to prove the order is theirs, so they get a secret
+	var accessToken string
+	if request.UserID == "" {
+		if accessToken, err = newRandomID(); err != nil {
+			return models.Order{}, err
+		}
+	}
+
+	now := time.Now().UTC()
+	order := models.Order{
+		ID:              id,
+		Status:          models.OrderStatusPending,
+		Items:           make([]models.OrderLine, 0, len(items)),
+		ShippingAddress: request.ShippingAddress,
+		CartID:          request.CartID,
+		UserID:          request.UserID,
+		AccessTokenHash: hashAccessToken(accessToken),
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+	for _, item := range items {
+		product := products[item.ProductID]
+		line := models.OrderLine{
+			ProductID: product.ID,
+			Name:      product.Name,
+			Image:     product.Image,
+			Size:      item.Size,
+			Color:     item.Color,
+			Quantity:  item.Quantity,
+			UnitPrice: product.PriceFor(item.Size, item.Color),
+		}
+		if size, ok := findFold(product.Sizes, item.Size); ok {
+			line.Size = size
+		}
+		if color, ok := findFold(product.Colors, item.Color); ok {
+			line.Color = color
+		}
+		if v, ok := product.FindVariant(item.Size, item.Color); ok {
+			line.SKU = product.Variants[v].SKU
+		}
+		line.LineTotal = roundCents(line.UnitPrice * float64(line.Quantity))
+
+		order.Items = append(order.Items, line)
+		order.ItemCount += line.Quantity
+		order.Subtotal += line.LineTotal
+	}
+	order.Subtotal = roundCents(order.Subtotal)
+	order.Total = order.Subtotal
+
+	// Webhooks for the payment wait until the order is stored or abandoned
+	unlock := ors.locks.lock(id)
+	defer unlock()
+
+	// The gateway call doesn't follow the request context, so a client that
+	// hangs up doesn't leave an authorization half made
+	paymentCtx, cancel := context.WithTimeout(context.Background(), ors.paymentTimeout)
+	defer cancel()
+	payment, err := ors.gateway.Authorize(paymentCtx, payments.AuthorizeRequest{
+		OrderID: id,
+		Amount:  order.Total,
+		Token:   request.PaymentToken,
+	})
+	if err != nil {
+		ors.releaseReserved(ctx, id, items)
+		if errors.Is(err, ErrPaymentTimeout) {
+			ors.abandonAuthorization(ctx, id)
+		}
+		return models.Order{}, err
+	}
+	order.PaymentID = payment.ID
+	order.PaymentStatus = string(payment.Status)
+
+	if err := ors.repo.Save(order); err != nil {
+		// Don't keep stock or money held for an order that doesn't exist
+		ors.releaseReserved(ctx, id, items)
+		ors.voidPayment(ctx, id, payment.ID)
+		return models.Order{}, err
+	}
+
+	event := models.OrderEvent{OrderID: id, To: order.Status, Actor: PlacementActor, At: now}
+	if err := ors.repo.AppendEvent(event); err != nil {
+		// The order exists at this point, so a missing history entry isn't worth failing it
+		logger.LogErrorContext(ctx, "OrderService", "place", err, map[string]interface{}{
+			"order_id": id,
+		})
+	}
+	order.AccessToken = accessToken
+	return order, nil
+}
+
+// releaseReserved puts the stock reserved for an order that wasn't placed back
+func (ors *OrderService) releaseReserved(ctx context.Context, orderID string, items []models.CartItem) {
+	if err := ors.inventory.ReleaseItems(ctx, items); err != nil {
+		logger.LogErrorContext(ctx, "OrderService", "place", err, map[string]interface{}{
+			"order_id": orderID,
+			"rollback": true,
+		})
+	}
+}
+
+// abandonAuthorization remembers an order whose payment authorization timed
+// out, so a later webhook for it voids the payment instead of being rejected
+func (ors *OrderService) abandonAuthorization(ctx context.Context, orderID string) {
+	ors.abandonedMu.Lock()
+	defer ors.abandonedMu.Unlock()
+
+	now := time.Now()
+	for id, at := range ors.abandoned {
+		if now.Sub(at) > abandonedAuthorizationTTL {
+			delete(ors.abandoned, id)
+		}
+	}
+	ors.abandoned[orderID] = now
+
+	logger.WarnContext(ctx, "Payment authorization outcome unknown; waiting for its webhook", map[string]interface{}{
+		"order_id": orderID,
+	})
+}
+
+// voidPayment releases the payment of an order that wasn't placed. The gateway
+// call runs on a fresh context, since the one of the failed call may have
+// expired; failures are only logged.
+func (ors *OrderService) voidPayment(ctx context.Context, orderID, paymentID string) {
+	paymentCtx, cancel := context.WithTimeout(context.Background(), ors.paymentTimeout)
+	defer cancel()
+
+	if _, err := ors.gateway.Void(paymentCtx, paymentID); err != nil {
+		logger.LogErrorContext(ctx, "OrderService", "voidPayment", err, map[string]interface{}{
+			"order_id":   orderID,
+			"payment_id": paymentID,
+			"rollback":   true,
+		})
+		return
+	}
+	logger.InfoContext(ctx, "Payment of unplaced order voided", map[string]interface{}{
+		"order_id":   orderID,
+		"payment_id": paymentID,
+	})
+}
+
+// GetOrder returns an order the caller may read. Orders of other users, and
+// guest orders without their access token, are reported as ErrOrderNotFound.
+func (ors *OrderService) GetOrder(ctx context.Context, id string, access models.OrderAccess) (*models.Order, error) {
+	start := time.Now()
+
+	params := map[string]interface{}{"order_id": id, "user_id": access.UserID}
+	logger.LogServiceCallContext(ctx, "OrderService", "GetOrder", params)
+
+	order, err := ors.readableOrder(id, access)
+	if err != nil {
+		if !errors.Is(err, ErrOrderNotFound) {
+			logger.LogErrorContext(ctx, "OrderService", "GetOrder", err, params)
+		}
+		return nil, err
+	}
+
+	duration := float64(time.Since(start).Nanoseconds()) / 1e6
+	logger.LogServiceResultContext(ctx, "OrderService", "GetOrder", 1, duration)
+
+	return order, nil
+}
+
+// TransitionOrder moves an order to a new status and records the change.
+// Moves the lifecycle doesn't allow fail with ErrInvalidTransition. Paying
+// captures the order's payment, cancelling voids it and refunding refunds it;
+// cancelling or refunding an order before it ships also puts its items back in stock.
+func (ors *OrderService) TransitionOrder(ctx context.Context, id string, transition models.OrderTransition) (models.Order, error) {
+	start := time.Now()
+
+	params := map[string]interface{}{
+		"order_id": id,
+		"status":   transition.Status,
+		"actor":    transition.Actor,
+	}
+	logger.LogServiceCallContext(ctx, "OrderService", "TransitionOrder", params)
+
+	var problems []string
+	if !transition.Status.Valid() {
+		problems = append(problems, fmt.Sprintf("unknown status %q", transition.Status))
+	}
+	if strings.TrimSpace(transition.Actor) == "" {
+		problems = append(problems, "actor is required")
+	}
+	if len(problems) > 0 {
+		return models.Order{}, &models.ValidationError{Problems: problems}
+	}
+
+	unlock := ors.locks.lock(id)
+	defer unlock()
+
+	order, err := ors.repo.Get(id)
+	if err != nil {
+		if !errors.Is(err, ErrOrderNotFound) {
+			logger.LogErrorContext(ctx, "OrderService", "TransitionOrder", err, params)
+		}
+		return models.Order{}, err
+	}
+
+	if err := ors.transition(ctx, order, transition, true); err != nil {
+		if !errors.Is(err, ErrInvalidTransition) && !errors.Is(err, ErrPaymentDeclined) && !errors.Is(err, ErrPaymentState) {
+			logger.LogErrorContext(ctx, "OrderService", "TransitionOrder", err, params)
+		}
+		return models.Order{}, err
+	}
+
+	duration := float64(time.Since(start).Nanoseconds()) / 1e6
+	logger.LogServiceResultContext(ctx, "OrderService", "TransitionOrder", 1, duration)
+
+	return *order, nil
+}
+
+// transition moves an order to a new status, stores it and records the event.
+// The new status is stored before the payment is settled. With settle set, the
+// order's payment is then captured, voided or refunded, and if that fails the
+// order is put back as it was; changes reported by the gateway itself don't
+// settle again. Stock is released last, once the move can no longer fail.
+// Callers hold the lock of the order.
+func (ors *OrderService) transition(ctx context.Context, order *models.Order, transition models.OrderTransition, settle bool) error {
+	from := order.Status
+	if !from.CanTransitionTo(transition.Status) {
+		logger.WarnContext(ctx, "Order transition rejected", map[string]interface{}{
+			"order_id": order.ID,
+			"from":     from,
+			"to":       transition.Status,
+			"actor":    transition.Actor,
+		})
+		return fmt.Errorf("%w: %s order can move to %s", ErrInvalidTransition, from, describeStatuses(from.NextStatuses()))
+	}
+
+	previous := order.Clone()
+	now := time.Now().UTC()
+	order.Status = transition.Status
+	order.UpdatedAt = now
+	if err := ors.repo.Save(*order); err != nil {
+		*order = previous
+		return err
+	}
+
+	if settle {
+		settled, err := ors.settlePayment(order, transition.Status)
+		if err != nil {
+			ors.restore(ctx, order, previous)
+			return err
+		}
+		if settled {
+			if err := ors.repo.Save(*order); err != nil {
+				// The status change is stored; the payment webhook brings the payment status up to date
+				logger.LogErrorContext(ctx, "OrderService", "transition", err, map[string]interface{}{
+					"order_id":       order.ID,
+					"payment_status": order.PaymentStatus,
+				})
+			}
+		}
+	}
+
+	releases := transition.Status.ReleasesStock(from)
+	if releases {
+		items := make([]models.CartItem, len(order.Items))
+		for i, line := range order.Items {
+			items[i] = line.CartItem()
+		}
+		if err := ors.inventory.ReleaseItems(ctx, items); err != nil {
+			// The order and its payment have already moved; the stock needs fixing by hand
+			logger.LogErrorContext(ctx, "OrderService", "transition", err, map[string]interface{}{
+				"order_id":      order.ID,
+				"to":            transition.Status,
+				"stock_release": "failed",
+			})
+		}
+	}
+
+	event := models.OrderEvent{
+		OrderID: order.ID,
+		From:    from,
+		To:      transition.Status,
+		Actor:   strings.TrimSpace(transition.Actor),
+		Note:    transition.Note,
+		At:      now,
+	}
+	if err := ors.repo.AppendEvent(event); err != nil {
+		// The order has moved, so a missing history entry isn't worth failing it
+		logger.LogErrorContext(ctx, "OrderService", "transition", err, map[string]interface{}{
+			"order_id": order.ID,
+		})
+	}
+
+	logger.InfoContext(ctx, "Order status changed", map[string]interface{}{
+		"order_id":       order.ID,
+		"from":           from,
+		"to":             order.Status,
+		"actor":          event.Actor,
+		"payment_status": order.PaymentStatus,
+		"stock_released": releases,
+	})
+	return nil
+}
+
+// restore puts an order back as it was before a transition that failed to settle
+func (ors *OrderService) restore(ctx context.Context, order *models.Order, previous models.Order) {
+	*order = previous
+	if err := ors.repo.Save(previous); err != nil {
+		logger.LogErrorContext(ctx, "OrderService", "transition", err, map[string]interface{}{
+			"order_id": order.ID,
+			"status":   previous.Status,
+			"rollback": true,
+		})
+	}
+}
+
+// settlePayment makes the gateway call a move to status needs and records the
+// resulting payment status on the order. It reports whether a call was made.
+func (ors *OrderService) settlePayment(order *models.Order, status models.OrderStatus) (bool, error) {
+	if order.PaymentID == "" {
+		return false, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), ors.paymentTimeout)
+	defer cancel()
+
+	var payment payments.Payment
+	var err error
+	current := payments.Status(order.PaymentStatus)
+	switch {
+	case status == models.OrderStatusPaid && current == payments.StatusAuthorized:
+		payment, err = ors.gateway.Capture(ctx, order.PaymentID)
+	case status == models.OrderStatusCancelled && current == payments.StatusAuthorized:
+		payment, err = ors.gateway.Void(ctx, order.PaymentID)
+	case status == models.OrderStatusRefunded && current == payments.StatusCaptured:
+		payment, err = ors.gateway.Refund(ctx, order.PaymentID)
+	case status == models.OrderStatusRefunded:
+		return false, fmt.Errorf("%w: payment %s is %s", ErrPaymentState, order.PaymentID, current)
+	default:
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	order.PaymentStatus = string(payment.Status)
+	return true, nil
+}
+
+// HandlePaymentEvent applies a payment webhook to its order. A capture marks a
+// pending order paid, a void cancels it and a refund refunds it. Events for
+// changes the order already went through, including the ones this service made
+// itself, are acknowledged without effect, and so are authorizations of orders
+// that were never stored. Webhooks for an order being placed wait until it is.
+func (ors *OrderService) HandlePaymentEvent(ctx context.Context, event payments.Event) error {
+	start := time.Now()
+
+	params := map[string]interface{}{
+		"event_id":   event.ID,
+		"event_type": event.Type,
+		"payment_id": event.Payment.ID,
+		"order_id":   event.Payment.OrderID,
+	}
+	logger.LogServiceCallContext(ctx, "OrderService", "HandlePaymentEvent", params)
+
+	unlock := ors.locks.lock(event.Payment.OrderID)
+	defer unlock()
+
+	order, err := ors.repo.Get(event.Payment.OrderID)
+	if errors.Is(err, ErrOrderNotFound) && ors.isAbandoned(event.Payment.OrderID) {
+		ors.reconcileAbandoned(ctx, event)
+		return nil
+	}
+	if errors.Is(err, ErrOrderNotFound) && event.Type == payments.EventPaymentAuthorized {
+		// Authorizations change nothing, and their order may have failed to be stored
+		logger.DebugContext(ctx, "Ignoring authorization of unknown order", params)
+		return nil
+	}
+	if err != nil {
+		if !errors.Is(err, ErrOrderNotFound) {
+			logger.LogErrorContext(ctx, "OrderService", "HandlePaymentEvent", err, params)
+		}
+		return err
+	}
+	if order.PaymentID != event.Payment.ID {
+		return &models.ValidationError{Problems: []string{
+			fmt.Sprintf("payment %s does not belong to order %s", event.Payment.ID, order.ID),
+		}}
+	}
+
+	var target models.OrderStatus
+	switch event.Type {
+	case payments.EventPaymentAuthorized:
+		// Recorded when the order was placed
+	case payments.EventPaymentCaptured:
+		target = models.OrderStatusPaid
+	case payments.EventPaymentVoided:
+		target = models.OrderStatusCancelled
+	case payments.EventPaymentRefunded:
+		target = models.OrderStatusRefunded
+	default:
+		logger.WarnContext(ctx, "Ignoring unknown payment event", params)
+		return nil
+	}
+	if target == "" || !order.Status.CanTransitionTo(target) {
+		logger.DebugContext(ctx, "Payment event already applied", params)
+		return nil
+	}
+
+	order.PaymentStatus = string(event.Payment.Status)
+	err = ors.transition(ctx, order, models.OrderTransition{
+		Status: target,
+		Actor:  PaymentActor,
+		Note:   event.Type + " " + event.ID,
+	}, false)
+	if err != nil {
+		logger.LogErrorContext(ctx, "OrderService", "HandlePaymentEvent", err, params)
+		return err
+	}
+
+	duration := float64(time.Since(start).Nanoseconds()) / 1e6
+	logger.LogServiceResultContext(ctx, "OrderService", "HandlePaymentEvent", 1, duration)
+
+	return nil
+}
+
+// isAbandoned reports whether an order wasn't placed because authorizing its payment timed out
+func (ors *OrderService) isAbandoned(orderID string) bool {
+	ors.abandonedMu.Lock()
+	defer ors.abandonedMu.Unlock()
+
+	_, abandoned := ors.abandoned[orderID]
+	return abandoned
+}
+
+// reconcileAbandoned handles a webhook for an order that was never placed
+// because authorizing its payment timed out. An authorization that went
+// through after all is voided. Callers hold the lock of the order.
+func (ors *OrderService) reconcileAbandoned(ctx context.Context, event payments.Event) {
+	orderID := event.Payment.OrderID
+	switch event.Payment.Status {
+	case payments.StatusAuthorized:
+		ors.voidPayment(ctx, orderID, event.Payment.ID)
+	case payments.StatusVoided, payments.StatusRefunded:
+		ors.abandonedMu.Lock()
+		delete(ors.abandoned, orderID)
+		ors.abandonedMu.Unlock()
+	default:
+		logger.WarnContext(ctx, "Payment of unplaced order needs manual review", map[string]interface{}{
+			"order_id":       orderID,
+			"payment_id":     event.Payment.ID,
+			"payment_status": event.Payment.Status,
+			"event_id":       event.ID,
+		})
+	}
+}
+
+// OrderEvents returns the status history of an order the caller may read, oldest first
+func (ors *OrderService) OrderEvents(ctx context.Context, id string, access models.OrderAccess) ([]models.OrderEvent, error) {
+	start := time.Now()
+
+	params := map[string]interface{}{"order_id": id, "user_id": access.UserID}
+	logger.LogServiceCallContext(ctx, "OrderService", "OrderEvents", params)
+
+	if _, err := ors.readableOrder(id, access); err != nil {
+		if !errors.Is(err, ErrOrderNotFound) {
+			logger.LogErrorContext(ctx, "OrderService", "OrderEvents", err, params)
+		}
+		return nil, err
+	}
+
+	events, err := ors.repo.Events(id)
+	if err != nil {
+		if !errors.Is(err, ErrOrderNotFound) {
+			logger.LogErrorContext(ctx, "OrderService", "OrderEvents", err, params)
+		}
+		return nil, err
+	}
+
+	duration := float64(time.Since(start).Nanoseconds()) / 1e6
+	logger.LogServiceResultContext(ctx, "OrderService", "OrderEvents", len(events), duration)
+
+	return events, nil
+}
+
+// readableOrder loads an order and checks that the caller may read it: its
+// user, anyone allowed to manage all orders, or a guest with its access token
+func (ors *OrderService) readableOrder(id string, access models.OrderAccess) (*models.Order, error) {
+	order, err := ors.repo.Get(id)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case access.ManageAll:
+	case order.UserID != "":
+		if order.UserID != access.UserID {
+			return nil, ErrOrderNotFound
+		}
+	case order.AccessTokenHash == "" || access.AccessToken == "":
+		return nil, ErrOrderNotFound
+	default:
+		given := hashAccessToken(access.AccessToken)
+		if subtle.ConstantTimeCompare([]byte(given), []byte(order.AccessTokenHash)) != 1 {
+			return nil, ErrOrderNotFound
+		}
+	}
+	return order, nil
+}
+
+// hashAccessToken returns the stored form of a guest order access token
+func hashAccessToken(token string) string {
+	if token == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// describeStatuses lists statuses for an error message
+func describeStatuses(statuses []models.OrderStatus) string {
+	if len(statuses) == 0 {
+		return "no other status"
+	}
+	names := make([]string, len(statuses))
+	for i, status := range statuses {
+		names[i] = string(status)
+	}
+	return strings.Join(names, " or ")
+}
@@ -0,0 +1,306 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"ecommerce-backend/models"
+	"ecommerce-backend/payments"
+	"ecommerce-backend/repository"
+)
+
+// stubGateway authorizes every payment unless authorizeErr is set, fails
+// captures with captureErr, holds captures while captureHold is open and
+// records the orders and payments it sees
+type stubGateway struct {
+	mu           sync.Mutex
+	authorizeErr error
+	captureErr   error
+	captureHold  chan struct{}
+	authorized   []string
+	voided       []string
+}
+
+func (g *stubGateway) Authorize(ctx context.Context, request payments.AuthorizeRequest) (payments.Payment, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.authorized = append(g.authorized, request.OrderID)
+	if g.authorizeErr != nil {
+		return payments.Payment{}, g.authorizeErr
+	}
+	return payments.Payment{ID: "pay_" + request.OrderID, OrderID: request.OrderID, Status: payments.StatusAuthorized}, nil
+}
+
+func (g *stubGateway) Capture(ctx context.Context, paymentID string) (payments.Payment, error) {
+	if g.captureHold != nil {
+		<-g.captureHold
+	}
+	if g.captureErr != nil {
+		return payments.Payment{}, g.captureErr
+	}
+	return payments.Payment{ID: paymentID, Status: payments.StatusCaptured}, nil
+}
+
+func (g *stubGateway) Refund(ctx context.Context, paymentID string) (payments.Payment, error) {
+	return payments.Payment{ID: paymentID, Status: payments.StatusRefunded}, nil
+}
+
+func (g *stubGateway) Void(ctx context.Context, paymentID string) (payments.Payment, error) {
+	if ctx.Err() != nil {
+		return payments.Payment{}, ctx.Err()
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.voided = append(g.voided, paymentID)
+	return payments.Payment{ID: paymentID, Status: payments.StatusVoided}, nil
+}
+
+// newTestOrderService creates an OrderService over the sample catalog
+func newTestOrderService(t *testing.T, gateway payments.PaymentGateway) (*OrderService, *ProductService) {
+	t.Helper()
+	products, err := NewProductService(repository.NewSampleProductRepository())
+	if err != nil {
+		t.Fatalf("NewProductService: %v", err)
+	}
+	carts := NewCartService(repository.NewMemoryCartRepository(), products)
+	orders := NewOrderService(repository.NewMemoryOrderRepository(), carts, NewInventoryService(products), gateway, 10*time.Millisecond)
+	return orders, products
+}
+
+// testOrderRequest orders one sample product
+func testOrderRequest() models.OrderRequest {
+	return models.OrderRequest{
+		Items: []models.CartItem{{ProductID: 1, Size: "M", Color: "Black", Quantity: 1}},
+		ShippingAddress: models.Address{
+			Name: "Alice", Line1: "1 Main St", City: "Springfield", PostalCode: "12345", Country: "US",
+		},
+		PaymentToken: "tok_visa",
+		UserID:       "alice",
+	}
+}
+
+// TestPlaceOrderVoidsLateAuthorizationAfterTimeout checks that a payment the
+// gateway authorizes after the order gave up on it is voided when its webhook arrives
+func TestPlaceOrderVoidsLateAuthorizationAfterTimeout(t *testing.T) {
+	gateway := &stubGateway{authorizeErr: fmt.Errorf("%w: no answer", payments.ErrTimeout)}
+	orders, _ := newTestOrderService(t, gateway)
+	ctx := context.Background()
+
+	_, err := orders.PlaceOrder(ctx, testOrderRequest())
+	if !errors.Is(err, ErrPaymentTimeout) {
+		t.Fatalf("PlaceOrder error = %v, want ErrPaymentTimeout", err)
+	}
+	if len(gateway.authorized) != 1 {
+		t.Fatalf("gateway saw %d authorizations, want 1", len(gateway.authorized))
+	}
+	orderID := gateway.authorized[0]
+
+	late := payments.Event{
+		ID:   "evt_late",
+		Type: payments.EventPaymentAuthorized,
+		Payment: payments.Payment{
+			ID: "pay_late", OrderID: orderID, Amount: 29.99, Status: payments.StatusAuthorized,
+		},
+	}
+	if err := orders.HandlePaymentEvent(ctx, late); err != nil {
+		t.Fatalf("HandlePaymentEvent: %v", err)
+	}
+	if len(gateway.voided) != 1 || gateway.voided[0] != "pay_late" {
+		t.Fatalf("voided payments = %v, want [pay_late]", gateway.voided)
+	}
+
+	voided := late
+	voided.ID, voided.Type, voided.Payment.Status = "evt_voided", payments.EventPaymentVoided, payments.StatusVoided
+	if err := orders.HandlePaymentEvent(ctx, voided); err != nil {
+		t.Fatalf("HandlePaymentEvent for the void: %v", err)
+	}
+
+	// Once settled, the unplaced order is forgotten like any other unknown order
+	if err := orders.HandlePaymentEvent(ctx, late); err != nil {
+		t.Errorf("HandlePaymentEvent for a replayed authorization = %v, want nil", err)
+	}
+	if len(gateway.voided) != 1 {
+		t.Errorf("voided payments = %v after reconciling, want only pay_late", gateway.voided)
+	}
+	captured := late
+	captured.ID, captured.Type, captured.Payment.Status = "evt_captured", payments.EventPaymentCaptured, payments.StatusCaptured
+	if err := orders.HandlePaymentEvent(ctx, captured); !errors.Is(err, ErrOrderNotFound) {
+		t.Errorf("HandlePaymentEvent after reconciling = %v, want ErrOrderNotFound", err)
+	}
+}
+
+// TestTransitionOrderRestoresOrderWhenSettlingFails checks that an order whose
+// payment can't be captured stays as it was, with no event recorded
+func TestTransitionOrderRestoresOrderWhenSettlingFails(t *testing.T) {
+	gateway := &stubGateway{captureErr: fmt.Errorf("%w: no answer", payments.ErrTimeout)}
+	orders, _ := newTestOrderService(t, gateway)
+	ctx := context.Background()
+
+	order, err := orders.PlaceOrder(ctx, testOrderRequest())
+	if err != nil {
+		t.Fatalf("PlaceOrder: %v", err)
+	}
+
+	_, err = orders.TransitionOrder(ctx, order.ID, models.OrderTransition{Status: models.OrderStatusPaid, Actor: "admin"})
+	if !errors.Is(err, ErrPaymentTimeout) {
+		t.Fatalf("TransitionOrder error = %v, want ErrPaymentTimeout", err)
+	}
+
+	access := models.OrderAccess{UserID: "alice"}
+	stored, err := orders.GetOrder(ctx, order.ID, access)
+	if err != nil {
+		t.Fatalf("GetOrder: %v", err)
+	}
+	if stored.Status != models.OrderStatusPending || stored.PaymentStatus != string(payments.StatusAuthorized) {
+		t.Errorf("order is %s with payment %s, want pending with payment authorized", stored.Status, stored.PaymentStatus)
+	}
+	events, err := orders.OrderEvents(ctx, order.ID, access)
+	if err != nil {
+		t.Fatalf("OrderEvents: %v", err)
+	}
+	if len(events) != 1 {
+		t.Errorf("order has %d events, want only its placement", len(events))
+	}
+}
+
+// TestTransitionOrderCancelVoidsAndReleasesStock checks a successful move that
+// settles the payment and puts the stock back
+func TestTransitionOrderCancelVoidsAndReleasesStock(t *testing.T) {
+	gateway := &stubGateway{}
+	orders, products := newTestOrderService(t, gateway)
+	ctx := context.Background()
+
+	stock := func() int {
+		product, err := products.GetProductByID(ctx, 1)
+		if err != nil {
+			t.Fatalf("GetProductByID: %v", err)
+		}
+		v, ok := product.FindVariant("M", "Black")
+		if !ok {
+			t.Fatal("sample product 1 has no M/Black variant")
+		}
+		return product.Variants[v].Stock
+	}
+	before := stock()
+
+	order, err := orders.PlaceOrder(ctx, testOrderRequest())
+	if err != nil {
+		t.Fatalf("PlaceOrder: %v", err)
+	}
+	if got := stock(); got != before-1 {
+		t.Fatalf("stock after placing = %d, want %d", got, before-1)
+	}
+
+	cancelled, err := orders.TransitionOrder(ctx, order.ID, models.OrderTransition{Status: models.OrderStatusCancelled, Actor: "admin"})
+	if err != nil {
+		t.Fatalf("TransitionOrder: %v", err)
+	}
+	if cancelled.Status != models.OrderStatusCancelled || cancelled.PaymentStatus != string(payments.StatusVoided) {
+		t.Errorf("order is %s with payment %s, want cancelled with payment voided", cancelled.Status, cancelled.PaymentStatus)
+	}
+	if got := stock(); got != before {
+		t.Errorf("stock after cancelling = %d, want %d", got, before)
+	}
+
+	stored, err := orders.GetOrder(ctx, order.ID, models.OrderAccess{ManageAll: true})
+	if err != nil {
+		t.Fatalf("GetOrder: %v", err)
+	}
+	if stored.Status != models.OrderStatusCancelled || stored.PaymentStatus != string(payments.StatusVoided) {
+		t.Errorf("stored order is %s with payment %s, want cancelled with payment voided", stored.Status, stored.PaymentStatus)
+	}
+}
+
+// TestTransitionOrderLocksOnlyItsOrder checks that a transition waiting on the
+// payment gateway doesn't hold up changes to other orders
+func TestTransitionOrderLocksOnlyItsOrder(t *testing.T) {
+	gateway := &stubGateway{captureHold: make(chan struct{})}
+	orders, _ := newTestOrderService(t, gateway)
+	ctx := context.Background()
+
+	slow, err := orders.PlaceOrder(ctx, testOrderRequest())
+	if err != nil {
+		t.Fatalf("PlaceOrder: %v", err)
+	}
+	other, err := orders.PlaceOrder(ctx, testOrderRequest())
+	if err != nil {
+		t.Fatalf("PlaceOrder: %v", err)
+	}
+
+	slowDone := make(chan error)
+	go func() {
+		_, err := orders.TransitionOrder(ctx, slow.ID, models.OrderTransition{Status: models.OrderStatusPaid, Actor: "admin"})
+		slowDone <- err
+	}()
+
+	otherDone := make(chan error)
+	go func() {
+		_, err := orders.TransitionOrder(ctx, other.ID, models.OrderTransition{Status: models.OrderStatusCancelled, Actor: "admin"})
+		otherDone <- err
+	}()
+	select {
+	case err := <-otherDone:
+		if err != nil {
+			t.Fatalf("TransitionOrder on another order: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("TransitionOrder on another order waited for a capture")
+	}
+
+	close(gateway.captureHold)
+	if err := <-slowDone; err != nil {
+		t.Fatalf("TransitionOrder waiting on the capture: %v", err)
+	}
+	if len(orders.locks.locks) != 0 {
+		t.Errorf("%d order locks left behind", len(orders.locks.locks))
+	}
+}
+
+// TestAuthorizationWebhookWaitsForPlacement checks that an authorization
+// webhook sent while its order is being placed is handled once the order is stored
+func TestAuthorizationWebhookWaitsForPlacement(t *testing.T) {
+	gateway := &webhookGateway{stubGateway: &stubGateway{}}
+	orders, _ := newTestOrderService(t, gateway)
+	gateway.orders = orders
+	ctx := context.Background()
+
+	if _, err := orders.PlaceOrder(ctx, testOrderRequest()); err != nil {
+		t.Fatalf("PlaceOrder: %v", err)
+	}
+	if err := <-gateway.delivered; err != nil {
+		t.Errorf("authorization webhook failed: %v", err)
+	}
+}
+
+// webhookGateway delivers an authorization webhook from a goroutine as soon as
+// it authorizes, before Authorize returns
+type webhookGateway struct {
+	*stubGateway
+	orders    *OrderService
+	delivered chan error
+}
+
+func (g *webhookGateway) Authorize(ctx context.Context, request payments.AuthorizeRequest) (payments.Payment, error) {
+	payment, err := g.stubGateway.Authorize(ctx, request)
+	if err != nil {
+		return payment, err
+	}
+	g.delivered = make(chan error, 1)
+	go func() {
+		err := g.orders.HandlePaymentEvent(context.Background(), payments.Event{
+			ID: "evt_authorized", Type: payments.EventPaymentAuthorized, Payment: payment,
+		})
+		if err == nil {
+			// The webhook must not have been handled before the order was stored
+			_, err = g.orders.repo.Get(request.OrderID)
+		}
+		g.delivered <- err
+	}()
+	// Give the webhook a head start on storing the order
+	time.Sleep(20 * time.Millisecond)
+	return payment, nil
+}
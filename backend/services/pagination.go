@@ -0,0 +1,196 @@
+package services
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"ecommerce-backend/models"
+)
+
+// ErrInvalidPageRequest is returned when limit, offset, sort or cursor are malformed
+var ErrInvalidPageRequest = errors.New("invalid page request")
+
+// Orderings used when the client does not ask for one
+const (
+	DefaultSort       = "id"
+	DefaultSearchSort = "relevance"
+)
+
+// sortFields lists the product fields a listing can be sorted by
+var sortFields = map[string]bool{
+	"id":        true,
+	"name":      true,
+	"price":     true,
+	"relevance": true,
+}
+
+// productOrder is a parsed sort parameter such as "-price"
+type productOrder struct {
+	field      string
+	descending bool
+}
+
+// pageCursor is the decoded form of an opaque next_cursor value. It records the
+// sort key of the last item returned so the next page resumes after it even if
+// products were inserted or removed in between.
+type pageCursor struct {
+	Sort  string  `json:"s"`
+	ID    int     `json:"i"`
+	Name  string  `json:"n,omitempty"`
+	Price float64 `json:"p,omitempty"`
+	Score float64 `json:"r,omitempty"`
+}
+
+// PaginateProducts sorts a listing and returns the requested page with its envelope.
+// Text searches default to relevance order, everything else to ID order.
+func PaginateProducts(listing models.ProductListing, req models.PageRequest, filter models.ProductFilter) (models.ProductPage, error) {
+	products := listing.Hits
+	if req.Sort == "" {
+		req.Sort = DefaultSort
+		if filter.Query != "" {
+			req.Sort = DefaultSearchSort
+		}
+	}
+	if req.Limit == 0 {
+		req.Limit = models.DefaultPageLimit
+	}
+
+	order, err := parseProductOrder(req.Sort)
+	if err != nil {
+		return models.ProductPage{}, err
+	}
+	if req.Limit < 0 || req.Limit > models.MaxPageLimit {
+		return models.ProductPage{}, fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidPageRequest, models.MaxPageLimit)
+	}
+	if req.Offset < 0 {
+		return models.ProductPage{}, fmt.Errorf("%w: offset must not be negative", ErrInvalidPageRequest)
+	}
+	if req.Cursor != "" && req.Offset != 0 {
+		return models.ProductPage{}, fmt.Errorf("%w: cursor and offset cannot be combined", ErrInvalidPageRequest)
+	}
+
+	sorted := make([]models.ProductHit, len(products))
+	copy(sorted, products)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return order.compare(sorted[i], sorted[j]) < 0
+	})
+
+	start := req.Offset
+	if req.Cursor != "" {
+		cursor, err := decodeCursor(req.Cursor)
+		if err != nil {
+			return models.ProductPage{}, err
+		}
+		if cursor.Sort != req.Sort {
+			return models.ProductPage{}, fmt.Errorf("%w: cursor was issued for sort %q", ErrInvalidPageRequest, cursor.Sort)
+		}
+		last := models.ProductHit{
+			Product: models.Product{ID: cursor.ID, Name: cursor.Name, Price: cursor.Price},
+			Score:   cursor.Score,
+		}
+		start = sort.Search(len(sorted), func(i int) bool {
+			return order.compare(sorted[i], last) > 0
+		})
+	}
+	if start > len(sorted) {
+		start = len(sorted)
+	}
+	end := start + req.Limit
+	if end > len(sorted) {
+		end = len(sorted)
+	}
+
+	page := models.ProductPage{
+		Items:       sorted[start:end],
+		Total:       len(sorted),
+		Limit:       req.Limit,
+		Offset:      start,
+		Sort:        req.Sort,
+		Filters:     filter,
+		Facets:      listing.Facets,
+		Suggestions: listing.Suggestions,
+	}
+	if end < len(sorted) {
+		page.NextCursor = encodeCursor(req.Sort, sorted[end-1])
+	}
+	return page, nil
+}
+
+// parseProductOrder parses sort values such as "price" or "-price"
+func parseProductOrder(value string) (productOrder, error) {
+	order := productOrder{field: value}
+	if strings.HasPrefix(value, "-") {
+		order.field = value[1:]
+		order.descending = true
+	}
+	if !sortFields[order.field] {
+		return productOrder{}, fmt.Errorf("%w: sort must be one of id, name, price, relevance (prefix with - to reverse)", ErrInvalidPageRequest)
+	}
+	return order, nil
+}
+
+// compare orders two products by the sort field, breaking ties by ID.
+// Relevance sorts best match first; "-relevance" reverses it.
+func (o productOrder) compare(a, b models.ProductHit) int {
+	result := 0
+	switch o.field {
+	case "relevance":
+		result = compareFloats(b.Score, a.Score)
+	case "price":
+		result = compareFloats(a.Price, b.Price)
+	case "name":
+		result = strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+	}
+	if o.descending {
+		result = -result
+	}
+	if result != 0 {
+		return result
+	}
+
+	if o.field == "id" && o.descending {
+		return b.ID - a.ID
+	}
+	return a.ID - b.ID
+}
+
+// compareFloats returns -1, 0 or 1 like strings.Compare
+func compareFloats(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// encodeCursor builds the opaque cursor pointing just after product
+func encodeCursor(sortValue string, hit models.ProductHit) string {
+	data, _ := json.Marshal(pageCursor{
+		Sort:  sortValue,
+		ID:    hit.ID,
+		Name:  hit.Name,
+		Price: hit.Price,
+		Score: hit.Score,
+	})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor parses a cursor produced by encodeCursor
+func decodeCursor(value string) (pageCursor, error) {
+	var cursor pageCursor
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return cursor, fmt.Errorf("%w: malformed cursor", ErrInvalidPageRequest)
+	}
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return cursor, fmt.Errorf("%w: malformed cursor", ErrInvalidPageRequest)
+	}
+	return cursor, nil
+}
@@ -0,0 +1,115 @@
+package services
+
+import (
+	"math"
+
+	"ecommerce-backend/models"
+	"ecommerce-backend/search"
+)
+
+// snippetLength caps the length of highlighted description snippets
+const snippetLength = 160
+
+// productIndexFields are the indexed product fields; name matches weigh more than description matches
+var productIndexFields = []search.Field{
+	{Name: "name", Weight: 3.0},
+	{Name: "description", Weight: 1.0},
+}
+
+// newProductIndex creates an empty full-text index for products
+func newProductIndex() *search.Index {
+	return search.NewIndex(productIndexFields...)
+}
+
+// indexProduct adds or refreshes a product in the search index and autocomplete trie
+func (ps *ProductService) indexProduct(product models.Product) {
+	ps.index.Upsert(product.ID, map[string]string{
+		"name":        product.Name,
+		"description": product.Description,
+	})
+	ps.completer.Upsert(product.ID, completionEntries(product))
+}
+
+// unindexProduct removes a product from the search index and autocomplete trie
+func (ps *ProductService) unindexProduct(id int) {
+	ps.index.Remove(id)
+	ps.completer.Remove(id)
+}
+
+// searchProducts ranks products matching filter.Query with the inverted index and
+// applies the remaining filter constraints. Misspelled query words are matched
+// against similar words in the catalog, and when nothing matched exactly the
+// listing carries "did you mean" suggestions. Callers must hold the read lock.
+func (ps *ProductService) searchProducts(filter models.ProductFilter) (models.ProductListing, error) {
+	result := ps.index.Search(filter.Query)
+	listing := models.ProductListing{
+		Hits:        []models.ProductHit{},
+		Facets:      models.ComputeFacets(nil, filter),
+		Suggestions: result.Suggestions,
+	}
+	if len(result.Hits) == 0 {
+		return listing, nil
+	}
+
+	facets, err := ps.searchFacets(result, filter)
+	if err != nil {
+		return models.ProductListing{}, err
+	}
+	listing.Facets = facets
+
+	structured := filter
+	structured.Query = ""
+	candidates, err := ps.repo.Filter(structured)
+	if err != nil {
+		return models.ProductListing{}, err
+	}
+	byID := make(map[int]models.Product, len(candidates))
+	for _, product := range candidates {
+		byID[product.ID] = product
+	}
+
+	for _, ranking := range result.Hits {
+		product, ok := byID[ranking.ID]
+		if !ok {
+			continue
+		}
+		listing.Hits = append(listing.Hits, models.ProductHit{
+			Product:    product,
+			Score:      math.Round(ranking.Score*1000) / 1000,
+			Highlights: highlightProduct(product, result.Terms),
+		})
+	}
+	return listing, nil
+}
+
+// searchFacets computes the facet counts over the products matching the text
+// query. Callers must hold the read lock.
+func (ps *ProductService) searchFacets(result search.Result, filter models.ProductFilter) (models.ProductFacets, error) {
+	products, err := ps.repo.List()
+	if err != nil {
+		return models.ProductFacets{}, err
+	}
+	matched := make(map[int]bool, len(result.Hits))
+	for _, hit := range result.Hits {
+		matched[hit.ID] = true
+	}
+	var found []models.Product
+	for _, product := range products {
+		if matched[product.ID] {
+			found = append(found, product)
+		}
+	}
+	return models.ComputeFacets(found, filter), nil
+}
+
+// highlightProduct builds the highlighted name and description snippets for a hit
+func highlightProduct(product models.Product, terms []string) map[string]string {
+	highlights := make(map[string]string)
+	if snippet, ok := search.HighlightTerms(product.Name, terms, 0); ok {
+		highlights["name"] = snippet
+	}
+	if snippet, ok := search.HighlightTerms(product.Description, terms, snippetLength); ok {
+		highlights["description"] = snippet
+	}
+	return highlights
+}
@@ -1,115 +1,212 @@
 package services
 
 import (
-	"strings"
+	"context"
+	"errors"
+	"sync"
 	"time"
-	
+
 	"ecommerce-backend/logger"
 	"ecommerce-backend/models"
+	"ecommerce-backend/repository"
+	"ecommerce-backend/search"
 )
 
-// ProductService handles all product-related business logic
+// ErrProductNotFound is returned when a requested product does not exist
+var ErrProductNotFound = repository.ErrProductNotFound
+
+// ProductService handles all product-related business logic.
+// It is safe for concurrent use: reads share a read lock while mutations take
+// the write lock, so a list or search never observes a half-applied change
+// and read-modify-write operations such as PatchProduct are atomic.
 type ProductService struct {
-	products []models.Product
+	mu        sync.RWMutex
+	repo      repository.ProductRepository
+	index     *search.Index
+	completer *search.Trie
 }
 
-// NewProductService creates a new instance of ProductService
-func NewProductService() *ProductService {
-	return &ProductService{
-		products: models.GetSampleProducts(),
+// NewProductService creates a new instance of ProductService backed by the given
+// repository and builds the search index and autocomplete trie from its current contents
+func NewProductService(repo repository.ProductRepository) (*ProductService, error) {
+	ps := &ProductService{
+		repo:      repo,
+		index:     newProductIndex(),
+		completer: search.NewTrie(),
+	}
+
+	products, err := repo.List()
+	if err != nil {
+		logger.LogError("ProductService", "NewProductService", err, nil)
+		return nil, err
 	}
+	for _, product := range products {
+		ps.indexProduct(product)
+	}
+
+	logger.Info("Product search index built", map[string]interface{}{
+		"service":       "ProductService",
+		"indexed_count": ps.index.Len(),
+	})
+
+	return ps, nil
 }
 
-// GetAllProducts returns all products with optional filtering
-func (ps *ProductService) GetAllProducts(gender, category string) []models.Product {
+// ListProducts returns the products matching every constraint in the filter.
+// When the filter has a text query, results are ranked by relevance and carry
+// a score and highlighted snippets; otherwise they come back in storage order.
+// The listing also carries facet counts for building filter controls.
+func (ps *ProductService) ListProducts(ctx context.Context, filter models.ProductFilter) (models.ProductListing, error) {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
 	start := time.Now()
-	
-	// Log service call
-	params := map[string]interface{}{
-		"gender":   gender,
-		"category": category,
-	}
-	logger.LogServiceCall("ProductService", "GetAllProducts", params)
 
-	filteredProducts := ps.products
+	// Log service call
+	params := filterParams(filter)
+	logger.LogServiceCallContext(ctx, "ProductService", "ListProducts", params)
+
+	var listing models.ProductListing
+	if filter.Query == "" {
+		products, err := ps.repo.Filter(filter)
+		if err != nil {
+			logger.LogErrorContext(ctx, "ProductService", "ListProducts", err, params)
+			return models.ProductListing{}, err
+		}
+		listing.Hits = make([]models.ProductHit, 0, len(products))
+		for _, product := range products {
+			listing.Hits = append(listing.Hits, models.ProductHit{Product: product})
+		}
 
-	// Filter by gender if specified
-	if gender != "" {
-		filteredProducts = ps.filterByGender(filteredProducts, gender)
-		logger.Debug("Applied gender filter", map[string]interface{}{
-			"gender":           gender,
-			"filtered_count":   len(filteredProducts),
-			"original_count":   len(ps.products),
-		})
+		all, err := ps.repo.List()
+		if err != nil {
+			logger.LogErrorContext(ctx, "ProductService", "ListProducts", err, params)
+			return models.ProductListing{}, err
+		}
+		listing.Facets = models.ComputeFacets(all, filter)
+	} else {
+		var err error
+		listing, err = ps.searchProducts(filter)
+		if err != nil {
+			logger.LogErrorContext(ctx, "ProductService", "ListProducts", err, params)
+			return models.ProductListing{}, err
+		}
+		if len(listing.Suggestions) > 0 {
+			logger.DebugContext(ctx, "No exact search matches, offering suggestions", map[string]interface{}{
+				"service":     "ProductService",
+				"query":       filter.Query,
+				"suggestions": listing.Suggestions,
+				"fuzzy_count": len(listing.Hits),
+			})
+		}
 	}
+	hits := listing.Hits
 
-	// Filter by category if specified
-	if category != "" {
-		originalCount := len(filteredProducts)
-		filteredProducts = ps.filterByCategory(filteredProducts, category)
-		logger.Debug("Applied category filter", map[string]interface{}{
-			"category":         category,
-			"filtered_count":   len(filteredProducts),
-			"before_filter":    originalCount,
-		})
-	}
+	logger.DebugContext(ctx, "Applied product filter", map[string]interface{}{
+		"service":        "ProductService",
+		"filter":         params,
+		"filtered_count": len(hits),
+	})
 
 	// Log result
 	duration := float64(time.Since(start).Nanoseconds()) / 1e6
-	logger.LogServiceResult("ProductService", "GetAllProducts", len(filteredProducts), duration)
+	logger.LogServiceResultContext(ctx, "ProductService", "ListProducts", len(hits), duration)
 
-	logger.Info("Products retrieved successfully", map[string]interface{}{
-		"count":    len(filteredProducts),
-		"gender":   gender,
-		"category": category,
+	logger.InfoContext(ctx, "Products retrieved successfully", map[string]interface{}{
+		"service": "ProductService",
+		"count":   len(hits),
+		"filter":  params,
 	})
 
-	return filteredProducts
+	return listing, nil
 }
 
-// GetProductByID returns a product by its ID
-func (ps *ProductService) GetProductByID(id int) (*models.Product, bool) {
+// GetProductByID returns a product by its ID, or ErrProductNotFound
+func (ps *ProductService) GetProductByID(ctx context.Context, id int) (*models.Product, error) {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
 	start := time.Now()
-	
+
 	// Log service call
 	params := map[string]interface{}{
 		"product_id": id,
 	}
-	logger.LogServiceCall("ProductService", "GetProductByID", params)
-
-	for _, product := range ps.products {
-		if product.ID == id {
-			duration := float64(time.Since(start).Nanoseconds()) / 1e6
-			logger.LogServiceResult("ProductService", "GetProductByID", 1, duration)
-			
-			logger.Info("Product found", map[string]interface{}{
-				"product_id":   id,
-				"product_name": product.Name,
-				"category":     product.Category,
+	logger.LogServiceCallContext(ctx, "ProductService", "GetProductByID", params)
+
+	product, err := ps.repo.GetByID(id)
+	duration := float64(time.Since(start).Nanoseconds()) / 1e6
+
+	if errors.Is(err, ErrProductNotFound) {
+		logger.LogServiceResultContext(ctx, "ProductService", "GetProductByID", 0, duration)
+
+		logger.WarnContext(ctx, "Product not found", map[string]interface{}{
+			"service":    "ProductService",
+			"product_id": id,
+		})
+
+		return nil, err
+	}
+	if err != nil {
+		logger.LogErrorContext(ctx, "ProductService", "GetProductByID", err, params)
+		return nil, err
+	}
+
+	logger.LogServiceResultContext(ctx, "ProductService", "GetProductByID", 1, duration)
+
+	logger.InfoContext(ctx, "Product found", map[string]interface{}{
+		"service":      "ProductService",
+		"product_id":   id,
+		"product_name": product.Name,
+		"category":     product.Category,
+	})
+
+	return product, nil
+}
+
+// lookupProducts returns the existing products among ids, keyed by ID. It is
+// used internally (e.g. to price carts) and skips the per-call request logging.
+func (ps *ProductService) lookupProducts(ctx context.Context, ids []int) (map[int]models.Product, error) {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
+	products := make(map[int]models.Product, len(ids))
+	for _, id := range ids {
+		if _, seen := products[id]; seen {
+			continue
+		}
+		product, err := ps.repo.GetByID(id)
+		if errors.Is(err, ErrProductNotFound) {
+			continue
+		}
+		if err != nil {
+			logger.LogErrorContext(ctx, "ProductService", "lookupProducts", err, map[string]interface{}{
+				"product_id": id,
 			})
-			
-			return &product, true
+			return nil, err
 		}
+		products[id] = *product
 	}
-	
-	duration := float64(time.Since(start).Nanoseconds()) / 1e6
-	logger.LogServiceResult("ProductService", "GetProductByID", 0, duration)
-	
-	logger.Warn("Product not found", map[string]interface{}{
-		"product_id": id,
-	})
-	
-	return nil, false
+	return products, nil
 }
 
 // GetCategories returns all unique categories
-func (ps *ProductService) GetCategories() []string {
+func (ps *ProductService) GetCategories(ctx context.Context) ([]string, error) {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
 	start := time.Now()
-	
-	logger.LogServiceCall("ProductService", "GetCategories", map[string]interface{}{})
+
+	logger.LogServiceCallContext(ctx, "ProductService", "GetCategories", map[string]interface{}{})
+
+	products, err := ps.repo.List()
+	if err != nil {
+		logger.LogErrorContext(ctx, "ProductService", "GetCategories", err, nil)
+		return nil, err
+	}
 
 	categoryMap := make(map[string]bool)
-	for _, product := range ps.products {
+	for _, product := range products {
 		categoryMap[product.Category] = true
 	}
 
@@ -119,20 +216,30 @@ func (ps *ProductService) GetCategories() []string {
 	}
 
 	duration := float64(time.Since(start).Nanoseconds()) / 1e6
-	logger.LogServiceResult("ProductService", "GetCategories", len(categories), duration)
+	logger.LogServiceResultContext(ctx, "ProductService", "GetCategories", len(categories), duration)
 
-	logger.Info("Categories retrieved", map[string]interface{}{
+	logger.InfoContext(ctx, "Categories retrieved", map[string]interface{}{
+		"service":          "ProductService",
 		"categories_count": len(categories),
 		"categories":       categories,
 	})
 
-	return categories
+	return categories, nil
 }
 
 // GetGenders returns all unique genders
-func (ps *ProductService) GetGenders() []string {
+func (ps *ProductService) GetGenders(ctx context.Context) ([]string, error) {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
+	products, err := ps.repo.List()
+	if err != nil {
+		logger.LogErrorContext(ctx, "ProductService", "GetGenders", err, nil)
+		return nil, err
+	}
+
 	genderMap := make(map[string]bool)
-	for _, product := range ps.products {
+	for _, product := range products {
 		genderMap[product.Gender] = true
 	}
 
@@ -141,92 +248,216 @@ func (ps *ProductService) GetGenders() []string {
 		genders = append(genders, gender)
 	}
 
-	return genders
+	return genders, nil
 }
 
-// filterByGender filters products by gender
-func (ps *ProductService) filterByGender(products []models.Product, gender string) []models.Product {
-	var filtered []models.Product
-	for _, product := range products {
-		if product.Gender == gender {
-			filtered = append(filtered, product)
-		}
+// CreateProduct validates and stores a new product; the ID is assigned by the repository
+func (ps *ProductService) CreateProduct(ctx context.Context, product models.Product) (models.Product, error) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	start := time.Now()
+
+	params := map[string]interface{}{
+		"product_name": product.Name,
+		"category":     product.Category,
+		"gender":       product.Gender,
+	}
+	logger.LogServiceCallContext(ctx, "ProductService", "CreateProduct", params)
+
+	if err := product.Validate(); err != nil {
+		logger.WarnContext(ctx, "Product validation failed", map[string]interface{}{
+			"service": "ProductService",
+			"method":  "CreateProduct",
+			"error":   err.Error(),
+		})
+		return models.Product{}, err
+	}
+
+	product.ID = 0
+	product.EnsureSlices()
+	product.SyncInventory()
+	created, err := ps.repo.Create(product)
+	if err != nil {
+		logger.LogErrorContext(ctx, "ProductService", "CreateProduct", err, params)
+		return models.Product{}, err
 	}
-	return filtered
+	ps.indexProduct(created)
+
+	duration := float64(time.Since(start).Nanoseconds()) / 1e6
+	logger.LogServiceResultContext(ctx, "ProductService", "CreateProduct", 1, duration)
+
+	logger.InfoContext(ctx, "Product created", map[string]interface{}{
+		"service":      "ProductService",
+		"product_id":   created.ID,
+		"product_name": created.Name,
+	})
+
+	return created, nil
 }
 
-// filterByCategory filters products by category
-func (ps *ProductService) filterByCategory(products []models.Product, category string) []models.Product {
-	var filtered []models.Product
-	for _, product := range products {
-		if product.Category == category {
-			filtered = append(filtered, product)
+// UpdateProduct validates and replaces the product with the given ID
+func (ps *ProductService) UpdateProduct(ctx context.Context, id int, product models.Product) (models.Product, error) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
+	start := time.Now()
+
+	params := map[string]interface{}{
+		"product_id":   id,
+		"product_name": product.Name,
+	}
+	logger.LogServiceCallContext(ctx, "ProductService", "UpdateProduct", params)
+
+	product.ID = id
+	if err := product.Validate(); err != nil {
+		logger.WarnContext(ctx, "Product validation failed", map[string]interface{}{
+			"service":    "ProductService",
+			"method":     "UpdateProduct",
+			"product_id": id,
+			"error":      err.Error(),
+		})
+		return models.Product{}, err
+	}
+
+	product.EnsureSlices()
+	product.SyncInventory()
+	updated, err := ps.repo.Update(product)
+	if err != nil {
+		if !errors.Is(err, ErrProductNotFound) {
+			logger.LogErrorContext(ctx, "ProductService", "UpdateProduct", err, params)
 		}
+		return models.Product{}, err
 	}
-	return filtered
+	ps.indexProduct(updated)
+
+	duration := float64(time.Since(start).Nanoseconds()) / 1e6
+	logger.LogServiceResultContext(ctx, "ProductService", "UpdateProduct", 1, duration)
+
+	logger.InfoContext(ctx, "Product updated", map[string]interface{}{
+		"service":      "ProductService",
+		"product_id":   updated.ID,
+		"product_name": updated.Name,
+	})
+
+	return updated, nil
 }
 
-// GetProductsByPriceRange returns products within a price range
-func (ps *ProductService) GetProductsByPriceRange(minPrice, maxPrice float64) []models.Product {
+// PatchProduct applies a partial update to the product with the given ID
+func (ps *ProductService) PatchProduct(ctx context.Context, id int, patch models.ProductPatch) (models.Product, error) {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
 	start := time.Now()
-	
+
 	params := map[string]interface{}{
-		"min_price": minPrice,
-		"max_price": maxPrice,
+		"product_id": id,
+	}
+	logger.LogServiceCallContext(ctx, "ProductService", "PatchProduct", params)
+
+	existing, err := ps.repo.GetByID(id)
+	if err != nil {
+		if !errors.Is(err, ErrProductNotFound) {
+			logger.LogErrorContext(ctx, "ProductService", "PatchProduct", err, params)
+		}
+		return models.Product{}, err
+	}
+
+	patched := patch.Apply(*existing)
+	patched.EnsureSlices()
+	if patch.Variants == nil {
+		// Keep the stock of sizes and colors that remain after the patch
+		patched.SyncInventory()
+	}
+	if err := patched.Validate(); err != nil {
+		logger.WarnContext(ctx, "Product validation failed", map[string]interface{}{
+			"service":    "ProductService",
+			"method":     "PatchProduct",
+			"product_id": id,
+			"error":      err.Error(),
+		})
+		return models.Product{}, err
 	}
-	logger.LogServiceCall("ProductService", "GetProductsByPriceRange", params)
+	patched.SyncInventory()
 
-	var filtered []models.Product
-	for _, product := range ps.products {
-		if product.Price >= minPrice && product.Price <= maxPrice {
-			filtered = append(filtered, product)
+	updated, err := ps.repo.Update(patched)
+	if err != nil {
+		if !errors.Is(err, ErrProductNotFound) {
+			logger.LogErrorContext(ctx, "ProductService", "PatchProduct", err, params)
 		}
+		return models.Product{}, err
 	}
+	ps.indexProduct(updated)
 
 	duration := float64(time.Since(start).Nanoseconds()) / 1e6
-	logger.LogServiceResult("ProductService", "GetProductsByPriceRange", len(filtered), duration)
+	logger.LogServiceResultContext(ctx, "ProductService", "PatchProduct", 1, duration)
 
-	logger.Info("Price range filter applied", map[string]interface{}{
-		"min_price":     minPrice,
-		"max_price":     maxPrice,
-		"results_count": len(filtered),
-		"total_products": len(ps.products),
+	logger.InfoContext(ctx, "Product patched", map[string]interface{}{
+		"service":      "ProductService",
+		"product_id":   updated.ID,
+		"product_name": updated.Name,
 	})
 
-	return filtered
+	return updated, nil
 }
 
-// SearchProducts searches products by name or description
-func (ps *ProductService) SearchProducts(query string) []models.Product {
+// DeleteProduct removes the product with the given ID
+func (ps *ProductService) DeleteProduct(ctx context.Context, id int) error {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+
 	start := time.Now()
-	
+
 	params := map[string]interface{}{
-		"search_query": query,
+		"product_id": id,
 	}
-	logger.LogServiceCall("ProductService", "SearchProducts", params)
+	logger.LogServiceCallContext(ctx, "ProductService", "DeleteProduct", params)
 
-	var filtered []models.Product
-	for _, product := range ps.products {
-		if containsIgnoreCase(product.Name, query) || containsIgnoreCase(product.Description, query) {
-			filtered = append(filtered, product)
+	if err := ps.repo.Delete(id); err != nil {
+		if !errors.Is(err, ErrProductNotFound) {
+			logger.LogErrorContext(ctx, "ProductService", "DeleteProduct", err, params)
 		}
+		return err
 	}
+	ps.unindexProduct(id)
 
 	duration := float64(time.Since(start).Nanoseconds()) / 1e6
-	logger.LogServiceResult("ProductService", "SearchProducts", len(filtered), duration)
+	logger.LogServiceResultContext(ctx, "ProductService", "DeleteProduct", 1, duration)
 
-	logger.Info("Product search completed", map[string]interface{}{
-		"search_query":  query,
-		"results_count": len(filtered),
-		"total_products": len(ps.products),
+	logger.InfoContext(ctx, "Product deleted", map[string]interface{}{
+		"service":    "ProductService",
+		"product_id": id,
 	})
 
-	return filtered
+	return nil
 }
 
-// Helper function to check if a string contains another string (case insensitive)
-func containsIgnoreCase(str, substr string) bool {
-	strLower := strings.ToLower(str)
-	substrLower := strings.ToLower(substr)
-	return strings.Contains(strLower, substrLower)
-}
\ No newline at end of file
+// filterParams flattens the non-empty filter fields for logging
+func filterParams(filter models.ProductFilter) map[string]interface{} {
+	params := map[string]interface{}{}
+	if filter.Gender != "" {
+		params["gender"] = filter.Gender
+	}
+	if len(filter.Categories) > 0 {
+		params["categories"] = filter.Categories
+	}
+	if filter.MinPrice != nil {
+		params["min_price"] = *filter.MinPrice
+	}
+	if filter.MaxPrice != nil {
+		params["max_price"] = *filter.MaxPrice
+	}
+	if filter.Query != "" {
+		params["search_query"] = filter.Query
+	}
+	if filter.Size != "" {
+		params["size"] = filter.Size
+	}
+	if filter.Color != "" {
+		params["color"] = filter.Color
+	}
+	if filter.InStock != nil {
+		params["in_stock"] = *filter.InStock
+	}
+	return params
+}
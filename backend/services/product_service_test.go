@@ -0,0 +1,165 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"ecommerce-backend/logger"
+	"ecommerce-backend/models"
+	"ecommerce-backend/repository"
+)
+
+func TestMain(m *testing.M) {
+	if err := logger.Init(logger.LogConfig{Level: "error", Output: "stdout"}); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	logger.Logger.SetOutput(io.Discard)
+	os.Exit(m.Run())
+}
+
+// stressProduct is a product whose name and description both carry version,
+// so a reader can tell whether it saw a half-applied update
+func stressProduct(writer, version int) models.Product {
+	return models.Product{
+		Name:        fmt.Sprintf("Stresstest shirt w%d v%d", writer, version),
+		Description: fmt.Sprintf("w%d v%d", writer, version),
+		Price:       float64(10 + version),
+		Category:    "shirts",
+		Gender:      "men",
+		Sizes:       []string{"M"},
+		Colors:      []string{"Black"},
+		InStock:     true,
+	}
+}
+
+// checkStressHit fails the test if a listed product is a torn write
+func checkStressHit(t *testing.T, product models.Product) {
+	if !strings.HasPrefix(product.Name, "Stresstest shirt ") {
+		return
+	}
+	if want := strings.TrimPrefix(product.Name, "Stresstest shirt "); product.Description != want {
+		t.Errorf("product %d: name %q does not match description %q", product.ID, product.Name, product.Description)
+	}
+}
+
+// TestProductServiceConcurrentReadsAndWrites runs listings, searches and
+// suggestions while products are created, updated, patched and deleted. Run
+// it with -race.
+func TestProductServiceConcurrentReadsAndWrites(t *testing.T) {
+	ps, err := NewProductService(repository.NewSampleProductRepository())
+	if err != nil {
+		t.Fatalf("NewProductService: %v", err)
+	}
+	ctx := context.Background()
+
+	const (
+		writers    = 4
+		readers    = 4
+		iterations = 20
+	)
+
+	var writersWG, readersWG sync.WaitGroup
+	done := make(chan struct{})
+
+	for w := 0; w < writers; w++ {
+		writersWG.Add(1)
+		go func(w int) {
+			defer writersWG.Done()
+			for i := 0; i < iterations; i++ {
+				created, err := ps.CreateProduct(ctx, stressProduct(w, 0))
+				if err != nil {
+					t.Errorf("CreateProduct: %v", err)
+					return
+				}
+				if _, err := ps.UpdateProduct(ctx, created.ID, stressProduct(w, 1)); err != nil {
+					t.Errorf("UpdateProduct %d: %v", created.ID, err)
+					return
+				}
+				price := 99.0
+				if _, err := ps.PatchProduct(ctx, created.ID, models.ProductPatch{Price: &price}); err != nil {
+					t.Errorf("PatchProduct %d: %v", created.ID, err)
+					return
+				}
+				// Keep every other product so readers see a growing catalog
+				if i%2 == 0 {
+					if err := ps.DeleteProduct(ctx, created.ID); err != nil {
+						t.Errorf("DeleteProduct %d: %v", created.ID, err)
+						return
+					}
+					if _, err := ps.GetProductByID(ctx, created.ID); !errors.Is(err, ErrProductNotFound) {
+						t.Errorf("GetProductByID %d after delete: %v, want ErrProductNotFound", created.ID, err)
+					}
+				}
+			}
+		}(w)
+	}
+
+	for r := 0; r < readers; r++ {
+		readersWG.Add(1)
+		go func(r int) {
+			defer readersWG.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+
+				filter := models.ProductFilter{}
+				switch r % 3 {
+				case 1:
+					filter.Query = "stresstest shirt"
+				case 2:
+					filter.Categories = []string{"shirts"}
+				}
+				listing, err := ps.ListProducts(ctx, filter)
+				if err != nil {
+					t.Errorf("ListProducts(%+v): %v", filter, err)
+					return
+				}
+				for _, hit := range listing.Hits {
+					checkStressHit(t, hit.Product)
+				}
+
+				for _, completion := range ps.SuggestProducts(ctx, "stress", 5) {
+					if completion.Text == "" {
+						t.Errorf("SuggestProducts returned an empty completion")
+					}
+				}
+				if _, err := ps.GetCategories(ctx); err != nil {
+					t.Errorf("GetCategories: %v", err)
+					return
+				}
+			}
+		}(r)
+	}
+
+	writersWG.Wait()
+	close(done)
+	readersWG.Wait()
+
+	// Every kept product is listed once, with its patched price, and deleted ones are gone
+	listing, err := ps.ListProducts(ctx, models.ProductFilter{Query: "stresstest"})
+	if err != nil {
+		t.Fatalf("ListProducts: %v", err)
+	}
+	if want := writers * iterations / 2; len(listing.Hits) != want {
+		t.Errorf("search found %d stress products, want %d", len(listing.Hits), want)
+	}
+	for _, hit := range listing.Hits {
+		checkStressHit(t, hit.Product)
+		if hit.Price != 99 {
+			t.Errorf("product %d has price %v, want 99", hit.ID, hit.Price)
+		}
+	}
+	if len(ps.SuggestProducts(ctx, "stresstest", 5)) == 0 {
+		t.Errorf("SuggestProducts found no completions for the kept products")
+	}
+}
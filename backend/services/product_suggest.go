@@ -0,0 +1,58 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"ecommerce-backend/logger"
+	"ecommerce-backend/models"
+	"ecommerce-backend/search"
+)
+
+// Default and maximum number of autocomplete suggestions per request
+const (
+	DefaultSuggestLimit = 8
+	MaxSuggestLimit     = 25
+)
+
+// Kinds of autocomplete suggestions
+const (
+	SuggestionKindName     = "name"
+	SuggestionKindCategory = "category"
+	SuggestionKindColor    = "color"
+)
+
+// completionEntries returns the phrases a product contributes to autocomplete
+func completionEntries(product models.Product) []search.Entry {
+	entries := []search.Entry{
+		{Kind: SuggestionKindName, Text: product.Name},
+		{Kind: SuggestionKindCategory, Text: product.Category},
+	}
+	for _, color := range product.Colors {
+		entries = append(entries, search.Entry{Kind: SuggestionKindColor, Text: color})
+	}
+	return entries
+}
+
+// SuggestProducts returns up to limit completions of prefix drawn from product
+// names, categories and colors. It only reads the in-memory trie, so it is cheap
+// enough to call on every keystroke.
+func (ps *ProductService) SuggestProducts(ctx context.Context, prefix string, limit int) []search.Completion {
+	ps.mu.RLock()
+	defer ps.mu.RUnlock()
+
+	start := time.Now()
+
+	params := map[string]interface{}{
+		"prefix": prefix,
+		"limit":  limit,
+	}
+	logger.LogServiceCallContext(ctx, "ProductService", "SuggestProducts", params)
+
+	completions := ps.completer.Complete(prefix, limit)
+
+	duration := float64(time.Since(start).Nanoseconds()) / 1e6
+	logger.LogServiceResultContext(ctx, "ProductService", "SuggestProducts", len(completions), duration)
+
+	return completions
+}
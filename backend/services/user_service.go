@@ -0,0 +1,484 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"ecommerce-backend/auth"
+	"ecommerce-backend/logger"
+	"ecommerce-backend/models"
+	"ecommerce-backend/repository"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// User and session errors
+var (
+	// ErrUserNotFound is returned when a requested user does not exist
+	ErrUserNotFound = repository.ErrUserNotFound
+	// ErrEmailTaken is returned when registering an email that already has an account
+	ErrEmailTaken = repository.ErrEmailTaken
+	// ErrInvalidCredentials is returned for an unknown email or a wrong password
+	ErrInvalidCredentials = errors.New("invalid email or password")
+	// ErrAccountLocked is returned while an account is locked after repeated failed logins
+	ErrAccountLocked = errors.New("account is temporarily locked")
+	// ErrInvalidRefreshToken is returned for unknown, used or expired refresh tokens
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+	// ErrInvalidVerificationToken is returned for unknown or used email verification tokens
+	ErrInvalidVerificationToken = errors.New("invalid verification token")
+)
+
+// AccountLockedError carries when a locked account can try again
+type AccountLockedError struct {
+	Until time.Time
+}
+
+func (e *AccountLockedError) Error() string {
+	return ErrAccountLocked.Error()
+}
+
+// Unwrap lets errors.Is match ErrAccountLocked
+func (e *AccountLockedError) Unwrap() error {
+	return ErrAccountLocked
+}
+
+// VerificationSender delivers email verification tokens to newly registered users
+type VerificationSender interface {
+	SendVerification(ctx context.Context, user models.User, token string) error
+}
+
+// UndeliveredVerificationSender is used while no email sender is configured.
+// It logs that a token was issued, but never the token itself.
+type UndeliveredVerificationSender struct{}
+
+// SendVerification logs that the token could not be delivered
+func (UndeliveredVerificationSender) SendVerification(ctx context.Context, user models.User, token string) error {
+	logger.WarnContext(ctx, "Email verification token issued but not delivered; no email sender is configured", map[string]interface{}{
+		"user_id": user.ID,
+	})
+	return nil
+}
+
+// DevVerificationSender prints verification tokens to stderr instead of
+// sending email. It is for local development only (ENV=development): the
+// token bypasses the logger, so it never reaches log files or log shipping.
+type DevVerificationSender struct{}
+
+// SendVerification prints the token
+func (DevVerificationSender) SendVerification(ctx context.Context, user models.User, token string) error {
+	fmt.Fprintf(os.Stderr, "[development] email verification token for user %s: %s\n", user.ID, token)
+	logger.InfoContext(ctx, "Email verification token printed to stderr (development)", map[string]interface{}{
+		"user_id": user.ID,
+	})
+	return nil
+}
+
+// UserServiceConfig holds the token and lockout settings of a UserService
+type UserServiceConfig struct {
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+	// Issuer and Audience are put into access tokens when set
+	Issuer   string
+	Audience string
+	// MaxFailedLogins wrong passwords in a row lock an account for LockoutDuration
+	MaxFailedLogins int
+	LockoutDuration time.Duration
+}
+
+// UserService manages accounts and sessions. Passwords are stored as bcrypt
+// hashes; sessions are a short-lived JWT access token plus a single-use refresh
+// token that is rotated on every refresh.
+type UserService struct {
+	// mu serializes updates to failed login counters
+	mu           sync.Mutex
+	repo         repository.UserRepository
+	signer       *auth.Signer
+	verification VerificationSender
+	config       UserServiceConfig
+	// dummyHash is compared against for unknown emails so they take as long as wrong passwords
+	dummyHash []byte
+}
+
+// NewUserService creates a UserService that signs access tokens with signer
+func NewUserService(repo repository.UserRepository, signer *auth.Signer, verification VerificationSender, config UserServiceConfig) (*UserService, error) {
+	dummyHash, err := bcrypt.GenerateFromPassword([]byte("not-a-real-password"), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+	return &UserService{
+		repo:         repo,
+		signer:       signer,
+		verification: verification,
+		config:       config,
+		dummyHash:    dummyHash,
+	}, nil
+}
+
+// Register creates a shopper account and sends it an email verification token
+func (us *UserService) Register(ctx context.Context, registration models.Registration) (models.User, error) {
+	start := time.Now()
+
+	params := map[string]interface{}{"email": models.NormalizeEmail(registration.Email)}
+	logger.LogServiceCallContext(ctx, "UserService", "Register", params)
+
+	if err := registration.Validate(); err != nil {
+		return models.User{}, err
+	}
+	user, err := us.newUser(registration, []string{auth.RoleShopper})
+	if err != nil {
+		logger.LogErrorContext(ctx, "UserService", "Register", err, params)
+		return models.User{}, err
+	}
+
+	token, err := newSecretToken()
+	if err != nil {
+		logger.LogErrorContext(ctx, "UserService", "Register", err, params)
+		return models.User{}, err
+	}
+	user.VerificationTokenHash = hashToken(token)
+
+	if err := us.repo.Create(user); err != nil {
+		if !errors.Is(err, ErrEmailTaken) {
+			logger.LogErrorContext(ctx, "UserService", "Register", err, params)
+		}
+		return models.User{}, err
+	}
+
+	// The account exists either way, so a failed send is logged rather than undoing it
+	if err := us.verification.SendVerification(ctx, user, token); err != nil {
+		logger.LogErrorContext(ctx, "UserService", "Register", err, map[string]interface{}{
+			"user_id":      user.ID,
+			"verification": true,
+		})
+	}
+
+	duration := float64(time.Since(start).Nanoseconds()) / 1e6
+	logger.LogServiceResultContext(ctx, "UserService", "Register", 1, duration)
+
+	logger.InfoContext(ctx, "User registered", map[string]interface{}{
+		"user_id": user.ID,
+		"email":   user.Email,
+	})
+
+	return user, nil
+}
+
+// EnsureUser creates an account with the given roles unless the email is
+// already registered. It is used to bootstrap staff accounts at startup.
+func (us *UserService) EnsureUser(registration models.Registration, roles []string) (models.User, error) {
+	if err := registration.Validate(); err != nil {
+		return models.User{}, err
+	}
+	if existing, err := us.repo.GetByEmail(models.NormalizeEmail(registration.Email)); err == nil {
+		return *existing, nil
+	}
+
+	user, err := us.newUser(registration, roles)
+	if err != nil {
+		return models.User{}, err
+	}
+	user.EmailVerified = true
+	if err := us.repo.Create(user); err != nil {
+		return models.User{}, err
+	}
+	return user, nil
+}
+
+// newUser builds an account with a hashed password
+func (us *UserService) newUser(registration models.Registration, roles []string) (models.User, error) {
+	id, err := newRandomID()
+	if err != nil {
+		return models.User{}, err
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(registration.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return models.User{}, err
+	}
+
+	now := time.Now().UTC()
+	return models.User{
+		ID:           id,
+		Email:        models.NormalizeEmail(registration.Email),
+		Name:         strings.TrimSpace(registration.Name),
+		Roles:        roles,
+		PasswordHash: hash,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}, nil
+}
+
+// VerifyEmail marks the account holding token as verified
+func (us *UserService) VerifyEmail(ctx context.Context, token string) (models.User, error) {
+	start := time.Now()
+	logger.LogServiceCallContext(ctx, "UserService", "VerifyEmail", nil)
+
+	user, err := us.repo.GetByVerificationToken(hashToken(token))
+	if err != nil {
+		if errors.Is(err, ErrUserNotFound) {
+			return models.User{}, ErrInvalidVerificationToken
+		}
+		logger.LogErrorContext(ctx, "UserService", "VerifyEmail", err, nil)
+		return models.User{}, err
+	}
+
+	user.EmailVerified = true
+	user.VerificationTokenHash = ""
+	user.UpdatedAt = time.Now().UTC()
+	if err := us.repo.Update(*user); err != nil {
+		logger.LogErrorContext(ctx, "UserService", "VerifyEmail", err, map[string]interface{}{"user_id": user.ID})
+		return models.User{}, err
+	}
+
+	duration := float64(time.Since(start).Nanoseconds()) / 1e6
+	logger.LogServiceResultContext(ctx, "UserService", "VerifyEmail", 1, duration)
+
+	logger.InfoContext(ctx, "User email verified", map[string]interface{}{"user_id": user.ID})
+	return *user, nil
+}
+
+// Login checks an email and password and starts a session. After
+// MaxFailedLogins wrong passwords in a row the account is locked for
+// LockoutDuration, and logins fail even with the right password. Unknown
+// emails, wrong passwords and locked accounts all get ErrInvalidCredentials
+// after a bcrypt comparison, so callers can't tell them apart.
+func (us *UserService) Login(ctx context.Context, request models.LoginRequest) (models.TokenPair, error) {
+	start := time.Now()
+
+	email := models.NormalizeEmail(request.Email)
+	params := map[string]interface{}{"email": email}
+	logger.LogServiceCallContext(ctx, "UserService", "Login", params)
+
+	user, err := us.repo.GetByEmail(email)
+	if err != nil {
+		if !errors.Is(err, ErrUserNotFound) {
+			logger.LogErrorContext(ctx, "UserService", "Login", err, params)
+			return models.TokenPair{}, err
+		}
+		bcrypt.CompareHashAndPassword(us.dummyHash, []byte(request.Password))
+		logger.WarnContext(ctx, "Login failed", map[string]interface{}{"email": email, "reason": "unknown_email"})
+		return models.TokenPair{}, ErrInvalidCredentials
+	}
+
+	now := time.Now().UTC()
+	if user.Locked(now) {
+		bcrypt.CompareHashAndPassword(us.dummyHash, []byte(request.Password))
+		logger.WarnContext(ctx, "Login failed", map[string]interface{}{
+			"user_id":      user.ID,
+			"reason":       "account_locked",
+			"locked_until": user.LockedUntil,
+		})
+		return models.TokenPair{}, ErrInvalidCredentials
+	}
+
+	if err := bcrypt.CompareHashAndPassword(user.PasswordHash, []byte(request.Password)); err != nil {
+		return models.TokenPair{}, us.recordFailedLogin(ctx, user, now)
+	}
+
+	if user.FailedLogins > 0 || user.LockedUntil != nil {
+		if err := us.resetFailedLogins(user.ID); err != nil {
+			logger.LogErrorContext(ctx, "UserService", "Login", err, params)
+			return models.TokenPair{}, err
+		}
+	}
+
+	tokens, err := us.issueTokens(*user)
+	if err != nil {
+		logger.LogErrorContext(ctx, "UserService", "Login", err, params)
+		return models.TokenPair{}, err
+	}
+
+	duration := float64(time.Since(start).Nanoseconds()) / 1e6
+	logger.LogServiceResultContext(ctx, "UserService", "Login", 1, duration)
+
+	logger.InfoContext(ctx, "User logged in", map[string]interface{}{"user_id": user.ID})
+	return tokens, nil
+}
+
+// recordFailedLogin counts a wrong password and locks the account when there
+// were too many. It always returns ErrInvalidCredentials unless storage fails.
+func (us *UserService) recordFailedLogin(ctx context.Context, user *models.User, now time.Time) error {
+	us.mu.Lock()
+	defer us.mu.Unlock()
+
+	// Re-read so concurrent failures are all counted
+	user, err := us.repo.Get(user.ID)
+	if err != nil {
+		logger.LogErrorContext(ctx, "UserService", "Login", err, nil)
+		return err
+	}
+	user.FailedLogins++
+	locked := us.config.MaxFailedLogins > 0 && user.FailedLogins >= us.config.MaxFailedLogins
+	if locked {
+		until := now.Add(us.config.LockoutDuration)
+		user.LockedUntil = &until
+		user.FailedLogins = 0
+	}
+	if err := us.repo.Update(*user); err != nil {
+		logger.LogErrorContext(ctx, "UserService", "Login", err, map[string]interface{}{"user_id": user.ID})
+		return err
+	}
+
+	if locked {
+		logger.LogAuditContext(ctx, "account_lockout", "locked", map[string]interface{}{
+			"user_id":      user.ID,
+			"locked_until": user.LockedUntil,
+		})
+		return ErrInvalidCredentials
+	}
+	logger.WarnContext(ctx, "Login failed", map[string]interface{}{
+		"user_id":       user.ID,
+		"reason":        "wrong_password",
+		"failed_logins": user.FailedLogins,
+	})
+	return ErrInvalidCredentials
+}
+
+// resetFailedLogins clears the failed login counter after a successful login
+func (us *UserService) resetFailedLogins(id string) error {
+	us.mu.Lock()
+	defer us.mu.Unlock()
+
+	user, err := us.repo.Get(id)
+	if err != nil {
+		return err
+	}
+	user.FailedLogins = 0
+	user.LockedUntil = nil
+	return us.repo.Update(*user)
+}
+
+// Refresh exchanges a refresh token for a new session. Each refresh token works once.
+func (us *UserService) Refresh(ctx context.Context, refreshToken string) (models.TokenPair, error) {
+	start := time.Now()
+	logger.LogServiceCallContext(ctx, "UserService", "Refresh", nil)
+
+	stored, err := us.repo.TakeRefreshToken(hashToken(refreshToken))
+	if err != nil {
+		if errors.Is(err, repository.ErrRefreshTokenNotFound) {
+			logger.WarnContext(ctx, "Refresh rejected", map[string]interface{}{"reason": "unknown_token"})
+			return models.TokenPair{}, ErrInvalidRefreshToken
+		}
+		logger.LogErrorContext(ctx, "UserService", "Refresh", err, nil)
+		return models.TokenPair{}, err
+	}
+	if time.Now().After(stored.ExpiresAt) {
+		logger.WarnContext(ctx, "Refresh rejected", map[string]interface{}{"reason": "expired", "user_id": stored.UserID})
+		return models.TokenPair{}, ErrInvalidRefreshToken
+	}
+
+	params := map[string]interface{}{"user_id": stored.UserID}
+	user, err := us.repo.Get(stored.UserID)
+	if err != nil {
+		if errors.Is(err, ErrUserNotFound) {
+			return models.TokenPair{}, ErrInvalidRefreshToken
+		}
+		logger.LogErrorContext(ctx, "UserService", "Refresh", err, params)
+		return models.TokenPair{}, err
+	}
+	if user.Locked(time.Now()) {
+		return models.TokenPair{}, &AccountLockedError{Until: *user.LockedUntil}
+	}
+
+	tokens, err := us.issueTokens(*user)
+	if err != nil {
+		logger.LogErrorContext(ctx, "UserService", "Refresh", err, params)
+		return models.TokenPair{}, err
+	}
+
+	duration := float64(time.Since(start).Nanoseconds()) / 1e6
+	logger.LogServiceResultContext(ctx, "UserService", "Refresh", 1, duration)
+
+	return tokens, nil
+}
+
+// GetUser returns a user or ErrUserNotFound
+func (us *UserService) GetUser(ctx context.Context, id string) (*models.User, error) {
+	start := time.Now()
+
+	params := map[string]interface{}{"user_id": id}
+	logger.LogServiceCallContext(ctx, "UserService", "GetUser", params)
+
+	user, err := us.repo.Get(id)
+	if err != nil {
+		if !errors.Is(err, ErrUserNotFound) {
+			logger.LogErrorContext(ctx, "UserService", "GetUser", err, params)
+		}
+		return nil, err
+	}
+
+	duration := float64(time.Since(start).Nanoseconds()) / 1e6
+	logger.LogServiceResultContext(ctx, "UserService", "GetUser", 1, duration)
+
+	return user, nil
+}
+
+// issueTokens mints an access token and stores a new refresh token for user
+func (us *UserService) issueTokens(user models.User) (models.TokenPair, error) {
+	now := time.Now()
+	jti, err := newRandomID()
+	if err != nil {
+		return models.TokenPair{}, err
+	}
+
+	claims := auth.Claims{
+		Subject:   user.ID,
+		Email:     user.Email,
+		Roles:     user.Roles,
+		Issuer:    us.config.Issuer,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(us.config.AccessTokenTTL).Unix(),
+		ID:        jti,
+	}
+	if us.config.Audience != "" {
+		claims.Audience = auth.Audience{us.config.Audience}
+	}
+	accessToken, err := us.signer.Sign(claims)
+	if err != nil {
+		return models.TokenPair{}, err
+	}
+
+	refreshToken, err := newSecretToken()
+	if err != nil {
+		return models.TokenPair{}, err
+	}
+	err = us.repo.SaveRefreshToken(models.RefreshToken{
+		Hash:      hashToken(refreshToken),
+		UserID:    user.ID,
+		ExpiresAt: now.Add(us.config.RefreshTokenTTL),
+	})
+	if err != nil {
+		return models.TokenPair{}, err
+	}
+
+	return models.TokenPair{
+		AccessToken:  accessToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(us.config.AccessTokenTTL.Seconds()),
+		RefreshToken: refreshToken,
+		User:         user,
+	}, nil
+}
+
+// newSecretToken returns a random 256-bit URL-safe token
+func newSecretToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("generate token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// hashToken returns the SHA-256 of a secret token. Only hashes are stored, so a
+// leaked store doesn't hand out working tokens.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
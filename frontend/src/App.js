@@ -6,74 +6,96 @@ import Home from './components/Home';
 import ProductDetail from './components/ProductDetail';
 import Cart from './components/Cart';
 
+const API_URL = 'http://localhost:8080/api';
+
+// Map server cart lines to the shape the Cart component renders
+const toCartItems = (cart) =>
+  (cart ? cart.items : []).map(line => ({
+    id: line.productId,
+    name: line.name,
+    image: line.image,
+    price: line.unitPrice,
+    selectedSize: line.size,
+    selectedColor: line.color,
+    quantity: line.quantity,
+    available: line.available,
+    problem: line.problem
+  }));
+
 function App() {
-  const [cartItems, setCartItems] = useState([]);
+  const [cart, setCart] = useState(null);
   const [showCart, setShowCart] = useState(false);
 
-  // Load cart from localStorage on mount
+  // Load the server-side cart whose ID is kept in localStorage, creating one if needed
   useEffect(() => {
-    const savedCart = localStorage.getItem('cart');
-    if (savedCart) {
-      setCartItems(JSON.parse(savedCart));
-    }
+    const loadCart = async () => {
+      const savedCartId = localStorage.getItem('cartId');
+      if (savedCartId) {
+        const response = await fetch(`${API_URL}/carts/${savedCartId}/items`);
+        if (response.ok) {
+          setCart(await response.json());
+          return;
+        }
+      }
+      const response = await fetch(`${API_URL}/carts`, { method: 'POST' });
+      const created = await response.json();
+      localStorage.setItem('cartId', created.id);
+      setCart(created);
+    };
+    loadCart().catch(error => console.error('Error loading cart:', error));
   }, []);
 
-  // Save cart to localStorage whenever it changes
-  useEffect(() => {
-    localStorage.setItem('cart', JSON.stringify(cartItems));
-  }, [cartItems]);
+  // Apply a cart change on the server and show the repriced cart it returns.
+  // Resolves to false when the server rejected the change.
+  const updateCart = async (request) => {
+    try {
+      const response = await request;
+      if (!response.ok) {
+        alert(await response.text());
+        return false;
+      }
+      setCart(await response.json());
+      return true;
+    } catch (error) {
+      console.error('Error updating cart:', error);
+      return false;
+    }
+  };
+
+  const setItemQuantity = (productId, size, color, quantity) =>
+    cart && updateCart(fetch(`${API_URL}/carts/${cart.id}/items`, {
+      method: 'PUT',
+      headers: { 'Content-Type': 'application/json' },
+      body: JSON.stringify({ productId, size, color, quantity })
+    }));
 
   const addToCart = (product, selectedSize, selectedColor, quantity = 1) => {
-    const existingItem = cartItems.find(
+    const existingItem = toCartItems(cart).find(
       item => 
         item.id === product.id && 
         item.selectedSize === selectedSize && 
         item.selectedColor === selectedColor
     );
-
-    if (existingItem) {
-      setCartItems(cartItems.map(item =>
-        item.id === product.id && 
-        item.selectedSize === selectedSize && 
-        item.selectedColor === selectedColor
-          ? { ...item, quantity: item.quantity + quantity }
-          : item
-      ));
-    } else {
-      setCartItems([...cartItems, {
-        ...product,
-        selectedSize,
-        selectedColor,
-        quantity
-      }]);
-    }
+    const currentQuantity = existingItem ? existingItem.quantity : 0;
+    return setItemQuantity(product.id, selectedSize, selectedColor, currentQuantity + quantity);
   };
 
   const removeFromCart = (id, selectedSize, selectedColor) => {
-    setCartItems(cartItems.filter(item => 
-      !(item.id === id && item.selectedSize === selectedSize && item.selectedColor === selectedColor)
-    ));
+    const params = new URLSearchParams({ productId: id, size: selectedSize, color: selectedColor });
+    return cart && updateCart(fetch(`${API_URL}/carts/${cart.id}/items?${params}`, { method: 'DELETE' }));
   };
 
-  const updateQuantity = (id, selectedSize, selectedColor, newQuantity) => {
-    if (newQuantity <= 0) {
-      removeFromCart(id, selectedSize, selectedColor);
-      return;
-    }
+  const updateQuantity = (id, selectedSize, selectedColor, newQuantity) =>
+    setItemQuantity(id, selectedSize, selectedColor, Math.max(0, newQuantity));
 
-    setCartItems(cartItems.map(item =>
-      item.id === id && item.selectedSize === selectedSize && item.selectedColor === selectedColor
-        ? { ...item, quantity: newQuantity }
-        : item
-    ));
-  };
+  const cartItems = toCartItems(cart);
 
   const getTotalItems = () => {
-    return cartItems.reduce((total, item) => total + item.quantity, 0);
+    return cart ? cart.itemCount : 0;
   };
 
   const getTotalPrice = () => {
-    return cartItems.reduce((total, item) => total + (item.price * item.quantity), 0).toFixed(2);
+    return (cart ? cart.total : 0).toFixed(2);
   };
 
   return (
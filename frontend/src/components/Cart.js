@@ -33,6 +33,9 @@ const Cart = ({ items, onClose, onRemove, onUpdateQuantity, totalPrice }) => {
                   <div className="cart-item-info">
                     <div className="cart-item-name">{item.name}</div>
                     <div className="cart-item-price">${item.price}</div>
+                    {item.problem && (
+                      <div style={{ fontSize: '0.9rem', color: '#e74c3c' }}>{item.problem}</div>
+                    )}
                     <div style={{ fontSize: '0.9rem', color: '#666' }}>
                       Size: {item.selectedSize} | Color: {item.selectedColor}
                     </div>
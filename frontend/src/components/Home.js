@@ -2,19 +2,20 @@ import React, { useState, useEffect } from 'react';
 import { Link, useSearchParams } from 'react-router-dom';
 import ProductCard from './ProductCard';
 
+const PAGE_SIZE = 12;
+
 const Home = ({ addToCart }) => {
   const [products, setProducts] = useState([]);
-  const [categories, setCategories] = useState([]);
-  const [filteredProducts, setFilteredProducts] = useState([]);
+  const [facets, setFacets] = useState({ category: [], gender: [] });
   const [selectedGender, setSelectedGender] = useState('');
   const [selectedCategory, setSelectedCategory] = useState('');
+  const [nextCursor, setNextCursor] = useState('');
+  const [totalProducts, setTotalProducts] = useState(0);
   const [loading, setLoading] = useState(true);
+  const [loadingMore, setLoadingMore] = useState(false);
   const [searchParams] = useSearchParams();
 
   useEffect(() => {
-    fetchProducts();
-    fetchCategories();
-    
     // Check for gender filter in URL params
     const genderFromUrl = searchParams.get('gender');
     if (genderFromUrl) {
@@ -23,45 +24,49 @@ const Home = ({ addToCart }) => {
   }, [searchParams]);
 
   useEffect(() => {
-    filterProducts();
-  }, [products, selectedGender, selectedCategory]);
+    fetchProducts();
+  }, [selectedGender, selectedCategory]);
 
-  const fetchProducts = async () => {
-    try {
-      const response = await fetch('http://localhost:8080/api/products');
-      const data = await response.json();
-      setProducts(data);
-      setLoading(false);
-    } catch (error) {
-      console.error('Error fetching products:', error);
-      setLoading(false);
+  // Fetch a page of products; without a cursor the list starts over
+  const fetchProducts = async (cursor = '') => {
+    const params = new URLSearchParams({ limit: PAGE_SIZE });
+    if (selectedGender) {
+      params.set('gender', selectedGender);
+    }
+    if (selectedCategory) {
+      params.set('category', selectedCategory);
+    }
+    if (cursor) {
+      params.set('cursor', cursor);
     }
-  };
 
-  const fetchCategories = async () => {
     try {
-      const response = await fetch('http://localhost:8080/api/categories');
+      const response = await fetch(`http://localhost:8080/api/products?${params}`);
       const data = await response.json();
-      setCategories(data);
+      setProducts(previous => (cursor ? [...previous, ...data.items] : data.items));
+      setNextCursor(data.next_cursor || '');
+      setTotalProducts(data.total);
+      setFacets(data.facets);
     } catch (error) {
-      console.error('Error fetching categories:', error);
+      console.error('Error fetching products:', error);
     }
+    setLoading(false);
   };
 
-  const filterProducts = () => {
-    let filtered = products;
-
-    if (selectedGender) {
-      filtered = filtered.filter(product => product.gender === selectedGender);
-    }
-
-    if (selectedCategory) {
-      filtered = filtered.filter(product => product.category === selectedCategory);
-    }
+  const loadMore = async () => {
+    setLoadingMore(true);
+    await fetchProducts(nextCursor);
+    setLoadingMore(false);
+  };
 
-    setFilteredProducts(filtered);
+  // Facet count for a value, e.g. how many products are in a category
+  const facetCount = (facet, value) => {
+    const entry = facets[facet].find(option => option.value === value);
+    return entry ? entry.count : 0;
   };
 
+  const capitalize = (value) => value.charAt(0).toUpperCase() + value.slice(1);
+
   const handleQuickAdd = (product) => {
     // Quick add with default size and color
     const defaultSize = product.sizes && product.sizes.length > 0 ? product.sizes[0] : '';
@@ -101,8 +106,13 @@ const Home = ({ addToCart }) => {
                 onChange={(e) => setSelectedGender(e.target.value)}
               >
                 <option value="">All</option>
-                <option value="men">Men</option>
-                <option value="women">Women</option>
+                {['men', 'women']
+                  .filter(gender => gender === selectedGender || facetCount('gender', gender) > 0)
+                  .map(gender => (
+                    <option key={gender} value={gender}>
+                      {capitalize(gender)} ({facetCount('gender', gender)})
+                    </option>
+                  ))}
               </select>
             </div>
 
@@ -113,11 +123,14 @@ const Home = ({ addToCart }) => {
                 onChange={(e) => setSelectedCategory(e.target.value)}
               >
                 <option value="">All Categories</option>
-                {categories.map(category => (
-                  <option key={category} value={category}>
-                    {category.charAt(0).toUpperCase() + category.slice(1)}
+                {facets.category.map(({ value, count }) => (
+                  <option key={value} value={value}>
+                    {capitalize(value)} ({count})
                   </option>
                 ))}
+                {selectedCategory && facetCount('category', selectedCategory) === 0 && (
+                  <option value={selectedCategory}>{capitalize(selectedCategory)} (0)</option>
+                )}
               </select>
             </div>
 
@@ -144,14 +157,14 @@ const Home = ({ addToCart }) => {
             {selectedCategory && ` - ${selectedCategory.charAt(0).toUpperCase() + selectedCategory.slice(1)}`}
           </h2>
 
-          {filteredProducts.length === 0 ? (
+          {products.length === 0 ? (
             <div style={{ textAlign: 'center', padding: '3rem 0' }}>
               <h3>No products found</h3>
               <p>Try adjusting your filters to see more products.</p>
             </div>
           ) : (
             <div className="products-grid">
-              {filteredProducts.map(product => (
+              {products.map(product => (
                 <ProductCard
                   key={product.id}
                   product={product}
@@ -160,6 +173,18 @@ const Home = ({ addToCart }) => {
               ))}
             </div>
           )}
+
+          {nextCursor && (
+            <div style={{ textAlign: 'center', padding: '2rem 0' }}>
+              <button 
+                onClick={loadMore}
+                className="btn btn-secondary"
+                disabled={loadingMore}
+              >
+                {loadingMore ? 'Loading...' : `Load More (${products.length} of ${totalProducts})`}
+              </button>
+            </div>
+          )}
         </div>
       </section>
     </div>
@@ -17,7 +17,13 @@ const ProductDetail = ({ addToCart }) => {
 
   useEffect(() => {
     if (product) {
-      // Set default selections
+      // Set default selections, preferring the first variant in stock
+      const firstAvailable = (product.variants || []).find(variant => variant.available);
+      if (firstAvailable) {
+        setSelectedSize(firstAvailable.size);
+        setSelectedColor(firstAvailable.color);
+        return;
+      }
       if (product.sizes && product.sizes.length > 0) {
         setSelectedSize(product.sizes[0]);
       }
@@ -27,6 +33,19 @@ const ProductDetail = ({ addToCart }) => {
     }
   }, [product]);
 
+  // Variant for a size and color; undefined when the product doesn't track inventory
+  const findVariant = (size, color) =>
+    (product.variants || []).find(variant => variant.size === size && variant.color === color);
+
+  // Whether a size and color can be bought; products without variants are always available
+  const isAvailable = (size, color) => {
+    if (!product.variants || product.variants.length === 0) {
+      return product.inStock;
+    }
+    const variant = findVariant(size, color);
+    return Boolean(variant && variant.available);
+  };
+
   const fetchProduct = async () => {
     try {
       const response = await fetch(`http://localhost:8080/api/products/${id}`);
@@ -42,14 +61,19 @@ const ProductDetail = ({ addToCart }) => {
     }
   };
 
-  const handleAddToCart = () => {
+  const handleAddToCart = async () => {
     if (!selectedSize || !selectedColor) {
       alert('Please select size and color');
       return;
     }
+    if (!isAvailable(selectedSize, selectedColor)) {
+      alert('This size and color is sold out');
+      return;
+    }
     
-    addToCart(product, selectedSize, selectedColor, quantity);
-    alert('Product added to cart!');
+    if (await addToCart(product, selectedSize, selectedColor, quantity)) {
+      alert('Product added to cart!');
+    }
   };
 
   if (loading) {
@@ -101,7 +125,9 @@ const ProductDetail = ({ addToCart }) => {
           {/* Product Details */}
           <div className="product-details">
             <h1>{product.name}</h1>
-            <div className="price">${product.price}</div>
+            <div className="price">
+              ${(findVariant(selectedSize, selectedColor) || {}).price || product.price}
+            </div>
             <p className="description">{product.description}</p>
 
             {/* Product Options */}
@@ -114,7 +140,7 @@ const ProductDetail = ({ addToCart }) => {
                     {product.sizes.map(size => (
                       <div
                         key={size}
-                        className={`size-option ${selectedSize === size ? 'selected' : ''}`}
+                        className={`size-option ${selectedSize === size ? 'selected' : ''} ${isAvailable(size, selectedColor) ? '' : 'sold-out'}`}
                         onClick={() => setSelectedSize(size)}
                       >
                         {size}
@@ -132,7 +158,7 @@ const ProductDetail = ({ addToCart }) => {
                     {product.colors.map(color => (
                       <div
                         key={color}
-                        className={`color-option ${selectedColor === color ? 'selected' : ''}`}
+                        className={`color-option ${selectedColor === color ? 'selected' : ''} ${isAvailable(selectedSize, color) ? '' : 'sold-out'}`}
                         onClick={() => setSelectedColor(color)}
                       >
                         {color}
@@ -171,8 +197,9 @@ const ProductDetail = ({ addToCart }) => {
                 onClick={handleAddToCart}
                 className="btn btn-primary"
                 style={{ marginRight: '1rem' }}
+                disabled={!isAvailable(selectedSize, selectedColor)}
               >
-                Add to Cart
+                {isAvailable(selectedSize, selectedColor) ? 'Add to Cart' : 'Sold Out'}
               </button>
               <Link to="/" className="btn btn-secondary">
                 Continue Shopping